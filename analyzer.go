@@ -1,22 +1,65 @@
 package analyzer
 
 import (
+	"context"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
-	"io/ioutil"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/user/code-review-assistant/internal/analyzer/patterns"
 	"github.com/user/code-review-assistant/internal/config"
 	"github.com/user/code-review-assistant/internal/models"
+	"github.com/user/code-review-assistant/internal/scanner"
 	"github.com/user/code-review-assistant/internal/security"
+	"github.com/user/code-review-assistant/internal/typecheck"
 )
 
+// Version is the version of the code review assistant
+const Version = "1.0.0"
+
 // Results represents the results of code analysis
 type Results struct {
 	Issues         []*models.Issue
+	Functions      []*models.Function
+	Files          int // Number of files analyzed
+	Lines          int // Lines of Go code in the analyzed files
+	CachedFiles    int // Number of files whose results were reused from the cache
+	Insights       []string // Project insights from machine learning
+	Warnings       []string // Problems that did not prevent the analysis
+	TotalIssues    int
+	CriticalIssues int
+	HighIssues     int
+	MediumIssues   int
+	LowIssues      int
+	Metrics        *Metrics // Aggregated counts of the issues, kept up to date by Recount
+	Repositories   []*RepoSummary // Counts per repository of results merged with Merge
+	Timings        Timings `json:"-"` // Durations of the phases of the analysis, which vary from run to run
+}
+
+// Timings are the durations of the phases of an analysis. The repository is scanned while
+// the files found are analyzed, so the scan overlaps the analysis.
+type Timings struct {
+	Scan     time.Duration // Walking the repository or looking up the files given
+	Analysis time.Duration // Parsing, type-checking, and analyzing the files
+	Gosec    time.Duration // Running gosec, zero when it did not run
+	Learning time.Duration // Adjusting and recording the issues with machine learning
+}
+
+// RepoSummary counts the files and issues of one of several repositories analyzed together
+type RepoSummary struct {
+	Name           string // Prefix of the paths of the repository's files and issues
+	Files          int
+	Lines          int
 	TotalIssues    int
 	CriticalIssues int
 	HighIssues     int
@@ -26,122 +69,680 @@ type Results struct {
 
 // Analyzer is responsible for analyzing code and finding issues
 type Analyzer struct {
+	rootPath       string
 	config         *config.Config
 	fset           *token.FileSet
 	patterns       []*patterns.Pattern
 	antiPatterns   []*patterns.AntiPattern
 	bestPractices  []*patterns.BestPractice
 	securityRules  []*security.CustomSecurityRule
+	customRules    []Rule // Enabled rules added with RegisterRule
 	securityScanner *security.GosecScanner
+	cache          *Cache
+	typed          bool // Whether an enabled rule uses type information
+	only           map[string]bool // Relative paths the analysis is limited to, nil for the whole repository
+	relaxed        map[string]bool // Rules of the issues not reported in test code
 }
 
-// NewAnalyzer creates a new code analyzer
-func NewAnalyzer(cfg *config.Config) *Analyzer {
-	return &Analyzer{
+// NewAnalyzer creates a new code analyzer for the repository at rootPath
+func NewAnalyzer(rootPath string, cfg *config.Config) *Analyzer {
+	a := &Analyzer{
+		rootPath:       rootPath,
 		config:         cfg,
 		fset:           token.NewFileSet(),
-		patterns:       patterns.GetGoPatterns(),
-		antiPatterns:   patterns.GetGoAntiPatterns(),
-		bestPractices:  patterns.GetGoBestPractices(),
-		securityRules:  security.GetCustomSecurityRules(),
 		securityScanner: security.NewGosecScanner(cfg),
 	}
+
+	// Issues name custom security rules by ID, which may be relaxed by name instead
+	a.relaxed = make(map[string]bool)
+	for _, rule := range cfg.RelaxInTests {
+		a.relaxed[rule] = true
+	}
+	for _, sr := range security.GetCustomSecurityRules(cfg) {
+		if cfg.RuleRelaxedInTests(sr.ID, sr.Name) {
+			a.relaxed[sr.ID] = true
+		}
+	}
+
+	// Only keep the rules that are enabled in the configuration
+	for _, p := range patterns.GetGoPatterns(cfg) {
+		if cfg.CategoryEnabled(p.Category) && cfg.RuleEnabled(p.Name) {
+			a.patterns = append(a.patterns, p)
+		}
+	}
+	for _, ap := range patterns.GetGoAntiPatterns(cfg) {
+		if cfg.CategoryEnabled(ap.Category) && cfg.RuleEnabled(ap.Name) {
+			a.antiPatterns = append(a.antiPatterns, ap)
+		}
+	}
+	for _, bp := range patterns.GetGoBestPractices(cfg) {
+		if cfg.CategoryEnabled(bp.Category) && cfg.RuleEnabled(bp.Name) {
+			a.bestPractices = append(a.bestPractices, bp)
+			a.typed = a.typed || bp.Typed
+		}
+	}
+	if cfg.CategoryEnabled("security") {
+		for _, sr := range security.GetCustomSecurityRules(cfg) {
+			if cfg.RuleEnabled(sr.ID, sr.Name) {
+				a.securityRules = append(a.securityRules, sr)
+			}
+		}
+	}
+
+	for _, rule := range RegisteredRules() {
+		if cfg.CategoryEnabled(rule.Category()) && cfg.RuleEnabled(rule.ID()) {
+			a.customRules = append(a.customRules, rule)
+		}
+	}
+
+	// Reuse the results of unchanged files from previous runs
+	if cfg.CachePath != "" {
+		cache, err := NewCache(cfg.CachePath, a.cacheKey())
+		if err != nil && cfg.Verbose {
+			println("Error loading analysis cache:", err.Error())
+		}
+		a.cache = cache
+	}
+
+	return a
 }
 
-// Analyze analyzes a list of files and returns the results
-func (a *Analyzer) Analyze(files []*models.File) (*Results, error) {
+// Analyze analyzes a list of files and returns the results. If the context is cancelled,
+// the results gathered so far are returned along with the context's error.
+func (a *Analyzer) Analyze(ctx context.Context, files []*models.File) (*Results, error) {
+	fileChan := make(chan *models.File, len(files))
+	for _, file := range files {
+		fileChan <- file
+	}
+	close(fileChan)
+
+	return a.AnalyzeStream(ctx, fileChan)
+}
+
+// AnalyzeStream analyzes the files received on a channel until it is closed and returns
+// the results. At most Config.Concurrency files are analyzed at the same time. If the
+// context is cancelled, no further files are analyzed and the results gathered so far
+// are returned along with the context's error.
+func (a *Analyzer) AnalyzeStream(ctx context.Context, files <-chan *models.File) (*Results, error) {
+	start := time.Now()
 	results := &Results{
-		Issues: make([]*models.Issue, 0),
+		Issues:    make([]*models.Issue, 0),
+		Functions: make([]*models.Function, 0),
+	}
+
+	workers := a.config.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// Packages are type-checked once per run, as files may have changed since the last one
+	var typed *typecheck.Cache
+	if a.typed {
+		typed = typecheck.NewCache(a.fset)
+		if a.config.Verbose {
+			typed.OnError = func(dir string, err error) {
+				println("Type information unavailable for", dir, ":", err.Error())
+			}
+		}
 	}
 
-	// Use a wait group to process files concurrently
+	// Use a bounded pool of workers to process files concurrently
 	var wg sync.WaitGroup
 	var mutex sync.Mutex
 
-	// Process each file
-	for _, file := range files {
+	// Files found and analyzed, for Config.OnProgress
+	var found, done int64
+	progress := func() {
+		analyzed := atomic.AddInt64(&done, 1)
+		if a.config.OnProgress != nil {
+			a.config.OnProgress(int(analyzed), int(atomic.LoadInt64(&found)))
+		}
+	}
+
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(f *models.File) {
+		go func() {
 			defer wg.Done()
 
-			// Analyze the file
-			issues, err := a.analyzeFile(f)
-			if err != nil {
-				if a.config.Verbose {
-					println("Error analyzing file", f.Path, ":", err.Error())
+			for {
+				var f *models.File
+				var ok bool
+				select {
+				case <-ctx.Done():
+					return
+				case f, ok = <-files:
 				}
-				return
-			}
+				if !ok {
+					return
+				}
+				atomic.AddInt64(&found, 1)
 
-			// Add issues to results
-			mutex.Lock()
-			results.Issues = append(results.Issues, issues...)
-			mutex.Unlock()
-		}(file)
+				mutex.Lock()
+				results.Files++
+				results.Lines += f.Lines
+				mutex.Unlock()
+
+				// Analyze the file unless its results are cached
+				issues, functions, cached := a.cachedResults(f)
+				if cached {
+					mutex.Lock()
+					results.CachedFiles++
+					mutex.Unlock()
+				} else {
+					var err error
+					issues, functions, err = a.analyzeFile(ctx, f, typed)
+					if err != nil {
+						if ctx.Err() != nil {
+							return
+						}
+						if a.config.Verbose {
+							println("Error analyzing file", f.Path, ":", err.Error())
+						}
+						progress()
+						continue
+					}
+
+					if a.cache != nil {
+						if err := a.cache.Put(f, a.dependencies(f, typed), issues, functions); err != nil && a.config.Verbose {
+							println("Error caching results for", f.Path, ":", err.Error())
+						}
+					}
+				}
+
+				// Add issues to results
+				mutex.Lock()
+				results.Issues = append(results.Issues, issues...)
+				results.Functions = append(results.Functions, functions...)
+				mutex.Unlock()
+				progress()
+			}
+		}()
 	}
 
 	// Wait for all files to be processed
 	wg.Wait()
+	results.Timings.Analysis = time.Since(start)
 
-	// Run security scanner on the repository
-	if len(files) > 0 {
-		// Get repository path from the first file
-		repoPath := files[0].Path
-		for i := 0; i < len(repoPath); i++ {
-			if repoPath[i:] == files[0].RelPath {
-				repoPath = repoPath[:i]
-				break
-			}
+	if a.cache != nil {
+		if err := a.cache.Save(); err != nil && a.config.Verbose {
+			println("Error saving analysis cache:", err.Error())
 		}
+	}
+
+	// Stop with partial results if cancelled
+	if err := ctx.Err(); err != nil {
+		results.Recount()
+		return results, err
+	}
 
-		// Run security scanner
-		securityIssues, err := a.securityScanner.Scan(repoPath)
+	// Run security scanner on the repository if gosec is installed
+	runGosec := results.Files > 0 && a.config.CategoryEnabled("security") && a.config.EnableGosec
+	if runGosec && !a.securityScanner.Available() {
+		if a.config.Verbose {
+			println("Warning: gosec not found in PATH, skipping gosec scan")
+		}
+		runGosec = false
+	}
+	if runGosec {
+		gosecStart := time.Now()
+		securityIssues, err := a.securityScanner.Scan(ctx, a.rootPath, a.onlyDirs()...)
+		results.Timings.Gosec = time.Since(gosecStart)
 		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				results.Recount()
+				return results, ctxErr
+			}
 			if a.config.Verbose {
 				println("Error running security scanner:", err.Error())
 			}
 		} else {
 			// Add security issues to results
 			mutex.Lock()
-			results.Issues = append(results.Issues, securityIssues...)
+			for _, issue := range securityIssues {
+				if !a.config.RuleEnabled(issue.Rule) || (a.only != nil && !a.only[issue.File]) {
+					continue
+				}
+				if a.relaxed[issue.Rule] && a.config.TestFile(issue.File) {
+					continue
+				}
+				a.applySeverityOverride(issue)
+				issue.ID = issue.Fingerprint()
+				results.Issues = append(results.Issues, issue)
+			}
+			if a.config.DedupeSecurity {
+				results.Issues = dedupeSecurity(results.Issues)
+			}
 			mutex.Unlock()
 		}
 	}
 
+	// Scan the files that are not Go source for secrets, with the whole repository
+	if a.config.ScanNonGoSecrets && a.only == nil && a.config.CategoryEnabled("security") && a.config.RuleEnabled("CS001", "hardcoded-secret") {
+		secretIssues, err := a.scanTextSecrets(ctx)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				results.Recount()
+				return results, ctxErr
+			}
+			if a.config.Verbose {
+				println("Error scanning files for secrets:", err.Error())
+			}
+		}
+		results.Issues = append(results.Issues, secretIssues...)
+	}
+
 	// Count issues by severity
-	for _, issue := range results.Issues {
-		results.TotalIssues++
+	results.Recount()
+
+	return results, nil
+}
+
+// scanTextSecrets returns the hardcoded secrets in the files of the repository that are not
+// Go source and are selected by secret_scan_extensions
+func (a *Analyzer) scanTextSecrets(ctx context.Context) ([]*models.Issue, error) {
+	secrets := security.NewTextSecretScanner(a.config)
+	var issues []*models.Issue
+	err := scanner.NewScanner(a.rootPath, a.config).WalkSecretFiles(func(file *models.File) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		found, err := secrets.Scan(file)
+		if err != nil {
+			if a.config.Verbose {
+				println("Error scanning", file.Path, "for secrets:", err.Error())
+			}
+			return nil
+		}
+		for _, issue := range found {
+			if a.relaxed[issue.Rule] && a.config.TestFile(issue.File) {
+				continue
+			}
+			a.applySeverityOverride(issue)
+			issue.ID = issue.Fingerprint()
+			issues = append(issues, issue)
+		}
+		return nil
+	})
+	return issues, err
+}
+
+// dedupeSecurity removes the duplicates among issues that gosec and a custom security rule
+// report for the same problem on the same line. The issue with the higher confidence is
+// kept, or the custom rule's if both are equally confident.
+func dedupeSecurity(issues []*models.Issue) []*models.Issue {
+	key := func(issue *models.Issue, rule string) string {
+		return fmt.Sprintf("%s:%d:%s:%s", issue.File, issue.Line, issue.Category, rule)
+	}
+
+	custom := make(map[string]*models.Issue)
+	for _, issue := range issues {
+		if _, ok := custom[key(issue, issue.Rule)]; !ok {
+			custom[key(issue, issue.Rule)] = issue
+		}
+	}
+
+	dropped := make(map[*models.Issue]bool)
+	for _, issue := range issues {
+		rule, ok := security.CustomRuleFor(issue.Rule)
+		if !ok {
+			continue
+		}
+		other, ok := custom[key(issue, rule)]
+		if !ok || dropped[other] {
+			continue
+		}
+		if models.ConfidenceScore(issue.Confidence) > models.ConfidenceScore(other.Confidence) {
+			dropped[other] = true
+		} else {
+			dropped[issue] = true
+		}
+	}
+	if len(dropped) == 0 {
+		return issues
+	}
+
+	kept := make([]*models.Issue, 0, len(issues)-len(dropped))
+	for _, issue := range issues {
+		if !dropped[issue] {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
+
+// onlyDirs returns the directories of the files the analysis is limited to, or nil when
+// the whole repository is analyzed
+func (a *Analyzer) onlyDirs() []string {
+	if a.only == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	dirs := make([]string, 0)
+	for relPath := range a.only {
+		dir := filepath.Dir(relPath)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// dependencies returns the directories whose files the results of a file depend on besides
+// the file itself: with rules using type information, the directories the type information
+// of its package comes from, or the directory of the file when the package could not be
+// type-checked, as fixing it changes the results too
+func (a *Analyzer) dependencies(file *models.File, typed *typecheck.Cache) []string {
+	if !a.typed {
+		return nil
+	}
+	if t := typed.File(file.Path); t != nil {
+		return t.Dirs
+	}
+	return []string{filepath.Dir(typecheck.AbsPath(file.Path))}
+}
+
+// cachedResults returns copies of the cached issues and functions of a file, if any
+func (a *Analyzer) cachedResults(file *models.File) ([]*models.Issue, []*models.Function, bool) {
+	if a.cache == nil {
+		return nil, nil, false
+	}
+
+	cachedIssues, cachedFunctions, ok := a.cache.Get(file)
+	if !ok {
+		return nil, nil, false
+	}
+
+	// Callers may modify the results, e.g. when applying machine learning
+	issues := make([]*models.Issue, len(cachedIssues))
+	for i, issue := range cachedIssues {
+		copied := *issue
+		issues[i] = &copied
+	}
+	functions := make([]*models.Function, len(cachedFunctions))
+	for i, function := range cachedFunctions {
+		copied := *function
+		functions[i] = &copied
+	}
+
+	return issues, functions, true
+}
+
+// Recount recomputes the issue totals by severity and the metrics, e.g. after issues have
+// been filtered
+func (r *Results) Recount() {
+	r.Metrics = newMetrics(r.Files, r.Lines, r.Issues)
+
+	r.TotalIssues = 0
+	r.CriticalIssues = 0
+	r.HighIssues = 0
+	r.MediumIssues = 0
+	r.LowIssues = 0
+
+	for _, repo := range r.Repositories {
+		repo.TotalIssues = 0
+		repo.CriticalIssues = 0
+		repo.HighIssues = 0
+		repo.MediumIssues = 0
+		repo.LowIssues = 0
+	}
+
+	for _, issue := range r.Issues {
+		r.TotalIssues++
+		switch issue.Severity {
+		case "critical":
+			r.CriticalIssues++
+		case "high":
+			r.HighIssues++
+		case "medium":
+			r.MediumIssues++
+		case "low":
+			r.LowIssues++
+		}
+
+		repo := r.repository(issue.File)
+		if repo == nil {
+			continue
+		}
+		repo.TotalIssues++
 		switch issue.Severity {
 		case "critical":
-			results.CriticalIssues++
+			repo.CriticalIssues++
 		case "high":
-			results.HighIssues++
+			repo.HighIssues++
 		case "medium":
-			results.MediumIssues++
+			repo.MediumIssues++
 		case "low":
-			results.LowIssues++
+			repo.LowIssues++
 		}
 	}
+}
 
-	return results, nil
+// repository returns the summary of the merged repository a file belongs to, or nil
+func (r *Results) repository(file string) *RepoSummary {
+	for _, repo := range r.Repositories {
+		if strings.HasPrefix(file, repo.Name+"/") {
+			return repo
+		}
+	}
+	return nil
 }
 
-// analyzeFile analyzes a single file and returns a list of issues
-func (a *Analyzer) analyzeFile(file *models.File) ([]*models.Issue, error) {
-	issues := make([]*models.Issue, 0)
+// Merge adds the results of the analysis of another repository, prefixing the paths of its
+// issues and functions with name and a slash, and recounts the totals. The IDs of the
+// issues are kept, so feedback and baselines recorded for the repository on its own still
+// apply.
+func (r *Results) Merge(name string, other *Results) {
+	for _, issue := range other.Issues {
+		issue.File = name + "/" + issue.File
+		r.Issues = append(r.Issues, issue)
+	}
+	for _, function := range other.Functions {
+		function.File = name + "/" + function.File
+		r.Functions = append(r.Functions, function)
+	}
+	r.Files += other.Files
+	r.Lines += other.Lines
+	r.CachedFiles += other.CachedFiles
+	for _, insight := range other.Insights {
+		r.Insights = append(r.Insights, name+": "+insight)
+	}
+	for _, warning := range other.Warnings {
+		r.Warnings = append(r.Warnings, name+": "+warning)
+	}
+	r.Repositories = append(r.Repositories, &RepoSummary{Name: name, Files: other.Files, Lines: other.Lines})
+	r.Timings.Scan += other.Timings.Scan
+	r.Timings.Analysis += other.Timings.Analysis
+	r.Timings.Gosec += other.Timings.Gosec
+	r.Timings.Learning += other.Timings.Learning
+	r.Recount()
+}
 
-	// Read file content
-	content, err := ioutil.ReadFile(file.Path)
-	if err != nil {
+// MergeResults combines the results of analyses of parts of a code base, such as the shards
+// of a CI job, into one. Issues reported by several of them, with the same fingerprint on
+// the same line, are kept once, and the totals are recounted. The counts of files and lines
+// add up those of every result.
+func MergeResults(results ...*Results) *Results {
+	type issueKey struct {
+		fingerprint string
+		line        int
+	}
+	merged := &Results{}
+	seenIssues := make(map[issueKey]bool)
+	seenFunctions := make(map[models.Function]bool)
+	seenNotes := make(map[string]bool)
+	repositories := make(map[string]*RepoSummary)
+	for _, other := range results {
+		for _, issue := range other.Issues {
+			key := issueKey{issue.Fingerprint(), issue.Line}
+			if !seenIssues[key] {
+				seenIssues[key] = true
+				merged.Issues = append(merged.Issues, issue)
+			}
+		}
+		for _, function := range other.Functions {
+			if !seenFunctions[*function] {
+				seenFunctions[*function] = true
+				merged.Functions = append(merged.Functions, function)
+			}
+		}
+		for _, insight := range other.Insights {
+			if !seenNotes["insight:"+insight] {
+				seenNotes["insight:"+insight] = true
+				merged.Insights = append(merged.Insights, insight)
+			}
+		}
+		for _, warning := range other.Warnings {
+			if !seenNotes["warning:"+warning] {
+				seenNotes["warning:"+warning] = true
+				merged.Warnings = append(merged.Warnings, warning)
+			}
+		}
+		for _, repo := range other.Repositories {
+			summary, ok := repositories[repo.Name]
+			if !ok {
+				summary = &RepoSummary{Name: repo.Name}
+				repositories[repo.Name] = summary
+				merged.Repositories = append(merged.Repositories, summary)
+			}
+			summary.Files += repo.Files
+			summary.Lines += repo.Lines
+		}
+		merged.Files += other.Files
+		merged.Lines += other.Lines
+		merged.CachedFiles += other.CachedFiles
+	}
+	merged.Recount()
+	return merged
+}
+
+// Filter keeps only the issues selected by the configured category, severity, and rule
+// filters and at or above the minimum confidence, and recounts the totals. Issues without
+// a known confidence level are kept.
+func (r *Results) Filter(cfg *config.Config) {
+	minConfidence := models.ConfidenceScore(cfg.MinConfidence)
+	filtered := make([]*models.Issue, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		if confidence := models.ConfidenceScore(issue.Confidence); confidence > 0 && confidence < minConfidence {
+			continue
+		}
+		if cfg.IssueSelected(issue.Category, issue.Severity, issue.Rule) {
+			filtered = append(filtered, issue)
+		}
+	}
+	r.Issues = filtered
+	r.Recount()
+}
+
+// AnalyzeSource analyzes the contents of a single file, which may differ from the file on
+// disk, such as an unsaved editor buffer. When rules use type information, the file's
+// package is type-checked with content in place of the file. gosec, which scans files on
+// disk, and the cache are not used.
+func (a *Analyzer) AnalyzeSource(ctx context.Context, file *models.File, content []byte) (*Results, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	// Parse the file
-	astFile, err := parser.ParseFile(a.fset, file.Path, content, parser.AllErrors|parser.ParseComments)
+	var typed *typecheck.File
+	if a.typed {
+		path := typecheck.AbsPath(file.Path)
+		files, err := typecheck.LoadDir(a.fset, filepath.Dir(path), map[string][]byte{path: content})
+		if err != nil && a.config.Verbose {
+			println("Type information unavailable for", filepath.Dir(path), ":", err.Error())
+		}
+		typed = files[path]
+	}
+
+	issues, functions, err := a.analyzeContent(file, content, typed)
 	if err != nil {
 		return nil, err
 	}
 
+	results := &Results{
+		Issues:    issues,
+		Functions: functions,
+		Files:     1,
+		Lines:     file.Lines,
+	}
+	results.Recount()
+	return results, nil
+}
+
+// analyzeFile analyzes a single file and returns a list of issues and the functions it
+// declares. The syntax tree and type information of the file are taken from typed when its
+// package can be type-checked.
+func (a *Analyzer) analyzeFile(ctx context.Context, file *models.File, typed *typecheck.Cache) ([]*models.Issue, []*models.Function, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// Read file content
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a.analyzeContent(file, content, typed.File(file.Path))
+}
+
+// analyzeContent analyzes the contents of a file, using the syntax tree and type information
+// of typed unless it is nil
+func (a *Analyzer) analyzeContent(file *models.File, content []byte, typed *typecheck.File) ([]*models.Issue, []*models.Function, error) {
+	issues := make([]*models.Issue, 0)
+
+	// Parse the file, unless its package was type-checked
+	var astFile *ast.File
+	var info *types.Info
+	if typed != nil {
+		astFile, info = typed.Syntax, typed.Info
+	} else {
+		var err error
+		astFile, err = parser.ParseFile(a.fset, file.Path, content, parser.AllErrors|parser.ParseComments)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// report normalizes an issue before adding it to the list, with the suggestion of its
+	// rule unless the detector gave one
+	var lines []string
+	ids := make(map[string]int)
+	testFile := a.config.TestFile(file.RelPath)
+	report := func(issue *models.Issue, suggestion string) {
+		if testFile && a.relaxed[issue.Rule] {
+			return
+		}
+		if issue.Suggestion == "" {
+			issue.Suggestion = suggestion
+		}
+
+		// Set relative path for consistent reporting
+		issue.File = file.RelPath
+		a.applySeverityOverride(issue)
+
+		// The source line identifies the issue independently of its position
+		if issue.Code == "" {
+			if lines == nil {
+				lines = strings.Split(string(content), "\n")
+			}
+			if issue.Line >= 1 && issue.Line <= len(lines) {
+				issue.Code = strings.TrimSpace(lines[issue.Line-1])
+			}
+		}
+		issue.ID = issue.Fingerprint()
+
+		// Issues of a rule on the same line share the snippet, so later ones are numbered
+		if n := ids[issue.ID]; n > 0 {
+			ids[issue.ID]++
+			issue.ID = fmt.Sprintf("%s-%d", issue.ID, n+1)
+		} else {
+			ids[issue.ID] = 1
+		}
+
+		issues = append(issues, issue)
+	}
+
 	// Apply all pattern detectors
 	ast.Inspect(astFile, func(node ast.Node) bool {
 		if node == nil {
@@ -150,42 +751,57 @@ func (a *Analyzer) analyzeFile(file *models.File) ([]*models.Issue, error) {
 
 		// Apply code smell patterns
 		for _, p := range a.patterns {
-			if issue := p.Detector(a.fset, node); issue != nil {
-				// Set relative path for consistent reporting
-				issue.File = file.RelPath
-				issues = append(issues, issue)
+			for _, issue := range p.Detector(a.fset, node) {
+				report(issue, p.Suggestion)
 			}
 		}
 
 		// Apply anti-patterns
 		for _, ap := range a.antiPatterns {
-			if issue := ap.Detector(a.fset, node); issue != nil {
-				// Set relative path for consistent reporting
-				issue.File = file.RelPath
-				issues = append(issues, issue)
+			for _, issue := range ap.Detector(a.fset, node) {
+				report(issue, ap.Suggestion)
 			}
 		}
 
 		// Apply best practices
 		for _, bp := range a.bestPractices {
-			if issue := bp.Detector(a.fset, node); issue != nil {
-				// Set relative path for consistent reporting
-				issue.File = file.RelPath
-				issues = append(issues, issue)
+			for _, issue := range bp.Detector(a.fset, info, node) {
+				report(issue, bp.Suggestion)
 			}
 		}
 
 		// Apply custom security rules
 		for _, sr := range a.securityRules {
-			if issue := sr.Detector(a.fset, node); issue != nil {
-				// Set relative path for consistent reporting
-				issue.File = file.RelPath
-				issues = append(issues, issue)
+			for _, issue := range sr.Detector(a.fset, node) {
+				report(issue, sr.Suggestion)
+			}
+		}
+
+		// Apply rules added with RegisterRule
+		for _, rule := range a.customRules {
+			for _, issue := range detectRule(rule, a.fset, node) {
+				report(issue, "")
 			}
 		}
 
 		return true
 	})
 
-	return issues, nil
+	// Compute per-function metrics such as cyclomatic complexity
+	functions, functionIssues := a.analyzeFunctions(astFile, file)
+	for _, issue := range functionIssues {
+		report(issue, "")
+	}
+
+	// Drop issues silenced by //review:ignore comments
+	issues = parseSuppressions(a.fset, astFile, content).filter(issues)
+
+	return issues, functions, nil
+}
+
+// applySeverityOverride replaces the severity of an issue with the one configured for its rule, if any
+func (a *Analyzer) applySeverityOverride(issue *models.Issue) {
+	if severity, ok := a.config.RuleSeverities[issue.Rule]; ok {
+		issue.Severity = severity
+	}
 }
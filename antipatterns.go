@@ -1,10 +1,14 @@
 package patterns
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"strconv"
 	"strings"
 
+	"github.com/user/code-review-assistant/internal/config"
 	"github.com/user/code-review-assistant/internal/models"
 )
 
@@ -14,11 +18,13 @@ type AntiPattern struct {
 	Description string
 	Category    string
 	Severity    string
-	Detector    func(fset *token.FileSet, node ast.Node) *models.Issue
+	Suggestion  string // Suggested fix, reported with the issues
+	Detector    func(fset *token.FileSet, node ast.Node) []*models.Issue
 }
 
-// GetGoAntiPatterns returns a list of Go-specific code anti-patterns to detect
-func GetGoAntiPatterns() []*AntiPattern {
+// GetGoAntiPatterns returns a list of Go-specific code anti-patterns to detect, with the
+// limits of the configuration
+func GetGoAntiPatterns(cfg *config.Config) []*AntiPattern {
 	return []*AntiPattern{
 		// Singleton pattern (often overused in Go)
 		{
@@ -26,6 +32,7 @@ func GetGoAntiPatterns() []*AntiPattern {
 			Description: "Singleton pattern usage",
 			Category:    "anti-pattern",
 			Severity:    "medium",
+			Suggestion:  "Consider using dependency injection instead of singleton pattern",
 			Detector:    detectSingleton,
 		},
 		// Panic in non-main functions
@@ -34,8 +41,18 @@ func GetGoAntiPatterns() []*AntiPattern {
 			Description: "Use of panic in non-main functions",
 			Category:    "anti-pattern",
 			Severity:    "high",
+			Suggestion:  "Consider returning errors instead of using panic",
 			Detector:    detectPanic,
 		},
+		// Panic and recover used as control flow
+		{
+			Name:        "panic-control-flow",
+			Description: "Panic recovered in the same function as control flow",
+			Category:    "anti-pattern",
+			Severity:    "high",
+			Suggestion:  "Return an error instead of panicking and recovering within the same function",
+			Detector:    detectPanicControlFlow,
+		},
 		// Returning unexported types from exported functions
 		{
 			Name:        "unexported-return",
@@ -47,10 +64,11 @@ func GetGoAntiPatterns() []*AntiPattern {
 		// Large interface anti-pattern
 		{
 			Name:        "large-interface",
-			Description: "Interface with too many methods",
+			Description: fmt.Sprintf("Interface with more than max_interface_methods (%d) methods", cfg.MaxInterfaceMethods),
 			Category:    "anti-pattern",
 			Severity:    "medium",
-			Detector:    detectLargeInterface,
+			Suggestion:  "Consider breaking down the interface into smaller, more focused interfaces",
+			Detector:    largeInterface(cfg.MaxInterfaceMethods),
 		},
 		// Empty interface without context
 		{
@@ -58,6 +76,7 @@ func GetGoAntiPatterns() []*AntiPattern {
 			Description: "Use of empty interface without clear context",
 			Category:    "anti-pattern",
 			Severity:    "low",
+			Suggestion:  "Consider using generics or a concrete type to preserve type safety",
 			Detector:    detectEmptyInterface,
 		},
 		// Goroutine without context or cancellation
@@ -66,21 +85,113 @@ func GetGoAntiPatterns() []*AntiPattern {
 			Description: "Goroutine without context or cancellation mechanism",
 			Category:    "anti-pattern",
 			Severity:    "high",
+			Suggestion:  "Use context.Context to manage goroutine lifecycle",
 			Detector:    detectUnmanagedGoroutine,
 		},
+		// Mutex copied by value
+		{
+			Name:        "copied-mutex",
+			Description: "Mutex or struct containing a mutex copied by value",
+			Category:    "anti-pattern",
+			Severity:    "high",
+			Suggestion:  "Use a pointer so that all copies share the same mutex",
+			Detector:    detectCopiedMutex,
+		},
+		// Mutex embedded in an exported type
+		{
+			Name:        "exported-mutex",
+			Description: "Exported struct type embedding a mutex",
+			Category:    "anti-pattern",
+			Severity:    "medium",
+			Suggestion:  "Use an unexported named field such as mu sync.Mutex, so that callers cannot lock the value",
+			Detector:    detectExportedMutex,
+		},
+		// Defer inside a loop
+		{
+			Name:        "defer-in-loop",
+			Description: "Defer inside a loop body",
+			Category:    "anti-pattern",
+			Severity:    "medium",
+			Suggestion:  "Move the loop body into a function so the deferred call runs every iteration, or call Close explicitly at the end of the iteration",
+			Detector:    detectDeferInLoop,
+		},
+		// Address of a range variable kept after the iteration
+		{
+			Name:        "loop-var-address",
+			Description: "Address of a range loop variable escaping the loop",
+			Category:    "anti-pattern",
+			Severity:    "high",
+			Suggestion:  "Before Go 1.22 every iteration shares the same variable, so all stored pointers end up pointing to the last element. Take the address of the element, as in &items[i], or of a copy declared in the loop body; modules declaring go 1.22 or later get a new variable per iteration",
+			Detector:    detectLoopVarAddress,
+		},
+		// time.Sleep used to wait for goroutines
+		{
+			Name:        "sleep-for-sync",
+			Description: "time.Sleep used to synchronize with goroutines",
+			Category:    "anti-pattern",
+			Severity:    "medium",
+			Suggestion:  "Wait with a sync.WaitGroup, a channel, or a context instead of sleeping for a fixed time",
+			Detector:    detectSleepForSync,
+		},
+		// Goroutine waiting on a channel without a way out
+		{
+			Name:        "blocking-receive",
+			Description: "Goroutine whose only channel operation is a receive outside of a select",
+			Category:    "anti-pattern",
+			Severity:    "high",
+			Suggestion:  "Receive in a select with a case <-ctx.Done() or a timeout, or make sure the channel is always sent to or closed",
+			Detector:    detectBlockingReceive,
+		},
+		// Unbuffered channel used before a goroutine can receive from it
+		{
+			Name:        "unbuffered-channel-deadlock",
+			Description: "Send or receive on an unbuffered channel that no other goroutine can reach",
+			Category:    "anti-pattern",
+			Severity:    "high",
+			Suggestion:  "Start the goroutine that uses the channel first, or give the channel a buffer with make(chan T, n)",
+			Detector:    detectUnbufferedChannelDeadlock,
+		},
+		// Goroutine that is never counted down by its WaitGroup
+		{
+			Name:        "waitgroup-leak",
+			Description: "Goroutine using a sync.WaitGroup without calling Done, or counted with Add after it starts",
+			Category:    "anti-pattern",
+			Severity:    "high",
+			Suggestion:  "Call wg.Add before the go statement and defer wg.Done() at the start of the goroutine",
+			Detector:    detectWaitGroupLeak,
+		},
+		// Methods of a type declared on both T and *T
+		{
+			Name:        "mixed-receivers",
+			Description: "Type with both pointer and value receivers",
+			Category:    "anti-pattern",
+			Severity:    "medium",
+			Suggestion:  "Use pointer receivers for all methods of the type if any of them needs one, so that the method sets of T and *T only differ as expected",
+			Detector:    detectMixedReceivers,
+		},
 		// Misuse of init function
 		{
 			Name:        "init-misuse",
-			Description: "Misuse of init function for complex initialization",
+			Description: fmt.Sprintf("Init function longer than max_init_lines (%d) lines", cfg.MaxInitLines),
 			Category:    "anti-pattern",
 			Severity:    "medium",
-			Detector:    detectInitMisuse,
+			Suggestion:  "Move complex initialization to dedicated functions that can be explicitly called",
+			Detector:    initMisuse(cfg.MaxInitLines),
+		},
+		// Generic maps used in place of a struct
+		{
+			Name:        "map-as-struct",
+			Description: fmt.Sprintf("map[string]interface{} accessed with more than max_map_keys (%d) distinct string keys", cfg.MaxMapKeys),
+			Category:    "anti-pattern",
+			Severity:    "low",
+			Suggestion:  "Define a struct type with a typed field for each key, and decode into it if the data comes from JSON or YAML",
+			Detector:    mapAsStruct(cfg.MaxMapKeys),
 		},
 	}
 }
 
 // detectSingleton detects singleton pattern usage
-func detectSingleton(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectSingleton(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Look for package-level variables with getter functions
 	// This is a simplified implementation
 	varDecl, ok := node.(*ast.GenDecl)
@@ -88,6 +199,7 @@ func detectSingleton(fset *token.FileSet, node ast.Node) *models.Issue {
 		return nil
 	}
 
+	var issues []*models.Issue
 	for _, spec := range varDecl.Specs {
 		valueSpec, ok := spec.(*ast.ValueSpec)
 		if !ok || len(valueSpec.Names) == 0 {
@@ -96,8 +208,8 @@ func detectSingleton(fset *token.FileSet, node ast.Node) *models.Issue {
 
 		varName := valueSpec.Names[0].Name
 		if !valueSpec.Names[0].IsExported() && strings.HasPrefix(strings.ToLower(varName), "instance") {
-			pos := fset.Position(varDecl.Pos())
-			return &models.Issue{
+			pos := fset.Position(valueSpec.Pos())
+			issues = append(issues, &models.Issue{
 				File:       pos.Filename,
 				Line:       pos.Line,
 				Column:     pos.Column,
@@ -105,17 +217,16 @@ func detectSingleton(fset *token.FileSet, node ast.Node) *models.Issue {
 				Category:   "anti-pattern",
 				Severity:   "medium",
 				Confidence: "medium",
-				Suggestion: "Consider using dependency injection instead of singleton pattern",
 				Rule:       "singleton-pattern",
-			}
+			})
 		}
 	}
 
-	return nil
+	return issues
 }
 
 // detectPanic detects use of panic in non-main functions
-func detectPanic(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectPanic(fset *token.FileSet, node ast.Node) []*models.Issue {
 	callExpr, ok := node.(*ast.CallExpr)
 	if !ok {
 		return nil
@@ -139,7 +250,7 @@ func detectPanic(fset *token.FileSet, node ast.Node) *models.Issue {
 	// If not in main or init function, report issue
 	if funcName != "main" && funcName != "init" {
 		pos := fset.Position(callExpr.Pos())
-		return &models.Issue{
+		return []*models.Issue{{
 			File:       pos.Filename,
 			Line:       pos.Line,
 			Column:     pos.Column,
@@ -147,59 +258,217 @@ func detectPanic(fset *token.FileSet, node ast.Node) *models.Issue {
 			Category:   "anti-pattern",
 			Severity:   "high",
 			Confidence: "high",
-			Suggestion: "Consider returning errors instead of using panic",
 			Rule:       "panic-usage",
-		}
+		}}
 	}
 
 	return nil
 }
 
+// detectPanicControlFlow detects functions that call panic and recover from it in a
+// deferred closure of their own, using panics in place of returned errors. Functions that
+// only recover from panics of the functions they call are not reported.
+func detectPanicControlFlow(fset *token.FileSet, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil {
+		return nil
+	}
+
+	panics := false
+	recovers := false
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.DeferStmt:
+			if funcLit, ok := n.Call.Fun.(*ast.FuncLit); ok && callsBuiltin(funcLit.Body, "recover") {
+				recovers = true
+			}
+			return false
+		case *ast.FuncLit:
+			// Panics of closures may not happen while the function runs
+			return false
+		case *ast.CallExpr:
+			if ident, ok := n.Fun.(*ast.Ident); ok && ident.Name == "panic" {
+				panics = true
+			}
+		}
+		return true
+	})
+	if !panics || !recovers {
+		return nil
+	}
+
+	pos := fset.Position(funcDecl.Name.Pos())
+	return []*models.Issue{{
+		File:       pos.Filename,
+		Line:       pos.Line,
+		Column:     pos.Column,
+		Message:    "Function '" + funcDecl.Name.Name + "' panics and recovers from its own panic, using it as control flow",
+		Category:   "anti-pattern",
+		Severity:   "high",
+		Confidence: "medium",
+		Rule:       "panic-control-flow",
+	}}
+}
+
+// callsBuiltin reports whether a block calls a builtin function directly, outside of
+// nested function literals
+func callsBuiltin(body *ast.BlockStmt, name string) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if ident, ok := n.Fun.(*ast.Ident); ok && ident.Name == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
 // detectUnexportedReturn detects returning unexported types from exported functions
-func detectUnexportedReturn(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectUnexportedReturn(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Implementation will be added
 	return nil
 }
 
-// detectLargeInterface detects interfaces with too many methods
-func detectLargeInterface(fset *token.FileSet, node ast.Node) *models.Issue {
-	typeSpec, ok := node.(*ast.TypeSpec)
-	if !ok {
-		return nil
-	}
+// largeInterface returns a detector of interfaces with more than maxMethods methods,
+// embedded interfaces counting as one. A maxMethods of zero disables the detector.
+func largeInterface(maxMethods int) func(fset *token.FileSet, node ast.Node) []*models.Issue {
+	return func(fset *token.FileSet, node ast.Node) []*models.Issue {
+		typeSpec, ok := node.(*ast.TypeSpec)
+		if !ok || maxMethods <= 0 {
+			return nil
+		}
 
-	interfaceType, ok := typeSpec.Type.(*ast.InterfaceType)
-	if !ok || interfaceType.Methods == nil {
-		return nil
-	}
+		interfaceType, ok := typeSpec.Type.(*ast.InterfaceType)
+		if !ok || interfaceType.Methods == nil {
+			return nil
+		}
+
+		methodCount := len(interfaceType.Methods.List)
+		if methodCount <= maxMethods {
+			return nil
+		}
 
-	methodCount := len(interfaceType.Methods.List)
-	if methodCount > 5 {
 		pos := fset.Position(typeSpec.Pos())
-		return &models.Issue{
+		return []*models.Issue{{
 			File:       pos.Filename,
 			Line:       pos.Line,
 			Column:     pos.Column,
-			Message:    "Interface '" + typeSpec.Name.Name + "' has too many methods (" + string(rune('0'+methodCount)) + ")",
+			Message:    fmt.Sprintf("Interface '%s' has too many methods (%d, maximum %d)", typeSpec.Name.Name, methodCount, maxMethods),
 			Category:   "anti-pattern",
 			Severity:   "medium",
 			Confidence: "high",
-			Suggestion: "Consider breaking down the interface into smaller, more focused interfaces",
 			Rule:       "large-interface",
+		}}
+	}
+}
+
+// detectEmptyInterface detects use of empty interface without clear context
+func detectEmptyInterface(fset *token.FileSet, node ast.Node) []*models.Issue {
+	var issues []*models.Issue
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		// Printf-style functions legitimately accept ...interface{} arguments
+		allowVariadic := isFormattingFunc(n.Name.Name)
+
+		if n.Type.Params != nil {
+			for _, field := range n.Type.Params.List {
+				if ellipsis, ok := field.Type.(*ast.Ellipsis); ok && allowVariadic && isEmptyInterface(ellipsis.Elt) {
+					continue
+				}
+				if isEmptyInterfaceField(field) {
+					issues = append(issues, newEmptyInterfaceIssue(fset, field, "Function '"+n.Name.Name+"' accepts empty interface"+fieldNameSuffix(field, "parameter")))
+				}
+			}
+		}
+
+		if n.Type.Results != nil {
+			for _, field := range n.Type.Results.List {
+				if isEmptyInterfaceField(field) {
+					issues = append(issues, newEmptyInterfaceIssue(fset, field, "Function '"+n.Name.Name+"' returns empty interface"))
+				}
+			}
+		}
+	case *ast.StructType:
+		if n.Fields == nil {
+			return nil
+		}
+		for _, field := range n.Fields.List {
+			if isEmptyInterfaceField(field) {
+				issues = append(issues, newEmptyInterfaceIssue(fset, field, "Struct field uses empty interface"+fieldNameSuffix(field, "field")))
+			}
 		}
 	}
 
-	return nil
+	return issues
 }
 
-// detectEmptyInterface detects use of empty interface without clear context
-func detectEmptyInterface(fset *token.FileSet, node ast.Node) *models.Issue {
-	// Implementation will be added
-	return nil
+// isEmptyInterfaceField checks if a field is declared as interface{} or any,
+// including variadic ...interface{} parameters. Composite types such as
+// map[string]interface{} are deliberately not flagged since they are the
+// idiomatic representation of generic JSON.
+func isEmptyInterfaceField(field *ast.Field) bool {
+	if ellipsis, ok := field.Type.(*ast.Ellipsis); ok {
+		return isEmptyInterface(ellipsis.Elt)
+	}
+	return isEmptyInterface(field.Type)
+}
+
+// isEmptyInterface checks if an expression is interface{} or any
+func isEmptyInterface(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.InterfaceType:
+		return t.Methods == nil || len(t.Methods.List) == 0
+	case *ast.Ident:
+		return t.Name == "any"
+	}
+	return false
+}
+
+// formattingSuffixes are the lowercase name suffixes of Printf-style functions besides
+// those containing print, such as Errorf, Logf, Fatalf, or the Infof of leveled loggers
+var formattingSuffixes = []string{"errorf", "logf", "fatalf", "panicf", "debugf", "infof", "warnf", "warningf"}
+
+// isFormattingFunc checks if a function name suggests Printf-style formatting
+func isFormattingFunc(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range formattingSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return strings.Contains(lower, "print") || strings.Contains(lower, "format")
+}
+
+// fieldNameSuffix describes the first name of a field for use in an issue message
+func fieldNameSuffix(field *ast.Field, kind string) string {
+	if len(field.Names) == 0 {
+		return ""
+	}
+	return " for " + kind + " '" + field.Names[0].Name + "'"
+}
+
+// newEmptyInterfaceIssue creates an empty-interface issue for a field
+func newEmptyInterfaceIssue(fset *token.FileSet, field *ast.Field, message string) *models.Issue {
+	pos := fset.Position(field.Pos())
+	return &models.Issue{
+		File:       pos.Filename,
+		Line:       pos.Line,
+		Column:     pos.Column,
+		Message:    message,
+		Category:   "anti-pattern",
+		Severity:   "low",
+		Confidence: "medium",
+		Rule:       "empty-interface",
+	}
 }
 
 // detectUnmanagedGoroutine detects goroutines without context or cancellation
-func detectUnmanagedGoroutine(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectUnmanagedGoroutine(fset *token.FileSet, node ast.Node) []*models.Issue {
 	goStmt, ok := node.(*ast.GoStmt)
 	if !ok {
 		return nil
@@ -227,7 +496,7 @@ func detectUnmanagedGoroutine(fset *token.FileSet, node ast.Node) *models.Issue
 
 	if !hasContext {
 		pos := fset.Position(goStmt.Pos())
-		return &models.Issue{
+		return []*models.Issue{{
 			File:       pos.Filename,
 			Line:       pos.Line,
 			Column:     pos.Column,
@@ -235,42 +504,1130 @@ func detectUnmanagedGoroutine(fset *token.FileSet, node ast.Node) *models.Issue
 			Category:   "anti-pattern",
 			Severity:   "high",
 			Confidence: "medium",
-			Suggestion: "Use context.Context to manage goroutine lifecycle",
 			Rule:       "unmanaged-goroutine",
-		}
+		}}
 	}
 
 	return nil
 }
 
-// detectInitMisuse detects misuse of init function
-func detectInitMisuse(fset *token.FileSet, node ast.Node) *models.Issue {
-	funcDecl, ok := node.(*ast.FuncDecl)
-	if !ok || funcDecl.Name.Name != "init" {
+// detectBlockingReceive detects goroutines started on a function literal whose only channel
+// operation is a receive from a variable outside of a select, which leaks the goroutine if
+// nothing is ever sent or the channel never closed. Receives from calls, such as
+// <-ctx.Done() or <-time.After(d), are the way out rather than the problem.
+func detectBlockingReceive(fset *token.FileSet, node ast.Node) []*models.Issue {
+	goStmt, ok := node.(*ast.GoStmt)
+	if !ok {
+		return nil
+	}
+	lit, ok := goStmt.Call.Fun.(*ast.FuncLit)
+	if !ok {
 		return nil
 	}
 
-	// Check if init function is too complex
-	if funcDecl.Body != nil {
-		startPos := fset.Position(funcDecl.Body.Lbrace)
-		endPos := fset.Position(funcDecl.Body.Rbrace)
-		lineCount := endPos.Line - startPos.Line
+	var receive *ast.UnaryExpr
+	operations := 0
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit, *ast.GoStmt:
+			// Checked on their own when they run as goroutines
+			return false
+		case *ast.SelectStmt:
+			operations += 2
+			return false
+		case *ast.SendStmt:
+			operations++
+		case *ast.RangeStmt:
+			// The range may be over a channel, which ends when the channel is closed
+			operations++
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				operations++
+				if _, isCall := ast.Unparen(n.X).(*ast.CallExpr); !isCall {
+					receive = n
+				}
+			}
+		}
+		return true
+	})
+	if operations != 1 || receive == nil {
+		return nil
+	}
+
+	pos := fset.Position(receive.Pos())
+	return []*models.Issue{{
+		File:       pos.Filename,
+		Line:       pos.Line,
+		Column:     pos.Column,
+		Message:    fmt.Sprintf("Goroutine blocks receiving from %s with no select, timeout, or cancellation, and leaks if nothing is ever sent", types.ExprString(receive.X)),
+		Category:   "anti-pattern",
+		Severity:   "high",
+		Confidence: "low",
+		Rule:       "blocking-receive",
+	}}
+}
+
+// detectDeferInLoop detects defer statements in loop bodies, which only run when the
+// function returns and so pile up with every iteration
+func detectDeferInLoop(fset *token.FileSet, node ast.Node) []*models.Issue {
+	var body *ast.BlockStmt
+	switch loop := node.(type) {
+	case *ast.ForStmt:
+		body = loop.Body
+	case *ast.RangeStmt:
+		body = loop.Body
+	default:
+		return nil
+	}
 
-		if lineCount > 10 {
-			pos := fset.Position(funcDecl.Pos())
-			return &models.Issue{
+	var issues []*models.Issue
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Deferred calls of a closure run when the closure returns
+			return false
+		case *ast.ForStmt, *ast.RangeStmt:
+			// Nested loops are checked on their own
+			return false
+		case *ast.DeferStmt:
+			pos := fset.Position(n.Pos())
+			issues = append(issues, &models.Issue{
 				File:       pos.Filename,
 				Line:       pos.Line,
 				Column:     pos.Column,
-				Message:    "Complex init function with " + string(rune('0'+lineCount/10)) + string(rune('0'+lineCount%10)) + " lines",
+				Message:    "Defer inside a loop runs only when the function returns, not at the end of each iteration",
 				Category:   "anti-pattern",
 				Severity:   "medium",
+				Confidence: "high",
+				Rule:       "defer-in-loop",
+			})
+		}
+		return true
+	})
+
+	return issues
+}
+
+// detectLoopVarAddress detects the address of a variable declared by a range statement
+// escaping the iteration: appended to a slice, stored into an element of a slice or map, or
+// returned
+func detectLoopVarAddress(fset *token.FileSet, node ast.Node) []*models.Issue {
+	loop, ok := node.(*ast.RangeStmt)
+	if !ok || loop.Tok != token.DEFINE {
+		return nil
+	}
+	var vars []*ast.Ident
+	for _, expr := range []ast.Expr{loop.Key, loop.Value} {
+		if ident, ok := expr.(*ast.Ident); ok && ident.Name != "_" {
+			vars = append(vars, ident)
+		}
+	}
+	if len(vars) == 0 {
+		return nil
+	}
+
+	var issues []*models.Issue
+	report := func(expr ast.Expr, how string) {
+		addr, ok := ast.Unparen(expr).(*ast.UnaryExpr)
+		if !ok || addr.Op != token.AND {
+			return
+		}
+		for _, v := range vars {
+			if !sameVar(ast.Unparen(addr.X), v) {
+				continue
+			}
+			pos := fset.Position(addr.Pos())
+			issues = append(issues, &models.Issue{
+				File:       pos.Filename,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Message:    fmt.Sprintf("Address of range variable %s is %s, so it outlives the iteration", v.Name, how),
+				Category:   "anti-pattern",
+				Severity:   "high",
 				Confidence: "medium",
-				Suggestion: "Move complex initialization to dedicated functions that can be explicitly called",
-				Rule:       "init-misuse",
+				Rule:       "loop-var-address",
+			})
+		}
+	}
+
+	var visit func(body ast.Node, closure bool)
+	visit = func(body ast.Node, closure bool) {
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				// The results of a closure are not the results of the function
+				visit(n.Body, true)
+				return false
+			case *ast.CallExpr:
+				if fun, ok := n.Fun.(*ast.Ident); ok && fun.Name == "append" && len(n.Args) > 1 {
+					for _, arg := range n.Args[1:] {
+						report(arg, "appended to a slice")
+					}
+				}
+			case *ast.AssignStmt:
+				if len(n.Lhs) != len(n.Rhs) {
+					return true
+				}
+				for i, lhs := range n.Lhs {
+					if _, ok := ast.Unparen(lhs).(*ast.IndexExpr); ok {
+						report(n.Rhs[i], "stored into an element of a slice or map")
+					}
+				}
+			case *ast.ReturnStmt:
+				if !closure {
+					for _, result := range n.Results {
+						report(result, "returned")
+					}
+				}
+			}
+			return true
+		})
+	}
+	visit(loop.Body, false)
+
+	return issues
+}
+
+// detectSleepForSync detects time.Sleep calls in functions that start goroutines or
+// communicate over channels, where the sleep most likely waits for another goroutine
+func detectSleepForSync(fset *token.FileSet, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil {
+		return nil
+	}
+
+	concurrent := false
+	var sleeps []*ast.CallExpr
+	ast.Inspect(funcDecl, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.GoStmt, *ast.SendStmt, *ast.ChanType:
+			concurrent = true
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				concurrent = true
+			}
+		case *ast.SelectorExpr:
+			if pkg, ok := n.X.(*ast.Ident); ok && pkg.Name == "sync" && (n.Sel.Name == "WaitGroup" || n.Sel.Name == "Cond") {
+				concurrent = true
+			}
+		case *ast.CallExpr:
+			if sel, ok := n.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Sleep" {
+				if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "time" {
+					sleeps = append(sleeps, n)
+				}
 			}
 		}
+		return true
+	})
+	if !concurrent {
+		return nil
 	}
 
-	return nil
+	var issues []*models.Issue
+	for _, call := range sleeps {
+		pos := fset.Position(call.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    "time.Sleep in a function that starts goroutines or uses channels is an unreliable way to wait for other goroutines",
+			Category:   "anti-pattern",
+			Severity:   "medium",
+			Confidence: "medium",
+			Rule:       "sleep-for-sync",
+		})
+	}
+
+	return issues
+}
+
+// detectUnbufferedChannelDeadlock detects a send on or receive from an unbuffered channel
+// made earlier in the same block, when no goroutine was started and the channel was not
+// handed to other code in between. Nothing else can use the channel, so the operation
+// blocks forever.
+func detectUnbufferedChannelDeadlock(fset *token.FileSet, node ast.Node) []*models.Issue {
+	block, ok := node.(*ast.BlockStmt)
+	if !ok {
+		return nil
+	}
+
+	var issues []*models.Issue
+	for i, stmt := range block.List {
+		ch := unbufferedChanMade(stmt)
+		if ch == nil {
+			continue
+		}
+
+		for _, next := range block.List[i+1:] {
+			if op, verb := blockingChanOp(next, ch); op != nil {
+				pos := fset.Position(op.Pos())
+				issues = append(issues, &models.Issue{
+					File:       pos.Filename,
+					Line:       pos.Line,
+					Column:     pos.Column,
+					Message:    fmt.Sprintf("%s unbuffered channel %s before any goroutine can use it blocks forever", verb, ch.Name),
+					Category:   "anti-pattern",
+					Severity:   "high",
+					Confidence: "medium",
+					Rule:       "unbuffered-channel-deadlock",
+				})
+				break
+			}
+			// Any other use of the channel may reach another goroutine
+			if startsGoroutine(next) || refersTo(next, ch) {
+				break
+			}
+		}
+	}
+
+	return issues
+}
+
+// unbufferedChanMade returns the variable assigned an unbuffered channel by a statement
+// such as ch := make(chan T), or nil
+func unbufferedChanMade(stmt ast.Stmt) *ast.Ident {
+	var lhs, rhs []ast.Expr
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		lhs, rhs = s.Lhs, s.Rhs
+	case *ast.DeclStmt:
+		if gen, ok := s.Decl.(*ast.GenDecl); ok && len(gen.Specs) == 1 {
+			if spec, ok := gen.Specs[0].(*ast.ValueSpec); ok && len(spec.Names) == 1 {
+				lhs, rhs = []ast.Expr{spec.Names[0]}, spec.Values
+			}
+		}
+	}
+	if len(lhs) != 1 || len(rhs) != 1 || !isUnbufferedMake(rhs[0]) {
+		return nil
+	}
+
+	ident, ok := lhs[0].(*ast.Ident)
+	if !ok || ident.Name == "_" {
+		return nil
+	}
+	return ident
+}
+
+// isUnbufferedMake reports whether an expression is make(chan T) or make(chan T, 0)
+func isUnbufferedMake(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 || len(call.Args) > 2 {
+		return false
+	}
+	if fun, ok := call.Fun.(*ast.Ident); !ok || fun.Name != "make" {
+		return false
+	}
+	if _, ok := call.Args[0].(*ast.ChanType); !ok {
+		return false
+	}
+	if len(call.Args) == 1 {
+		return true
+	}
+	lit, ok := call.Args[1].(*ast.BasicLit)
+	return ok && lit.Value == "0"
+}
+
+// blockingChanOp returns the send or receive on ch performed directly by a statement, with
+// a verb describing it, or nil
+func blockingChanOp(stmt ast.Stmt, ch *ast.Ident) (ast.Node, string) {
+	var expr ast.Expr
+	switch s := stmt.(type) {
+	case *ast.SendStmt:
+		if sameVar(s.Chan, ch) {
+			return s, "Sending on"
+		}
+		return nil, ""
+	case *ast.ExprStmt:
+		expr = s.X
+	case *ast.AssignStmt:
+		if len(s.Rhs) == 1 {
+			expr = s.Rhs[0]
+		}
+	}
+
+	if recv, ok := expr.(*ast.UnaryExpr); ok && recv.Op == token.ARROW && sameVar(recv.X, ch) {
+		return recv, "Receiving from"
+	}
+	return nil, ""
+}
+
+// startsGoroutine reports whether a statement contains a go statement
+func startsGoroutine(stmt ast.Stmt) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if _, ok := n.(*ast.GoStmt); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// refersTo reports whether a node uses the variable declared by ident
+func refersTo(node ast.Node, ident *ast.Ident) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if expr, ok := n.(ast.Expr); ok && sameVar(expr, ident) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// sameVar reports whether expr is an identifier of the variable declared by ident
+func sameVar(expr ast.Expr, ident *ast.Ident) bool {
+	other, ok := expr.(*ast.Ident)
+	if !ok || other.Name != ident.Name {
+		return false
+	}
+	return other.Obj == nil || ident.Obj == nil || other.Obj == ident.Obj
+}
+
+// detectWaitGroupLeak detects goroutines started by a function that are counted with Add
+// on one of its sync.WaitGroup variables, or use it, but never call Done on it, so that
+// Wait blocks forever, and
+// calls of Add in a loop after the goroutine counted by it is started, so that Wait may
+// return before the goroutine is counted. Goroutines handing the WaitGroup to other code
+// are not reported.
+func detectWaitGroupLeak(fset *token.FileSet, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil {
+		return nil
+	}
+	groups := waitGroupVars(funcDecl)
+	if len(groups) == 0 {
+		return nil
+	}
+
+	var issues []*models.Issue
+	report := func(at ast.Node, message, suggestion string) {
+		pos := fset.Position(at.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    message,
+			Category:   "anti-pattern",
+			Severity:   "high",
+			Confidence: "medium",
+			Suggestion: suggestion,
+			Rule:       "waitgroup-leak",
+		})
+	}
+
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		var loopBody *ast.BlockStmt
+		switch n := n.(type) {
+		case *ast.GoStmt:
+			lit, ok := n.Call.Fun.(*ast.FuncLit)
+			if !ok {
+				return true
+			}
+			for _, wg := range groups {
+				if inner := goroutineWaitGroup(n.Call, lit, wg); inner != nil && !callsDone(lit.Body, inner) {
+					report(n, fmt.Sprintf("Goroutine uses WaitGroup %s but never calls %s.Done, so Wait blocks forever", wg.Name, inner.Name),
+						fmt.Sprintf("Add defer %s.Done() at the start of the goroutine", inner.Name))
+				}
+			}
+		case *ast.BlockStmt:
+			for _, wg := range groups {
+				if goStmt := uncountedGoroutine(n, wg); goStmt != nil {
+					report(goStmt, fmt.Sprintf("Goroutine counted by %s.Add never calls %s.Done, so Wait blocks forever", wg.Name, wg.Name),
+						fmt.Sprintf("Add defer %s.Done() at the start of the goroutine", wg.Name))
+				}
+			}
+		case *ast.ForStmt:
+			loopBody = n.Body
+		case *ast.RangeStmt:
+			loopBody = n.Body
+		}
+		for _, wg := range groups {
+			if add := addAfterGo(loopBody, wg); add != nil {
+				report(add, fmt.Sprintf("%s.Add is called after the goroutine it counts is started, so Wait may return before the goroutine runs", wg.Name),
+					fmt.Sprintf("Call %s.Add before the go statement", wg.Name))
+			}
+		}
+		return true
+	})
+
+	return issues
+}
+
+// waitGroupVars returns the identifiers declaring the sync.WaitGroup variables of a
+// function: its parameters of type *sync.WaitGroup, and the variables declared with
+// var wg sync.WaitGroup, wg := sync.WaitGroup{}, wg := &sync.WaitGroup{}, or
+// wg := new(sync.WaitGroup)
+func waitGroupVars(funcDecl *ast.FuncDecl) []*ast.Ident {
+	var groups []*ast.Ident
+	if funcDecl.Type.Params != nil {
+		for _, field := range funcDecl.Type.Params.List {
+			if isWaitGroupType(field.Type) {
+				groups = append(groups, field.Names...)
+			}
+		}
+	}
+
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			if n.Type != nil && isWaitGroupType(n.Type) {
+				groups = append(groups, n.Names...)
+			}
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, rhs := range n.Rhs {
+				if ident, ok := n.Lhs[i].(*ast.Ident); ok && ident.Name != "_" && isNewWaitGroup(rhs) {
+					groups = append(groups, ident)
+				}
+			}
+		}
+		return true
+	})
+	return groups
+}
+
+// isWaitGroupType reports whether a type expression is sync.WaitGroup or *sync.WaitGroup
+func isWaitGroupType(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "WaitGroup" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "sync"
+}
+
+// isNewWaitGroup reports whether an expression makes a new sync.WaitGroup
+func isNewWaitGroup(expr ast.Expr) bool {
+	if addr, ok := expr.(*ast.UnaryExpr); ok && addr.Op == token.AND {
+		expr = addr.X
+	}
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		return e.Type != nil && isWaitGroupType(e.Type)
+	case *ast.CallExpr:
+		fun, ok := e.Fun.(*ast.Ident)
+		return ok && fun.Name == "new" && len(e.Args) == 1 && isWaitGroupType(e.Args[0])
+	}
+	return false
+}
+
+// goroutineWaitGroup returns the identifier under which the function literal started by a
+// go statement uses the WaitGroup wg: wg itself when the literal captures it, or the
+// parameter it is passed as. It returns nil when the goroutine does not use wg, waits for
+// it, or hands it to other code that might call Done.
+func goroutineWaitGroup(call *ast.CallExpr, lit *ast.FuncLit, wg *ast.Ident) *ast.Ident {
+	inner := wg
+	if !refersTo(lit.Body, wg) {
+		inner = passedAsParam(call, lit, wg)
+		if inner == nil || !refersTo(lit.Body, inner) {
+			return nil
+		}
+	}
+
+	// Every use must be a method call other than Wait, others pass the WaitGroup on
+	receivers := make(map[ast.Expr]bool)
+	escapes := false
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sameVar(sel.X, inner) {
+			receivers[sel.X] = true
+			escapes = escapes || sel.Sel.Name == "Wait"
+		}
+		if ident, ok := n.(*ast.Ident); ok && sameVar(ident, inner) && !receivers[ident] {
+			escapes = true
+		}
+		return !escapes
+	})
+	if escapes {
+		return nil
+	}
+	return inner
+}
+
+// passedAsParam returns the parameter of a function literal that a call passes wg or &wg
+// as, or nil
+func passedAsParam(call *ast.CallExpr, lit *ast.FuncLit, wg *ast.Ident) *ast.Ident {
+	var params []*ast.Ident
+	if lit.Type.Params != nil {
+		for _, field := range lit.Type.Params.List {
+			params = append(params, field.Names...)
+		}
+	}
+	for i, arg := range call.Args {
+		if addr, ok := arg.(*ast.UnaryExpr); ok && addr.Op == token.AND {
+			arg = addr.X
+		}
+		if sameVar(arg, wg) && i < len(params) {
+			return params[i]
+		}
+	}
+	return nil
+}
+
+// callsDone reports whether a goroutine body calls, defers, or refers to wg.Done
+func callsDone(body *ast.BlockStmt, wg *ast.Ident) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "Done" && sameVar(sel.X, wg) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// uncountedGoroutine returns the go statement of a function literal following a call of
+// wg.Add in a block when the goroutine does not use wg at all, or nil
+func uncountedGoroutine(block *ast.BlockStmt, wg *ast.Ident) *ast.GoStmt {
+	for i := 1; i < len(block.List); i++ {
+		goStmt, ok := block.List[i].(*ast.GoStmt)
+		if !ok || waitGroupAdd(block.List[i-1], wg) == nil {
+			continue
+		}
+		if _, ok := goStmt.Call.Fun.(*ast.FuncLit); ok && !refersTo(goStmt, wg) {
+			return goStmt
+		}
+	}
+	return nil
+}
+
+// addAfterGo returns the call of wg.Add made by a loop body after it starts a goroutine
+// using wg, or nil
+func addAfterGo(body *ast.BlockStmt, wg *ast.Ident) *ast.CallExpr {
+	if body == nil {
+		return nil
+	}
+	started := false
+	for _, stmt := range body.List {
+		if add := waitGroupAdd(stmt, wg); add != nil && started {
+			return add
+		}
+		if goStmt, ok := stmt.(*ast.GoStmt); ok && refersTo(goStmt, wg) {
+			started = true
+		}
+	}
+	return nil
+}
+
+// waitGroupAdd returns the call of wg.Add made by a statement, or nil
+func waitGroupAdd(stmt ast.Stmt, wg *ast.Ident) *ast.CallExpr {
+	exprStmt, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return nil
+	}
+	call, ok := exprStmt.X.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Add" && sameVar(sel.X, wg) {
+		return call
+	}
+	return nil
+}
+
+// initMisuse returns a detector of init functions whose body spans more than maxLines
+// lines. A maxLines of zero disables the detector.
+func initMisuse(maxLines int) func(fset *token.FileSet, node ast.Node) []*models.Issue {
+	return func(fset *token.FileSet, node ast.Node) []*models.Issue {
+		funcDecl, ok := node.(*ast.FuncDecl)
+		if !ok || funcDecl.Name.Name != "init" || funcDecl.Body == nil || maxLines <= 0 {
+			return nil
+		}
+
+		startPos := fset.Position(funcDecl.Body.Lbrace)
+		endPos := fset.Position(funcDecl.Body.Rbrace)
+		lineCount := endPos.Line - startPos.Line
+		if lineCount <= maxLines {
+			return nil
+		}
+
+		pos := fset.Position(funcDecl.Pos())
+		return []*models.Issue{{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    fmt.Sprintf("Complex init function with %d lines (maximum %d)", lineCount, maxLines),
+			Category:   "anti-pattern",
+			Severity:   "medium",
+			Confidence: "medium",
+			Rule:       "init-misuse",
+		}}
+	}
+}
+
+// mapAsStruct returns a detector of map[string]interface{} variables of a function used in
+// place of a struct: indexed with, or created with a literal of, more than maxKeys distinct
+// string literal keys. Variables are followed by their declaration, so maps shared by
+// several variables are counted separately.
+func mapAsStruct(maxKeys int) func(fset *token.FileSet, node ast.Node) []*models.Issue {
+	return func(fset *token.FileSet, node ast.Node) []*models.Issue {
+		funcDecl, ok := node.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil || maxKeys <= 0 {
+			return nil
+		}
+
+		type genericMap struct {
+			ident *ast.Ident
+			keys  map[string]bool
+		}
+		var maps []*genericMap
+		byObject := make(map[*ast.Object]*genericMap)
+		declare := func(ident *ast.Ident, value ast.Expr) {
+			if ident.Name == "_" || ident.Obj == nil || byObject[ident.Obj] != nil {
+				return
+			}
+			m := &genericMap{ident: ident, keys: make(map[string]bool)}
+			if lit, ok := value.(*ast.CompositeLit); ok {
+				for _, elt := range lit.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := stringLiteral(kv.Key); ok {
+							m.keys[key] = true
+						}
+					}
+				}
+			}
+			byObject[ident.Obj] = m
+			maps = append(maps, m)
+		}
+
+		for _, field := range funcDecl.Type.Params.List {
+			if isGenericMap(field.Type) {
+				for _, name := range field.Names {
+					declare(name, nil)
+				}
+			}
+		}
+
+		// Declarations come before the uses, so both are found in one pass
+		ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ValueSpec:
+				for i, name := range n.Names {
+					var value ast.Expr
+					if i < len(n.Values) {
+						value = n.Values[i]
+					}
+					if isGenericMap(n.Type) || (n.Type == nil && value != nil && genericMapValue(value)) {
+						declare(name, value)
+					}
+				}
+			case *ast.AssignStmt:
+				if n.Tok != token.DEFINE {
+					break
+				}
+				// m, ok := v.(map[string]interface{}) declares m with the first of two results
+				if len(n.Rhs) == 1 && len(n.Lhs) == 2 {
+					if ident, ok := n.Lhs[0].(*ast.Ident); ok && genericMapValue(n.Rhs[0]) {
+						declare(ident, nil)
+					}
+					break
+				}
+				for i, lhs := range n.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok && i < len(n.Rhs) && genericMapValue(n.Rhs[i]) {
+						declare(ident, n.Rhs[i])
+					}
+				}
+			case *ast.IndexExpr:
+				ident, ok := n.X.(*ast.Ident)
+				if !ok || ident.Obj == nil || byObject[ident.Obj] == nil {
+					break
+				}
+				if key, ok := stringLiteral(n.Index); ok {
+					byObject[ident.Obj].keys[key] = true
+				}
+			}
+			return true
+		})
+
+		var issues []*models.Issue
+		for _, m := range maps {
+			if len(m.keys) <= maxKeys {
+				continue
+			}
+			pos := fset.Position(m.ident.Pos())
+			issues = append(issues, &models.Issue{
+				File:       pos.Filename,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Message:    fmt.Sprintf("Map '%s' is used like a struct, with %d distinct string keys (maximum %d)", m.ident.Name, len(m.keys), maxKeys),
+				Category:   "anti-pattern",
+				Severity:   "low",
+				Confidence: "medium",
+				Rule:       "map-as-struct",
+			})
+		}
+		return issues
+	}
+}
+
+// isGenericMap checks if a type expression is map[string]interface{} or map[string]any
+func isGenericMap(expr ast.Expr) bool {
+	mapType, ok := expr.(*ast.MapType)
+	if !ok {
+		return false
+	}
+	key, ok := mapType.Key.(*ast.Ident)
+	return ok && key.Name == "string" && isEmptyInterface(mapType.Value)
+}
+
+// genericMapValue checks if an expression creates or asserts a map[string]interface{}: a
+// composite literal, a call of make, or a type assertion
+func genericMapValue(expr ast.Expr) bool {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.CompositeLit:
+		return isGenericMap(e.Type)
+	case *ast.CallExpr:
+		fun, ok := e.Fun.(*ast.Ident)
+		return ok && fun.Name == "make" && len(e.Args) > 0 && isGenericMap(e.Args[0])
+	case *ast.TypeAssertExpr:
+		return isGenericMap(e.Type)
+	}
+	return false
+}
+
+// stringLiteral returns the value of a string literal expression
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+// detectCopiedMutex detects copies of a sync.Mutex, a sync.RWMutex, or a struct containing
+// one: parameters and receivers passed by value, range value variables, and assignments.
+// Without type information, only struct types declared in the same file are recognized.
+func detectCopiedMutex(fset *token.FileSet, node ast.Node) []*models.Issue {
+	file, ok := node.(*ast.File)
+	if !ok {
+		return nil
+	}
+
+	locks := mutexTypes(file)
+	containsLock := func(expr ast.Expr) (string, bool) {
+		switch t := ast.Unparen(expr).(type) {
+		case *ast.SelectorExpr:
+			if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "sync" && (t.Sel.Name == "Mutex" || t.Sel.Name == "RWMutex") {
+				return "sync." + t.Sel.Name, true
+			}
+		case *ast.Ident:
+			if locks[t.Name] {
+				return t.Name, true
+			}
+		}
+		return "", false
+	}
+
+	var issues []*models.Issue
+	report := func(pos token.Pos, message string) {
+		position := fset.Position(pos)
+		issues = append(issues, &models.Issue{
+			File:       position.Filename,
+			Line:       position.Line,
+			Column:     position.Column,
+			Message:    message,
+			Category:   "anti-pattern",
+			Severity:   "high",
+			Confidence: "medium",
+			Rule:       "copied-mutex",
+		})
+	}
+
+	// copied reports whether evaluating an expression copies a lock, i.e. it is a variable of
+	// a lock type or a dereferenced pointer to one
+	copied := func(expr ast.Expr) (string, bool) {
+		expr = ast.Unparen(expr)
+		if star, ok := expr.(*ast.StarExpr); ok {
+			if pointer, ok := declaredType(star.X).(*ast.StarExpr); ok {
+				return containsLock(pointer.X)
+			}
+			return "", false
+		}
+		if _, ok := expr.(*ast.Ident); ok {
+			return containsLock(declaredType(expr))
+		}
+		return "", false
+	}
+
+	checkParams := func(label string, fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			if name, ok := containsLock(field.Type); ok {
+				message := label
+				if len(field.Names) > 0 {
+					message += " '" + field.Names[0].Name + "'"
+				}
+				report(field.Pos(), message+" receives "+name+", which contains a mutex, by value")
+			}
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			checkParams("Receiver", n.Recv)
+		case *ast.FuncType:
+			checkParams("Parameter", n.Params)
+		case *ast.RangeStmt:
+			if n.Value == nil {
+				return true
+			}
+			var elem ast.Expr
+			switch t := declaredType(n.X).(type) {
+			case *ast.ArrayType:
+				elem = t.Elt
+			case *ast.MapType:
+				elem = t.Value
+			}
+			if name, ok := containsLock(elem); ok {
+				report(n.Value.Pos(), "Range value variable copies "+name+", which contains a mutex")
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for _, rhs := range n.Rhs {
+				if name, ok := copied(rhs); ok {
+					report(rhs.Pos(), "Assignment copies "+name+", which contains a mutex")
+				}
+			}
+		case *ast.ValueSpec:
+			for _, value := range n.Values {
+				if name, ok := copied(value); ok {
+					report(value.Pos(), "Assignment copies "+name+", which contains a mutex")
+				}
+			}
+		}
+		return true
+	})
+
+	return issues
+}
+
+// detectExportedMutex detects exported struct types embedding a sync.Mutex or sync.RWMutex,
+// whose Lock and Unlock methods are promoted into the API of the type
+func detectExportedMutex(fset *token.FileSet, node ast.Node) []*models.Issue {
+	typeSpec, ok := node.(*ast.TypeSpec)
+	if !ok || !typeSpec.Name.IsExported() {
+		return nil
+	}
+	structType, ok := typeSpec.Type.(*ast.StructType)
+	if !ok || structType.Fields == nil {
+		return nil
+	}
+
+	var issues []*models.Issue
+	for _, field := range structType.Fields.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		fieldType := field.Type
+		if star, ok := fieldType.(*ast.StarExpr); ok {
+			fieldType = star.X
+		}
+		selector, ok := fieldType.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		if pkg, ok := selector.X.(*ast.Ident); !ok || pkg.Name != "sync" || (selector.Sel.Name != "Mutex" && selector.Sel.Name != "RWMutex") {
+			continue
+		}
+
+		pos := fset.Position(field.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    fmt.Sprintf("Exported type '%s' embeds sync.%s, which makes its Lock and Unlock methods part of the API", typeSpec.Name.Name, selector.Sel.Name),
+			Category:   "anti-pattern",
+			Severity:   "medium",
+			Confidence: "high",
+			Rule:       "exported-mutex",
+		})
+	}
+	return issues
+}
+
+// mutexTypes returns the names of the struct types declared in a file that contain a
+// sync.Mutex or sync.RWMutex, directly or in a field of another such struct
+func mutexTypes(file *ast.File) map[string]bool {
+	var specs []*ast.TypeSpec
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.TYPE {
+			for _, spec := range genDecl.Specs {
+				specs = append(specs, spec.(*ast.TypeSpec))
+			}
+		}
+	}
+
+	locks := make(map[string]bool)
+	var isLock func(expr ast.Expr) bool
+	isLock = func(expr ast.Expr) bool {
+		switch t := expr.(type) {
+		case *ast.SelectorExpr:
+			pkg, ok := t.X.(*ast.Ident)
+			return ok && pkg.Name == "sync" && (t.Sel.Name == "Mutex" || t.Sel.Name == "RWMutex")
+		case *ast.Ident:
+			return locks[t.Name]
+		case *ast.ArrayType:
+			// Arrays hold their elements, slices only point to them
+			return t.Len != nil && isLock(t.Elt)
+		}
+		return false
+	}
+
+	// Repeat until no more types are found, as structs may be declared in any order
+	for changed := true; changed; {
+		changed = false
+		for _, spec := range specs {
+			if locks[spec.Name.Name] {
+				continue
+			}
+			found := isLock(spec.Type)
+			if structType, ok := spec.Type.(*ast.StructType); ok {
+				for _, field := range structType.Fields.List {
+					found = found || isLock(field.Type)
+				}
+			}
+			if found {
+				locks[spec.Name.Name] = true
+				changed = true
+			}
+		}
+	}
+	return locks
+}
+
+// declaredType returns the type expression an identifier was declared with, as a parameter,
+// a variable, or a variable initialized with a composite literal, or nil if it is not known
+func declaredType(expr ast.Expr) ast.Expr {
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return nil
+	}
+
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		return decl.Type
+	case *ast.ValueSpec:
+		if decl.Type != nil {
+			return decl.Type
+		}
+		for i, name := range decl.Names {
+			if name.Name == ident.Name && i < len(decl.Values) {
+				return literalType(decl.Values[i])
+			}
+		}
+	case *ast.AssignStmt:
+		for i, lhs := range decl.Lhs {
+			if name, ok := lhs.(*ast.Ident); ok && name.Name == ident.Name && len(decl.Lhs) == len(decl.Rhs) {
+				return literalType(decl.Rhs[i])
+			}
+		}
+	}
+	return nil
+}
+
+// literalType returns the type of a composite literal or a pointer to one, or nil for other
+// expressions
+func literalType(expr ast.Expr) ast.Expr {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.CompositeLit:
+		return e.Type
+	case *ast.UnaryExpr:
+		if lit, ok := e.X.(*ast.CompositeLit); ok && e.Op == token.AND {
+			return &ast.StarExpr{Star: e.OpPos, X: lit.Type}
+		}
+	}
+	return nil
+}
+
+// detectMixedReceivers detects types whose methods declared in a file have both pointer
+// and value receivers. The issue is reported at the first method whose receiver differs
+// from the one of the first method of the type.
+func detectMixedReceivers(fset *token.FileSet, node ast.Node) []*models.Issue {
+	file, ok := node.(*ast.File)
+	if !ok {
+		return nil
+	}
+
+	// First method with each kind of receiver, by type name
+	type receivers struct {
+		first   *ast.FuncDecl
+		pointer bool
+		other   *ast.FuncDecl
+	}
+	var names []string
+	byType := make(map[string]*receivers)
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 {
+			continue
+		}
+		name, pointer, ok := receiverType(funcDecl.Recv.List[0].Type)
+		if !ok {
+			continue
+		}
+
+		r, seen := byType[name]
+		if !seen {
+			byType[name] = &receivers{first: funcDecl, pointer: pointer}
+			names = append(names, name)
+		} else if r.other == nil && r.pointer != pointer {
+			r.other = funcDecl
+		}
+	}
+
+	var issues []*models.Issue
+	for _, name := range names {
+		r := byType[name]
+		if r.other == nil {
+			continue
+		}
+		pointerMethod, valueMethod := r.first.Name.Name, r.other.Name.Name
+		if !r.pointer {
+			pointerMethod, valueMethod = valueMethod, pointerMethod
+		}
+
+		pos := fset.Position(r.other.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    fmt.Sprintf("Type %s has both pointer receivers, as in %s, and value receivers, as in %s", name, pointerMethod, valueMethod),
+			Category:   "anti-pattern",
+			Severity:   "medium",
+			Confidence: "high",
+			Rule:       "mixed-receivers",
+		})
+	}
+	return issues
+}
+
+// receiverType returns the name of the type of a receiver and whether it is a pointer,
+// ignoring the type parameters of generic types
+func receiverType(expr ast.Expr) (name string, pointer bool, ok bool) {
+	expr = ast.Unparen(expr)
+	if star, isStar := expr.(*ast.StarExpr); isStar {
+		expr, pointer = ast.Unparen(star.X), true
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false, false
+	}
+	return ident.Name, pointer, true
 }
@@ -0,0 +1,108 @@
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// currentVersion is the version of the baseline file format
+const currentVersion = 1
+
+// digitsPattern matches numbers in issue messages, which tend to change as code is edited
+var digitsPattern = regexp.MustCompile(`\d+`)
+
+// Baseline records the issues that already existed when the baseline was written
+type Baseline struct {
+	Version      int            `json:"version"`
+	Fingerprints map[string]int `json:"fingerprints"` // Map of issue fingerprint to number of occurrences
+}
+
+// New creates a baseline from a list of issues
+func New(issues []*models.Issue) *Baseline {
+	b := &Baseline{
+		Version:      currentVersion,
+		Fingerprints: make(map[string]int),
+	}
+
+	for _, issue := range issues {
+		b.Fingerprints[Fingerprint(issue)]++
+	}
+
+	return b
+}
+
+// Load loads a baseline from a file
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline file: %w", err)
+	}
+
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline file: %w", err)
+	}
+
+	if b.Version != currentVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d (expected %d)", b.Version, currentVersion)
+	}
+	if b.Fingerprints == nil {
+		b.Fingerprints = make(map[string]int)
+	}
+
+	return &b, nil
+}
+
+// Save saves the baseline to a file
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write baseline file: %w", err)
+	}
+
+	return nil
+}
+
+// Filter returns the issues that are not part of the baseline. Each baseline entry
+// absorbs as many issues as were recorded for it, so a new occurrence of an
+// existing issue is still reported.
+func (b *Baseline) Filter(issues []*models.Issue) []*models.Issue {
+	remaining := make(map[string]int, len(b.Fingerprints))
+	for fingerprint, count := range b.Fingerprints {
+		remaining[fingerprint] = count
+	}
+
+	var filtered []*models.Issue
+	for _, issue := range issues {
+		fingerprint := Fingerprint(issue)
+		if remaining[fingerprint] > 0 {
+			remaining[fingerprint]--
+			continue
+		}
+		filtered = append(filtered, issue)
+	}
+
+	return filtered
+}
+
+// Fingerprint computes an identifier for an issue that is stable across line shifts.
+// It is derived from the rule, the file, and the message with numbers removed.
+func Fingerprint(issue *models.Issue) string {
+	message := digitsPattern.ReplaceAllString(strings.ToLower(issue.Message), "#")
+	message = strings.Join(strings.Fields(message), " ")
+
+	hash := sha256.Sum256([]byte(issue.Rule + "\x00" + filepath.ToSlash(issue.File) + "\x00" + message))
+	return hex.EncodeToString(hash[:])
+}
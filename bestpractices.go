@@ -1,9 +1,14 @@
 package bestpractices
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
 
+	"github.com/user/code-review-assistant/internal/config"
 	"github.com/user/code-review-assistant/internal/models"
 )
 
@@ -13,11 +18,14 @@ type BestPractice struct {
 	Description string
 	Category    string
 	Severity    string
-	Detector    func(fset *token.FileSet, node ast.Node) *models.Issue
+	Suggestion  string // Suggested fix, unless the detector gives its own
+	Detector    func(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue
+	Typed       bool // Whether the detector uses type information
 }
 
-// GetGoBestPractices returns a list of Go-specific best practices to check
-func GetGoBestPractices() []*BestPractice {
+// GetGoBestPractices returns a list of Go-specific best practices to check, with the
+// settings of the configuration
+func GetGoBestPractices(cfg *config.Config) []*BestPractice {
 	return []*BestPractice{
 		// Error handling best practice
 		{
@@ -25,7 +33,142 @@ func GetGoBestPractices() []*BestPractice {
 			Description: "Proper error handling",
 			Category:    "best-practice",
 			Severity:    "high",
+			Suggestion:  "Handle the error, or assign it to _ if it can be safely ignored",
 			Detector:    detectImproperErrorHandling,
+			Typed:       true,
+		},
+		// Error shadowing
+		{
+			Name:        "error-shadowing",
+			Description: "Shadowed error variables",
+			Category:    "best-practice",
+			Severity:    "high",
+			Suggestion:  "Assign to the outer err with = instead of :=, or give the inner error a different name",
+			Detector:    detectErrorShadowing,
+		},
+		// Unchecked type assertions
+		{
+			Name:        "unchecked-type-assertion",
+			Description: "Type assertions without the comma-ok form",
+			Category:    "best-practice",
+			Severity:    "medium",
+			Suggestion:  "Use the comma-ok form, v, ok := x.(T), and handle the case where ok is false",
+			Detector:    detectUncheckedTypeAssertion,
+		},
+		// Lost append results
+		{
+			Name:        "lost-append",
+			Description: "Result of append discarded or assigned to another variable",
+			Category:    "best-practice",
+			Severity:    "medium",
+			Suggestion:  "Assign the result of append back to the slice it appends to, e.g. s = append(s, x)",
+			Detector:    detectLostAppend,
+		},
+		// Appending to a slice made with a length
+		{
+			Name:        "append-after-make",
+			Description: "Slice made with a non-zero length and then appended to in a loop",
+			Category:    "best-practice",
+			Severity:    "high",
+			Suggestion:  "Make the slice with length 0 and the capacity as third argument, as in make([]T, 0, n), or assign s[i] in the loop instead of appending",
+			Detector:    detectAppendAfterMake,
+		},
+		// Environment variables read without a fallback
+		{
+			Name:        "getenv-without-fallback",
+			Description: "Environment variable used without checking whether it is set",
+			Category:    "best-practice",
+			Severity:    "low",
+			Suggestion:  "Use os.LookupEnv to tell whether the variable is set, or fall back to a default when the value is empty",
+			Detector:    detectGetenvWithoutFallback,
+		},
+		// Deprecated io/ioutil package
+		{
+			Name:        "deprecated-ioutil",
+			Description: "Use of the deprecated io/ioutil package",
+			Category:    "best-practice",
+			Severity:    "low",
+			Detector:    detectDeprecatedIoutil,
+		},
+		// Errors of other packages returned without context
+		{
+			Name:        "unwrapped-error",
+			Description: "Exported function returning an error of another package without adding context",
+			Category:    "best-practice",
+			Severity:    "low",
+			Detector:    detectUnwrappedError,
+			Typed:       true,
+		},
+		// Imports banned by the configuration
+		{
+			Name:        "banned-import",
+			Description: "Import of a package banned by banned_imports",
+			Category:    "best-practice",
+			Severity:    "medium",
+			Detector:    bannedImports(cfg.BannedImports),
+		},
+		// Opened resource never closed
+		{
+			Name:        "unclosed-resource",
+			Description: "File, connection, or database opened by a function that never closes it",
+			Category:    "best-practice",
+			Severity:    "high",
+			Detector:    detectUnclosedResource,
+		},
+		// Value returned with an error used after an error branch that does not leave
+		{
+			Name:        "use-after-error",
+			Description: "Value returned with an error used after an if err != nil branch that neither returns nor leaves the loop",
+			Category:    "best-practice",
+			Severity:    "high",
+			Suggestion:  "Return, continue, or otherwise leave the error branch before the value is used, or give the value a fallback in it",
+			Detector:    detectUseAfterError,
+			Typed:       true,
+		},
+		// Deferred Close of a file opened for writing
+		{
+			Name:        "unchecked-write-close",
+			Description: "Deferred Close of a file opened for writing, discarding the error that may report a failed write",
+			Category:    "best-practice",
+			Severity:    "low",
+			Detector:    detectUncheckedWriteClose,
+		},
+		// Sentinel errors compared with == instead of errors.Is
+		{
+			Name:        "error-comparison",
+			Description: "Error compared to a sentinel error with == or != instead of errors.Is",
+			Category:    "best-practice",
+			Severity:    "medium",
+			Suggestion:  "Use errors.Is, which also matches errors wrapping the sentinel error",
+			Detector:    errorComparison(cfg.SentinelErrorAllowlist),
+			Typed:       true,
+		},
+		// Nil or length check guarding only a range loop
+		{
+			Name:        "redundant-range-guard",
+			Description: "Nil or length check whose body only ranges over the checked slice or map",
+			Category:    "code-smell",
+			Severity:    "low",
+			Detector:    detectRedundantRangeGuard,
+			Typed:       true,
+		},
+		// Index of the last or first element without a length check
+		{
+			Name:        "unguarded-index",
+			Description: "s[len(s)-1], or s[0] of a slice parameter, without a check that the slice is not empty",
+			Category:    "best-practice",
+			Severity:    "medium",
+			Suggestion:  "Check the length first, as in if len(s) > 0, or return early when it is 0",
+			Detector:    detectUnguardedIndex,
+			Typed:       true,
+		},
+		// Else after an if block that returns
+		{
+			Name:        "unnecessary-else",
+			Description: "Else block after an if block that ends in a return, break, continue, or panic",
+			Category:    "code-smell",
+			Severity:    "low",
+			Detector:    detectUnnecessaryElse,
 		},
 		// Context propagation
 		{
@@ -49,6 +192,7 @@ func GetGoBestPractices() []*BestPractice {
 			Description: "Proper use of defer",
 			Category:    "best-practice",
 			Severity:    "medium",
+			Suggestion:  "Defer is most commonly used for closing resources. Consider if this is the appropriate use case.",
 			Detector:    detectImproperDeferUsage,
 		},
 		// Named return values
@@ -73,6 +217,7 @@ func GetGoBestPractices() []*BestPractice {
 			Description: "Proper function naming",
 			Category:    "best-practice",
 			Severity:    "low",
+			Suggestion:  "Use camelCase for unexported functions",
 			Detector:    detectImproperFunctionNaming,
 		},
 		// Variable naming
@@ -86,8 +231,88 @@ func GetGoBestPractices() []*BestPractice {
 	}
 }
 
-// detectImproperErrorHandling detects improper error handling
-func detectImproperErrorHandling(fset *token.FileSet, node ast.Node) *models.Issue {
+// detectImproperErrorHandling detects calls whose error result is discarded. With type
+// information, any call whose last result is an error and that is used as a statement is
+// reported; assigning the error to _ counts as handling it. Without type information, only
+// a few well-known functions are recognized.
+func detectImproperErrorHandling(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	if info == nil {
+		return detectKnownErrorReturns(fset, node)
+	}
+
+	exprStmt, ok := node.(*ast.ExprStmt)
+	if !ok {
+		return nil
+	}
+	callExpr, ok := ast.Unparen(exprStmt.X).(*ast.CallExpr)
+	if !ok || !returnsError(info, callExpr) || ignorableError(info, callExpr) {
+		return nil
+	}
+
+	funcName := types.ExprString(callExpr.Fun)
+	pos := fset.Position(exprStmt.Pos())
+	return []*models.Issue{{
+		File:       pos.Filename,
+		Line:       pos.Line,
+		Column:     pos.Column,
+		Message:    "Error not handled from call to '" + funcName + "'",
+		Category:   "best-practice",
+		Severity:   "high",
+		Confidence: "high",
+		Rule:       "error-handling",
+	}}
+}
+
+// returnsError reports whether the last result of a call is of type error
+func returnsError(info *types.Info, callExpr *ast.CallExpr) bool {
+	if tv, ok := info.Types[callExpr.Fun]; !ok || tv.IsType() {
+		return false
+	}
+	sig, ok := info.TypeOf(callExpr.Fun).Underlying().(*types.Signature)
+	if !ok || sig.Results().Len() == 0 {
+		return false
+	}
+	last := sig.Results().At(sig.Results().Len() - 1).Type()
+	return types.Identical(last, types.Universe.Lookup("error").Type())
+}
+
+// ignorableErrors lists the functions whose error is conventionally not checked, because
+// it is always nil or only reports a failure to write output
+var ignorableErrors = map[string]bool{
+	"fmt.Fprint":                     true,
+	"fmt.Fprintf":                    true,
+	"fmt.Fprintln":                   true,
+	"fmt.Print":                      true,
+	"fmt.Printf":                     true,
+	"fmt.Println":                    true,
+	"(*bytes.Buffer).Write":          true,
+	"(*bytes.Buffer).WriteByte":      true,
+	"(*bytes.Buffer).WriteRune":      true,
+	"(*bytes.Buffer).WriteString":    true,
+	"(*strings.Builder).Write":       true,
+	"(*strings.Builder).WriteByte":   true,
+	"(*strings.Builder).WriteRune":   true,
+	"(*strings.Builder).WriteString": true,
+}
+
+// ignorableError reports whether a call is to one of the ignorableErrors functions
+func ignorableError(info *types.Info, callExpr *ast.CallExpr) bool {
+	var ident *ast.Ident
+	switch fun := ast.Unparen(callExpr.Fun).(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return false
+	}
+	fn, ok := info.Uses[ident].(*types.Func)
+	return ok && ignorableErrors[fn.FullName()]
+}
+
+// detectKnownErrorReturns detects assignments that do not capture the error returned by
+// common standard library functions
+func detectKnownErrorReturns(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Look for ignored errors in assignment statements
 	assignStmt, ok := node.(*ast.AssignStmt)
 	if !ok {
@@ -125,7 +350,7 @@ func detectImproperErrorHandling(fset *token.FileSet, node ast.Node) *models.Iss
 
 		if errorReturningFuncs[funcName] && len(assignStmt.Lhs) < 2 {
 			pos := fset.Position(assignStmt.Pos())
-			return &models.Issue{
+			return []*models.Issue{{
 				File:       pos.Filename,
 				Line:       pos.Line,
 				Column:     pos.Column,
@@ -135,109 +360,1882 @@ func detectImproperErrorHandling(fset *token.FileSet, node ast.Node) *models.Iss
 				Confidence: "medium",
 				Suggestion: "Capture and handle the error return value",
 				Rule:       "error-handling",
-			}
+			}}
 		}
 	}
 
 	return nil
 }
 
-// detectMissingContextPropagation detects missing context propagation
-func detectMissingContextPropagation(fset *token.FileSet, node ast.Node) *models.Issue {
-	// Implementation will be added
-	return nil
-}
+// detectErrorShadowing detects err variables declared with := in a nested scope while an
+// err of an enclosing scope is read after the nested scope ends, so the outer check does
+// not see the inner error
+func detectErrorShadowing(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil {
+		return nil
+	}
 
-// detectInterfaceSegregation detects violations of interface segregation principle
-func detectInterfaceSegregation(fset *token.FileSet, node ast.Node) *models.Issue {
-	// Implementation will be added
-	return nil
+	// scope is a block of the function and the err declared in it, if any
+	type scope struct {
+		node ast.Node
+		err  *ast.Object
+	}
+	type shadow struct {
+		ident *ast.Ident
+		outer *ast.Object
+		end   token.Pos
+	}
+
+	var scopes []*scope
+	var nodes []ast.Node
+	var shadows []shadow
+	var uses []*ast.Ident
+	assigned := make(map[*ast.Ident]bool)
+
+	declare := func(ident *ast.Ident) {
+		if ident.Name == "err" && ident.Obj != nil {
+			scopes[len(scopes)-1].err = ident.Obj
+		}
+	}
+	define := func(assign *ast.AssignStmt) {
+		for _, lhs := range assign.Lhs {
+			ident, ok := lhs.(*ast.Ident)
+			// Redeclarations in the same scope refer to the earlier declaration
+			if !ok || ident.Name != "err" || ident.Obj == nil || ident.Obj.Decl != assign {
+				continue
+			}
+			current := scopes[len(scopes)-1]
+			for i := len(scopes) - 2; i >= 0 && current.err == nil; i-- {
+				if scopes[i].err != nil {
+					shadows = append(shadows, shadow{ident: ident, outer: scopes[i].err, end: current.node.End()})
+					break
+				}
+			}
+			current.err = ident.Obj
+		}
+	}
+
+	ast.Inspect(funcDecl, func(n ast.Node) bool {
+		if n == nil {
+			// Variables are in scope after their declaration, not in the closures of
+			// their values, so they are declared once the values are walked
+			last := nodes[len(nodes)-1]
+			switch last := last.(type) {
+			case *ast.ValueSpec:
+				for _, name := range last.Names {
+					declare(name)
+				}
+			case *ast.AssignStmt:
+				if last.Tok == token.DEFINE {
+					define(last)
+				}
+			}
+			if scopes[len(scopes)-1].node == last {
+				scopes = scopes[:len(scopes)-1]
+			}
+			nodes = nodes[:len(nodes)-1]
+			return true
+		}
+
+		// Function bodies share the scope of the parameters
+		switch n := n.(type) {
+		case *ast.FuncDecl, *ast.FuncLit, *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt,
+			*ast.TypeSwitchStmt, *ast.SelectStmt, *ast.CaseClause, *ast.CommClause:
+			scopes = append(scopes, &scope{node: n})
+		case *ast.BlockStmt:
+			switch nodes[len(nodes)-1].(type) {
+			case *ast.FuncDecl, *ast.FuncLit:
+			default:
+				scopes = append(scopes, &scope{node: n})
+			}
+		}
+		nodes = append(nodes, n)
+
+		switch n := n.(type) {
+		case *ast.FuncType:
+			for _, fields := range []*ast.FieldList{n.Params, n.Results} {
+				if fields == nil {
+					continue
+				}
+				for _, field := range fields.List {
+					for _, name := range field.Names {
+						declare(name)
+					}
+				}
+			}
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					assigned[ident] = true
+				}
+			}
+		case *ast.Ident:
+			if n.Name == "err" && n.Obj != nil {
+				uses = append(uses, n)
+			}
+		}
+
+		return true
+	})
+
+	// The outer err is stale if it is read after the nested scope before being assigned again
+	var issues []*models.Issue
+	for _, s := range shadows {
+		for _, use := range uses {
+			if use.Obj != s.outer || use.Pos() < s.end {
+				continue
+			}
+			if assigned[use] {
+				break
+			}
+			pos := fset.Position(s.ident.Pos())
+			outerPos := fset.Position(s.outer.Pos())
+			issues = append(issues, &models.Issue{
+				File:       pos.Filename,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Message:    fmt.Sprintf("Declaration of 'err' shadows err declared at line %d, which is checked after this block", outerPos.Line),
+				Category:   "best-practice",
+				Severity:   "high",
+				Confidence: "medium",
+				Rule:       "error-shadowing",
+			})
+			break
+		}
+	}
+
+	return issues
 }
 
-// detectImproperDeferUsage detects improper use of defer
-func detectImproperDeferUsage(fset *token.FileSet, node ast.Node) *models.Issue {
-	deferStmt, ok := node.(*ast.DeferStmt)
+// detectUncheckedTypeAssertion detects type assertions that panic when the value has a
+// different type, i.e. all assertions other than the comma-ok form and type switches
+func detectUncheckedTypeAssertion(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	file, ok := node.(*ast.File)
 	if !ok {
 		return nil
 	}
 
-	// Check for deferred function calls that don't close resources
-	callExpr, ok := deferStmt.Call.(*ast.CallExpr)
+	// Collect the assertions whose result is checked with the comma-ok form first
+	checked := make(map[*ast.TypeAssertExpr]bool)
+	commaOk := func(lhs int, rhs []ast.Expr) {
+		if lhs == 2 && len(rhs) == 1 {
+			if assert, ok := ast.Unparen(rhs[0]).(*ast.TypeAssertExpr); ok {
+				checked[assert] = true
+			}
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			commaOk(len(n.Lhs), n.Rhs)
+		case *ast.ValueSpec:
+			commaOk(len(n.Names), n.Values)
+		}
+		return true
+	})
+
+	var issues []*models.Issue
+	ast.Inspect(file, func(n ast.Node) bool {
+		// Type switches use x.(type), which has no type
+		assert, ok := n.(*ast.TypeAssertExpr)
+		if !ok || assert.Type == nil || checked[assert] {
+			return true
+		}
+
+		pos := fset.Position(assert.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    "Type assertion to " + types.ExprString(assert.Type) + " panics if the value has a different type",
+			Category:   "best-practice",
+			Severity:   "medium",
+			Confidence: "medium",
+			Rule:       "unchecked-type-assertion",
+		})
+		return true
+	})
+
+	return issues
+}
+
+// detectLostAppend detects calls to append whose result is discarded, or assigned with =
+// to a variable other than the slice appended to, which then misses the appended elements
+func detectLostAppend(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	var issues []*models.Issue
+	report := func(call *ast.CallExpr, message string) {
+		pos := fset.Position(call.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    message,
+			Category:   "best-practice",
+			Severity:   "medium",
+			Confidence: "medium",
+			Rule:       "lost-append",
+		})
+	}
+
+	switch stmt := node.(type) {
+	case *ast.ExprStmt:
+		if call := appendCall(stmt.X); call != nil {
+			report(call, "Result of append is discarded")
+		}
+	case *ast.AssignStmt:
+		// Declaring a new slice with := is the usual way to build one from another
+		if stmt.Tok != token.ASSIGN || len(stmt.Lhs) != len(stmt.Rhs) {
+			return nil
+		}
+		for i, rhs := range stmt.Rhs {
+			call := appendCall(rhs)
+			if call == nil || len(call.Args) == 0 {
+				continue
+			}
+			// Appending to literals, calls, or slices with a capped capacity creates a new
+			// slice, and s = append(s[:i], ...) reuses s
+			src := ast.Unparen(call.Args[0])
+			switch arg := src.(type) {
+			case *ast.Ident:
+				if arg.Name == "nil" {
+					continue
+				}
+			case *ast.SelectorExpr, *ast.IndexExpr:
+			case *ast.SliceExpr:
+				if arg.Slice3 {
+					continue
+				}
+				src = ast.Unparen(arg.X)
+			default:
+				continue
+			}
+			dst := types.ExprString(ast.Unparen(stmt.Lhs[i]))
+			if dst != "_" && dst != types.ExprString(src) {
+				report(call, "Result of append to "+types.ExprString(src)+" is assigned to "+dst)
+			}
+		}
+	}
+
+	return issues
+}
+
+// appendCall returns an expression as a call to the built-in append, or nil if it is not one
+func appendCall(expr ast.Expr) *ast.CallExpr {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
 	if !ok {
 		return nil
 	}
+	if ident, ok := call.Fun.(*ast.Ident); !ok || ident.Name != "append" {
+		return nil
+	}
+	return call
+}
 
-	// Check if the deferred call is a method call
-	selectorExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
-	if !ok {
+// detectGetenvWithoutFallback detects values of os.Getenv that are used without checking
+// for an unset variable: passed directly to a call or concatenated, or assigned to a
+// variable that the function never compares to "" or passes to len. Returned values are
+// left to the caller to check.
+func detectGetenvWithoutFallback(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil {
 		return nil
 	}
 
-	// Check if the method is Close()
-	if selectorExpr.Sel.Name != "Close" {
-		pos := fset.Position(deferStmt.Pos())
-		return &models.Issue{
+	// Each read is unchecked unless the variable it is assigned to is checked
+	type envRead struct {
+		call *ast.CallExpr
+		dst  *ast.Ident
+	}
+	var reads []envRead
+	var checked []*ast.Ident
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if ident, ok := n.Fun.(*ast.Ident); ok && ident.Name == "len" && len(n.Args) == 1 {
+				if arg, ok := ast.Unparen(n.Args[0]).(*ast.Ident); ok {
+					checked = append(checked, arg)
+				}
+			}
+			for _, arg := range n.Args {
+				if call := getenvCall(arg); call != nil {
+					reads = append(reads, envRead{call: call})
+				}
+			}
+		case *ast.BinaryExpr:
+			switch n.Op {
+			case token.EQL, token.NEQ:
+				if isEmptyString(n.Y) {
+					if ident, ok := ast.Unparen(n.X).(*ast.Ident); ok {
+						checked = append(checked, ident)
+					}
+				} else if isEmptyString(n.X) {
+					if ident, ok := ast.Unparen(n.Y).(*ast.Ident); ok {
+						checked = append(checked, ident)
+					}
+				}
+			case token.ADD:
+				for _, operand := range []ast.Expr{n.X, n.Y} {
+					if call := getenvCall(operand); call != nil {
+						reads = append(reads, envRead{call: call})
+					}
+				}
+			}
+		case *ast.SwitchStmt:
+			if ident, ok := n.Tag.(*ast.Ident); ok {
+				checked = append(checked, ident)
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i, rhs := range n.Rhs {
+					dst, ok := n.Lhs[i].(*ast.Ident)
+					if call := getenvCall(rhs); call != nil && ok && dst.Name != "_" {
+						reads = append(reads, envRead{call: call, dst: dst})
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) == len(n.Values) {
+				for i, value := range n.Values {
+					if call := getenvCall(value); call != nil && n.Names[i].Name != "_" {
+						reads = append(reads, envRead{call: call, dst: n.Names[i]})
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	var issues []*models.Issue
+	for _, read := range reads {
+		if read.dst != nil && sameVariable(read.dst, checked) {
+			continue
+		}
+		name := "the variable"
+		if lit, ok := read.call.Args[0].(*ast.BasicLit); ok {
+			name = lit.Value
+		}
+		pos := fset.Position(read.call.Pos())
+		issues = append(issues, &models.Issue{
 			File:       pos.Filename,
 			Line:       pos.Line,
 			Column:     pos.Column,
-			Message:    "Defer used for function other than resource closing",
+			Message:    fmt.Sprintf("Value of os.Getenv(%s) is used without checking whether it is set", name),
 			Category:   "best-practice",
 			Severity:   "low",
-			Confidence: "low",
-			Suggestion: "Defer is most commonly used for closing resources. Consider if this is the appropriate use case.",
-			Rule:       "defer-usage",
-		}
+			Confidence: "medium",
+			Rule:       "getenv-without-fallback",
+		})
 	}
 
-	return nil
+	return issues
 }
 
-// detectImproperNamedReturns detects improper use of named return values
-func detectImproperNamedReturns(fset *token.FileSet, node ast.Node) *models.Issue {
-	// Implementation will be added
-	return nil
+// getenvCall returns an expression as a call to os.Getenv, or nil if it is not one
+func getenvCall(expr ast.Expr) *ast.CallExpr {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Getenv" {
+		return nil
+	}
+	if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "os" {
+		return nil
+	}
+	return call
 }
 
-// detectImproperPackageNaming detects improper package naming
-func detectImproperPackageNaming(fset *token.FileSet, node ast.Node) *models.Issue {
-	// Implementation will be added
-	return nil
+// isEmptyString reports whether an expression is the empty string literal
+func isEmptyString(expr ast.Expr) bool {
+	lit, ok := ast.Unparen(expr).(*ast.BasicLit)
+	return ok && lit.Kind == token.STRING && (lit.Value == `""` || lit.Value == "``")
 }
 
-// detectImproperFunctionNaming detects improper function naming
-func detectImproperFunctionNaming(fset *token.FileSet, node ast.Node) *models.Issue {
-	funcDecl, ok := node.(*ast.FuncDecl)
+// sameVariable reports whether one of idents refers to the same variable as ident
+func sameVariable(ident *ast.Ident, idents []*ast.Ident) bool {
+	for _, other := range idents {
+		if other.Name != ident.Name {
+			continue
+		}
+		if other.Obj == nil || ident.Obj == nil || other.Obj == ident.Obj {
+			return true
+		}
+	}
+	return false
+}
+
+// ioutilReplacement is the replacement of a deprecated io/ioutil function or variable
+type ioutilReplacement struct {
+	name string // Qualified name of the replacement
+	note string // Difference in behavior to be aware of, if any
+}
+
+// ioutilReplacements maps the members of io/ioutil, deprecated since Go 1.16, to their
+// replacements in the os and io packages
+var ioutilReplacements = map[string]ioutilReplacement{
+	"ReadAll":   {name: "io.ReadAll"},
+	"ReadFile":  {name: "os.ReadFile"},
+	"WriteFile": {name: "os.WriteFile"},
+	"ReadDir":   {name: "os.ReadDir", note: "it returns []os.DirEntry instead of []fs.FileInfo"},
+	"TempFile":  {name: "os.CreateTemp"},
+	"TempDir":   {name: "os.MkdirTemp"},
+	"NopCloser": {name: "io.NopCloser"},
+	"Discard":   {name: "io.Discard"},
+}
+
+// detectDeprecatedIoutil detects uses of the members of io/ioutil and suggests their
+// replacements in os and io
+func detectDeprecatedIoutil(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	sel, ok := node.(*ast.SelectorExpr)
 	if !ok {
 		return nil
 	}
-
-	name := funcDecl.Name.Name
-	
-	// Check for mixed case in unexported functions
-	if !funcDecl.Name.IsExported() {
-		for i, c := range name {
-			if i > 0 && c >= 'A' && c <= 'Z' {
-				pos := fset.Position(funcDecl.Pos())
-				return &models.Issue{
-					File:       pos.Filename,
-					Line:       pos.Line,
-					Column:     pos.Column,
-					Message:    "Unexported function '" + name + "' uses mixed case",
-					Category:   "best-practice",
-					Severity:   "low",
-					Confidence: "high",
-					Suggestion: "Use camelCase for unexported functions",
-					Rule:       "function-naming",
-				}
-			}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	if info != nil {
+		// The package may be imported under another name, and ioutil may be something else
+		pkgName, ok := info.Uses[pkg].(*types.PkgName)
+		if !ok || pkgName.Imported().Path() != "io/ioutil" {
+			return nil
 		}
+	} else if pkg.Name != "ioutil" {
+		return nil
+	}
+	replacement, ok := ioutilReplacements[sel.Sel.Name]
+	if !ok {
+		return nil
 	}
 
-	return nil
+	suggestion := fmt.Sprintf("Use %s instead", replacement.name)
+	if replacement.note != "" {
+		suggestion += ", noting that " + replacement.note
+	}
+	pos := fset.Position(sel.Pos())
+	return []*models.Issue{{
+		File:       pos.Filename,
+		Line:       pos.Line,
+		Column:     pos.Column,
+		Message:    fmt.Sprintf("ioutil.%s is deprecated since Go 1.16, use %s", sel.Sel.Name, replacement.name),
+		Category:   "best-practice",
+		Severity:   "low",
+		Confidence: "high",
+		Suggestion: suggestion,
+		Rule:       "deprecated-ioutil",
+	}}
 }
 
-// detectImproperVariableNaming detects improper variable naming
-func detectImproperVariableNaming(fset *token.FileSet, node ast.Node) *models.Issue {
-	// Implementation will be added
-	return nil
+// bannedImports returns a detector of imports of the packages in banned, which maps import
+// paths to the reason they are banned. A path also bans the packages below it, and the
+// longest matching path gives the reason.
+func bannedImports(banned map[string]string) func(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	return func(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+		spec, ok := node.(*ast.ImportSpec)
+		if !ok || len(banned) == 0 {
+			return nil
+		}
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil
+		}
+
+		match := ""
+		for prefix := range banned {
+			if (path == prefix || strings.HasPrefix(path, prefix+"/")) && len(prefix) > len(match) {
+				match = prefix
+			}
+		}
+		if match == "" {
+			return nil
+		}
+
+		suggestion := banned[match]
+		if suggestion == "" {
+			suggestion = fmt.Sprintf("Remove the import of %s", path)
+		}
+		message := fmt.Sprintf("Import of banned package %s", path)
+		if match != path {
+			message = fmt.Sprintf("Import of %s, below banned package %s", path, match)
+		}
+		pos := fset.Position(spec.Pos())
+		return []*models.Issue{{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    message,
+			Category:   "best-practice",
+			Severity:   "medium",
+			Confidence: "high",
+			Suggestion: suggestion,
+			Rule:       "banned-import",
+		}}
+	}
+}
+
+// resourceOpeners lists the functions returning a resource to close, by package
+var resourceOpeners = map[string]map[string]bool{
+	"os":  {"Open": true, "Create": true, "OpenFile": true},
+	"net": {"Dial": true, "DialTimeout": true},
+	"sql": {"Open": true},
+}
+
+// detectUnclosedResource detects resources assigned from os.Open, os.Create, net.Dial,
+// sql.Open and the like in a function that never calls their Close method, directly or
+// deferred, or passes them to a function with close in its name. Resources that the
+// function returns, stores, sends, or hands to a goroutine are left to their new owner.
+func detectUnclosedResource(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil {
+		return nil
+	}
+
+	var issues []*models.Issue
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE || len(assign.Rhs) != 1 {
+			return true
+		}
+		opener := resourceOpener(assign.Rhs[0])
+		resource, ok := assign.Lhs[0].(*ast.Ident)
+		if opener == "" || !ok || resource.Name == "_" || resourceReleased(funcDecl.Body, resource) {
+			return true
+		}
+
+		pos := fset.Position(assign.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    fmt.Sprintf("%s opened with %s is never closed", resource.Name, opener),
+			Category:   "best-practice",
+			Severity:   "high",
+			Confidence: "medium",
+			Suggestion: fmt.Sprintf("Add defer %s.Close() once the error of %s has been checked", resource.Name, opener),
+			Rule:       "unclosed-resource",
+		})
+		return true
+	})
+
+	return issues
+}
+
+// resourceOpener returns the name of the function opening a resource called by an
+// expression, such as os.Open, or an empty string
+func resourceOpener(expr ast.Expr) string {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || !resourceOpeners[pkg.Name][sel.Sel.Name] {
+		return ""
+	}
+	return pkg.Name + "." + sel.Sel.Name
+}
+
+// resourceReleased reports whether a function body closes a resource, or gives it away by
+// returning, storing, or sending it, or by passing it to a goroutine
+func resourceReleased(body *ast.BlockStmt, resource *ast.Ident) bool {
+	released := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if sel, ok := n.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Close" && holdsResource(sel.X, resource) {
+				released = true
+			}
+			if strings.Contains(strings.ToLower(types.ExprString(n.Fun)), "close") && holdsAnyResource(n.Args, resource) {
+				released = true
+			}
+		case *ast.ReturnStmt:
+			released = released || holdsAnyResource(n.Results, resource)
+		case *ast.AssignStmt:
+			released = released || holdsAnyResource(n.Rhs, resource)
+		case *ast.SendStmt:
+			released = released || holdsResource(n.Value, resource)
+		case *ast.GoStmt:
+			released = released || holdsAnyResource(n.Call.Args, resource)
+		}
+		return !released
+	})
+	return released
+}
+
+// holdsAnyResource reports whether one of exprs holds a resource
+func holdsAnyResource(exprs []ast.Expr, resource *ast.Ident) bool {
+	for _, expr := range exprs {
+		if holdsResource(expr, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// holdsResource reports whether an expression is the variable of a resource, or a value
+// holding it: its address, a composite literal with it as element, or a slice it is
+// appended to
+func holdsResource(expr ast.Expr, resource *ast.Ident) bool {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return sameVariable(e, []*ast.Ident{resource})
+	case *ast.ParenExpr:
+		return holdsResource(e.X, resource)
+	case *ast.UnaryExpr:
+		return e.Op == token.AND && holdsResource(e.X, resource)
+	case *ast.KeyValueExpr:
+		return holdsResource(e.Value, resource)
+	case *ast.CompositeLit:
+		return holdsAnyResource(e.Elts, resource)
+	case *ast.CallExpr:
+		fun, ok := e.Fun.(*ast.Ident)
+		return ok && fun.Name == "append" && holdsAnyResource(e.Args, resource)
+	}
+	return false
+}
+
+// writeFlags are the flags of os.OpenFile that open a file for writing
+var writeFlags = map[string]bool{"O_WRONLY": true, "O_RDWR": true, "O_APPEND": true, "O_CREATE": true, "O_TRUNC": true}
+
+// detectUncheckedWriteClose detects defer f.Close() in a function where f was created by
+// os.Create or opened by os.OpenFile with a write flag. Close may report a write that
+// failed, which a deferred call discards. Functions that also check the error of a Close
+// call, such as return f.Close() after the deferred one, are not reported.
+func detectUncheckedWriteClose(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil {
+		return nil
+	}
+
+	// Files opened for writing, by the function opening them
+	written := make(map[*ast.Object]string)
+	var deferred []*ast.DeferStmt
+	unchecked := make(map[*ast.CallExpr]bool)
+	checked := make(map[*ast.Object]bool)
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Rhs) != 1 {
+				return true
+			}
+			file, ok := n.Lhs[0].(*ast.Ident)
+			if opener := resourceOpener(n.Rhs[0]); opener != "" && ok && file.Obj != nil && opensForWriting(opener, n.Rhs[0].(*ast.CallExpr)) {
+				written[file.Obj] = opener
+			}
+		case *ast.DeferStmt:
+			deferred = append(deferred, n)
+			unchecked[n.Call] = true
+		case *ast.ExprStmt:
+			if call, ok := n.X.(*ast.CallExpr); ok {
+				unchecked[call] = true
+			}
+		case *ast.CallExpr:
+			if file := closedFile(n); file != nil && !unchecked[n] {
+				checked[file.Obj] = true
+			}
+		}
+		return true
+	})
+
+	var issues []*models.Issue
+	for _, stmt := range deferred {
+		file := closedFile(stmt.Call)
+		if file == nil || written[file.Obj] == "" || checked[file.Obj] {
+			continue
+		}
+		pos := fset.Position(stmt.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    fmt.Sprintf("Deferred %s.Close() discards the error of closing a file opened for writing with %s", file.Name, written[file.Obj]),
+			Category:   "best-practice",
+			Severity:   "low",
+			Confidence: "medium",
+			Suggestion: fmt.Sprintf("Return the error of Close through a named result, e.g. defer func() { if cerr := %s.Close(); cerr != nil && err == nil { err = cerr } }()", file.Name),
+			Rule:       "unchecked-write-close",
+		})
+	}
+
+	return issues
+}
+
+// opensForWriting reports whether a call to a resource opener opens a file for writing:
+// os.Create, or os.OpenFile with one of writeFlags
+func opensForWriting(opener string, call *ast.CallExpr) bool {
+	switch opener {
+	case "os.Create":
+		return true
+	case "os.OpenFile":
+		if len(call.Args) < 2 {
+			return false
+		}
+		found := false
+		ast.Inspect(call.Args[1], func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok && isIdentNamed(sel.X, "os") && writeFlags[sel.Sel.Name] {
+				found = true
+			}
+			return !found
+		})
+		return found
+	}
+	return false
+}
+
+// closedFile returns the variable whose Close method a call calls without arguments, or nil
+func closedFile(call *ast.CallExpr) *ast.Ident {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Close" || len(call.Args) != 0 {
+		return nil
+	}
+	file, ok := sel.X.(*ast.Ident)
+	if !ok || file.Obj == nil {
+		return nil
+	}
+	return file
+}
+
+// errorComparison returns a detector of errors compared to sentinel errors with == or !=,
+// or by a switch on the error, which miss errors wrapping the sentinel. Sentinel errors
+// are package-level variables of type error; without type information, variables whose
+// name starts with Err and io.EOF are assumed to be. Sentinels in allowlist, such as
+// "io.EOF", may be compared directly.
+func errorComparison(allowlist []string) func(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, name := range allowlist {
+		allowed[name] = true
+	}
+
+	return func(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+		confidence := "medium"
+		if info != nil {
+			confidence = "high"
+		}
+		report := func(at ast.Node, message, suggestion string) []*models.Issue {
+			pos := fset.Position(at.Pos())
+			return []*models.Issue{{
+				File:       pos.Filename,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Message:    message,
+				Category:   "best-practice",
+				Severity:   "medium",
+				Confidence: confidence,
+				Suggestion: suggestion,
+				Rule:       "error-comparison",
+			}}
+		}
+
+		switch n := node.(type) {
+		case *ast.BinaryExpr:
+			if n.Op != token.EQL && n.Op != token.NEQ {
+				return nil
+			}
+			errExpr, sentinel := n.X, sentinelError(info, n.Y, allowed)
+			if sentinel == "" {
+				errExpr, sentinel = n.Y, sentinelError(info, n.X, allowed)
+			}
+			if sentinel == "" || !isErrorValue(info, errExpr) {
+				return nil
+			}
+			errName := types.ExprString(errExpr)
+			negation := ""
+			if n.Op == token.NEQ {
+				negation = "!"
+			}
+			return report(n, fmt.Sprintf("Comparing %s to %s with %s misses errors wrapping %s", errName, sentinel, n.Op, sentinel),
+				fmt.Sprintf("Use %serrors.Is(%s, %s)", negation, errName, sentinel))
+
+		case *ast.SwitchStmt:
+			if n.Tag == nil || !isErrorValue(info, n.Tag) {
+				return nil
+			}
+			errName := types.ExprString(n.Tag)
+			var issues []*models.Issue
+			for _, stmt := range n.Body.List {
+				clause, ok := stmt.(*ast.CaseClause)
+				if !ok {
+					continue
+				}
+				for _, value := range clause.List {
+					if sentinel := sentinelError(info, value, allowed); sentinel != "" {
+						issues = append(issues, report(value, fmt.Sprintf("Switching on %s compares it to %s with ==, missing errors wrapping %s", errName, sentinel, sentinel),
+							fmt.Sprintf("Use a tagless switch with case errors.Is(%s, %s)", errName, sentinel))...)
+					}
+				}
+			}
+			return issues
+		}
+		return nil
+	}
+}
+
+// isErrorValue reports whether an expression is an error value: of type error with type
+// information, otherwise a variable named err or ending in Err
+func isErrorValue(info *types.Info, expr ast.Expr) bool {
+	if info != nil {
+		t := info.TypeOf(expr)
+		return t != nil && types.Identical(t, types.Universe.Lookup("error").Type())
+	}
+	ident, ok := expr.(*ast.Ident)
+	return ok && (ident.Name == "err" || strings.HasSuffix(ident.Name, "Err"))
+}
+
+// sentinelError returns the name of the sentinel error an expression refers to, such as
+// io.EOF or ErrNotFound, or an empty string if it refers to none or one in allowed
+func sentinelError(info *types.Info, expr ast.Expr, allowed map[string]bool) string {
+	var ident *ast.Ident
+	switch e := expr.(type) {
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		if _, ok := e.X.(*ast.Ident); !ok {
+			return ""
+		}
+		ident = e.Sel
+	default:
+		return ""
+	}
+
+	name := types.ExprString(expr)
+	if allowed[name] {
+		return ""
+	}
+	if info != nil {
+		v, ok := info.Uses[ident].(*types.Var)
+		if !ok || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() || !types.Implements(v.Type(), errorInterface) {
+			return ""
+		}
+		return name
+	}
+	if strings.HasPrefix(ident.Name, "Err") || name == "io.EOF" {
+		return name
+	}
+	return ""
+}
+
+// errorInterface is the underlying interface of the error type
+var errorInterface = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+// detectMissingContextPropagation detects missing context propagation
+func detectMissingContextPropagation(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	// Implementation will be added
+	return nil
+}
+
+// detectInterfaceSegregation detects violations of interface segregation principle
+func detectInterfaceSegregation(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	// Implementation will be added
+	return nil
+}
+
+// detectImproperDeferUsage detects improper use of defer
+func detectImproperDeferUsage(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	deferStmt, ok := node.(*ast.DeferStmt)
+	if !ok {
+		return nil
+	}
+
+	// Check for deferred function calls that don't close resources
+	callExpr, ok := deferStmt.Call.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+
+	// Check if the deferred call is a method call
+	selectorExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+
+	// Check if the method is Close()
+	if selectorExpr.Sel.Name != "Close" {
+		pos := fset.Position(deferStmt.Pos())
+		return []*models.Issue{{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    "Defer used for function other than resource closing",
+			Category:   "best-practice",
+			Severity:   "low",
+			Confidence: "low",
+			Rule:       "defer-usage",
+		}}
+	}
+
+	return nil
+}
+
+// detectImproperNamedReturns detects improper use of named return values
+func detectImproperNamedReturns(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	// Implementation will be added
+	return nil
+}
+
+// detectImproperPackageNaming detects improper package naming
+func detectImproperPackageNaming(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	// Implementation will be added
+	return nil
+}
+
+// detectImproperFunctionNaming detects improper function naming
+func detectImproperFunctionNaming(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok {
+		return nil
+	}
+
+	name := funcDecl.Name.Name
+	
+	// Check for mixed case in unexported functions
+	if !funcDecl.Name.IsExported() {
+		for i, c := range name {
+			if i > 0 && c >= 'A' && c <= 'Z' {
+				pos := fset.Position(funcDecl.Pos())
+				return []*models.Issue{{
+					File:       pos.Filename,
+					Line:       pos.Line,
+					Column:     pos.Column,
+					Message:    "Unexported function '" + name + "' uses mixed case",
+					Category:   "best-practice",
+					Severity:   "low",
+					Confidence: "high",
+					Rule:       "function-naming",
+				}}
+			}
+		}
+	}
+
+	return nil
+}
+
+// detectImproperVariableNaming detects improper variable naming
+func detectImproperVariableNaming(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	// Implementation will be added
+	return nil
+}
+
+// detectRedundantRangeGuard finds if statements that check a slice or map against nil, or
+// its length against zero, and do nothing but range over it, which runs no iterations
+// anyway. Channels are left alone, as ranging over a nil channel blocks forever.
+func detectRedundantRangeGuard(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	ifStmt, ok := node.(*ast.IfStmt)
+	if !ok || ifStmt.Init != nil || ifStmt.Else != nil || len(ifStmt.Body.List) != 1 {
+		return nil
+	}
+	rangeStmt, ok := ifStmt.Body.List[0].(*ast.RangeStmt)
+	if !ok {
+		return nil
+	}
+
+	guarded, check := rangeGuard(ifStmt.Cond)
+	if guarded == nil || !isPlainReference(guarded) || types.ExprString(guarded) != types.ExprString(rangeStmt.X) {
+		return nil
+	}
+	if !isSliceOrMap(info, guarded) {
+		return nil
+	}
+
+	name := types.ExprString(guarded)
+	confidence := "medium"
+	if info != nil {
+		confidence = "high"
+	}
+	pos := fset.Position(ifStmt.Pos())
+	return []*models.Issue{{
+		File:       pos.Filename,
+		Line:       pos.Line,
+		Column:     pos.Column,
+		Message:    fmt.Sprintf("Checking "+check+" before ranging over it is redundant", name),
+		Category:   "code-smell",
+		Severity:   "low",
+		Confidence: confidence,
+		Suggestion: fmt.Sprintf("Range over %s without the if statement: ranging over a nil or empty slice or map runs no iterations", name),
+		Rule:       "redundant-range-guard",
+	}}
+}
+
+// rangeGuard returns the expression checked by a condition of the form x != nil or
+// len(x) > 0, in either order or with != 0, and a description of the check formatting the
+// expression with %s
+func rangeGuard(cond ast.Expr) (ast.Expr, string) {
+	binary, ok := ast.Unparen(cond).(*ast.BinaryExpr)
+	if !ok {
+		return nil, ""
+	}
+	x, y, op := ast.Unparen(binary.X), ast.Unparen(binary.Y), binary.Op
+	if isIdentNamed(x, "nil") || isZero(x) {
+		// nil != x, 0 < len(x), and 0 != len(x)
+		x, y = y, x
+		if op == token.LSS {
+			op = token.GTR
+		}
+	}
+
+	if op == token.NEQ && isIdentNamed(y, "nil") {
+		return x, "%s against nil"
+	}
+	if (op == token.GTR || op == token.NEQ) && isZero(y) {
+		if call, ok := x.(*ast.CallExpr); ok && isIdentNamed(call.Fun, "len") && len(call.Args) == 1 {
+			return ast.Unparen(call.Args[0]), "the length of %s"
+		}
+	}
+	return nil, ""
+}
+
+// isIdentNamed reports whether an expression is the identifier name
+func isIdentNamed(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// isZero reports whether an expression is the integer literal 0
+func isZero(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.BasicLit)
+	return ok && lit.Kind == token.INT && lit.Value == "0"
+}
+
+// isPlainReference reports whether an expression is a variable or a chain of field
+// selections, whose evaluation has no side effects
+func isPlainReference(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		return isPlainReference(e.X)
+	}
+	return false
+}
+
+// isSliceOrMap reports whether an expression is a slice or map, from its type when type
+// information is available, or else from the declaration of the variable it names
+func isSliceOrMap(info *types.Info, expr ast.Expr) bool {
+	if info != nil {
+		if t := info.TypeOf(expr); t != nil {
+			switch t.Underlying().(type) {
+			case *types.Slice, *types.Map:
+				return true
+			}
+			return false
+		}
+	}
+
+	ident, ok := expr.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return false
+	}
+	var typeExpr ast.Expr
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		typeExpr = decl.Type
+	case *ast.ValueSpec:
+		typeExpr = decl.Type
+	}
+	switch t := typeExpr.(type) {
+	case *ast.ArrayType:
+		return t.Len == nil
+	case *ast.MapType:
+		return true
+	}
+	return false
+}
+
+// errorConstructors are the packages whose functions create errors rather than fail
+var errorConstructors = map[string]bool{"errors": true, "fmt": true}
+
+// detectUnwrappedError finds exported functions that return, as is, an error received from
+// a function of another package, so that callers cannot tell which step failed. Only bare
+// returns of the variable the error was last assigned to are reported.
+func detectUnwrappedError(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil || !funcDecl.Name.IsExported() || !declaresErrorResult(funcDecl.Type) {
+		return nil
+	}
+
+	confidence := "medium"
+	if info != nil {
+		confidence = "high"
+	}
+
+	// Package functions that last assigned each variable, empty for other assignments
+	origins := make(map[*ast.Object]string)
+	var issues []*models.Issue
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Returns in function literals do not return from funcDecl
+			return false
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok || ident.Obj == nil {
+					continue
+				}
+				var rhs ast.Expr
+				if len(n.Rhs) == len(n.Lhs) {
+					rhs = n.Rhs[i]
+				} else if len(n.Rhs) == 1 {
+					rhs = n.Rhs[0]
+				}
+				origins[ident.Obj] = packageCall(info, rhs)
+			}
+		case *ast.ReturnStmt:
+			if len(n.Results) == 0 {
+				return true
+			}
+			ident, ok := n.Results[len(n.Results)-1].(*ast.Ident)
+			if !ok || ident.Obj == nil || origins[ident.Obj] == "" {
+				return true
+			}
+			call := origins[ident.Obj]
+			pos := fset.Position(n.Pos())
+			issues = append(issues, &models.Issue{
+				File:       pos.Filename,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Message:    fmt.Sprintf("%s returns the error of %s without context", funcDecl.Name.Name, call),
+				Category:   "best-practice",
+				Severity:   "low",
+				Confidence: confidence,
+				Suggestion: fmt.Sprintf("Wrap the error with what failed, e.g. return fmt.Errorf(\"...: %%w\", %s)", ident.Name),
+				Rule:       "unwrapped-error",
+			})
+		}
+		return true
+	})
+
+	return issues
+}
+
+// declaresErrorResult reports whether the last result of a function type is an error
+func declaresErrorResult(funcType *ast.FuncType) bool {
+	if funcType.Results == nil || len(funcType.Results.List) == 0 {
+		return false
+	}
+	last := funcType.Results.List[len(funcType.Results.List)-1]
+	ident, ok := last.Type.(*ast.Ident)
+	return ok && ident.Name == "error"
+}
+
+// packageCall returns the name, such as os.Open, of the function of another package that
+// expr calls, or an empty string if expr is not such a call. Without type information,
+// unresolved identifiers are taken for package names.
+func packageCall(info *types.Info, expr ast.Expr) string {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || errorConstructors[pkg.Name] {
+		return ""
+	}
+	if info != nil {
+		if _, ok := info.Uses[pkg].(*types.PkgName); !ok {
+			return ""
+		}
+	} else if pkg.Obj != nil {
+		return ""
+	}
+	return pkg.Name + "." + sel.Sel.Name
+}
+
+// detectUseAfterError detects statements such as x, err := f() followed by an if err != nil
+// branch that does not leave, after which a field or method of x is used or x is
+// dereferenced, while x is likely nil. With type information, only pointers and interfaces
+// are reported.
+func detectUseAfterError(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	block, ok := node.(*ast.BlockStmt)
+	if !ok {
+		return nil
+	}
+
+	var issues []*models.Issue
+	for i := 0; i+1 < len(block.List); i++ {
+		assign, ok := block.List[i].(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) < 2 || len(assign.Rhs) != 1 {
+			continue
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		errIdent, ok := assign.Lhs[len(assign.Lhs)-1].(*ast.Ident)
+		if !ok || errIdent.Obj == nil {
+			continue
+		}
+		check, ok := block.List[i+1].(*ast.IfStmt)
+		if !ok || check.Init != nil || check.Else != nil || !isErrNotNil(check.Cond, errIdent.Obj) || terminates(check.Body) {
+			continue
+		}
+
+		for _, lhs := range assign.Lhs[:len(assign.Lhs)-1] {
+			value, ok := lhs.(*ast.Ident)
+			if !ok || value.Obj == nil || assigns(check.Body, value.Obj) || !mayBeNil(info, value) {
+				continue
+			}
+			use := firstDereference(block.List[i+2:], value.Obj)
+			if use == nil {
+				continue
+			}
+			pos := fset.Position(use.Pos())
+			issues = append(issues, &models.Issue{
+				File:       pos.Filename,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Message:    fmt.Sprintf("%s is used after the error of %s was handled without returning, when it may be nil", value.Name, types.ExprString(call.Fun)),
+				Category:   "best-practice",
+				Severity:   "high",
+				Confidence: "medium",
+				Rule:       "use-after-error",
+			})
+		}
+	}
+
+	return issues
+}
+
+// isErrNotNil reports whether a condition is err != nil for the variable err
+func isErrNotNil(cond ast.Expr, err *ast.Object) bool {
+	binary, ok := ast.Unparen(cond).(*ast.BinaryExpr)
+	if !ok || binary.Op != token.NEQ || !isIdentNamed(binary.Y, "nil") {
+		return false
+	}
+	ident, ok := ast.Unparen(binary.X).(*ast.Ident)
+	return ok && ident.Obj == err
+}
+
+// leavingCalls are the methods and functions that do not return to their caller
+var leavingCalls = map[string]bool{
+	"panic": true, "Exit": true, "Goexit": true,
+	"Fatal": true, "Fatalf": true, "Fatalln": true,
+	"Panic": true, "Panicf": true, "Panicln": true,
+	"FailNow": true, "Skip": true, "Skipf": true, "SkipNow": true,
+}
+
+// terminates reports whether control never flows past a statement: it returns, branches,
+// or calls a function that does not return. Loops, switches, and selects are assumed to
+// terminate, as their branches are not followed.
+func terminates(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.BranchStmt:
+		return s.Tok != token.FALLTHROUGH
+	case *ast.BlockStmt:
+		return len(s.List) > 0 && terminates(s.List[len(s.List)-1])
+	case *ast.IfStmt:
+		return s.Else != nil && terminates(s.Body) && terminates(s.Else)
+	case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+		return true
+	case *ast.LabeledStmt:
+		return terminates(s.Stmt)
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			return fun.Name == "panic"
+		case *ast.SelectorExpr:
+			return leavingCalls[fun.Sel.Name]
+		}
+	}
+	return false
+}
+
+// assigns reports whether a node assigns a variable
+func assigns(node ast.Node, obj *ast.Object) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok {
+			for _, lhs := range assign.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok && ident.Obj == obj {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// mayBeNil reports whether a variable can be nil when dereferenced: a pointer or an
+// interface, or any variable without type information
+func mayBeNil(info *types.Info, ident *ast.Ident) bool {
+	if info == nil || info.TypeOf(ident) == nil {
+		return true
+	}
+	switch info.TypeOf(ident).Underlying().(type) {
+	case *types.Pointer, *types.Interface:
+		return true
+	}
+	return false
+}
+
+// firstDereference returns the first selector or dereference of a variable in stmts, or nil
+// if the variable is assigned first or never dereferenced
+func firstDereference(stmts []ast.Stmt, obj *ast.Object) ast.Node {
+	for _, stmt := range stmts {
+		var use ast.Node
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if use != nil {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if ident, ok := n.X.(*ast.Ident); ok && ident.Obj == obj {
+					use = n
+				}
+			case *ast.StarExpr:
+				if ident, ok := n.X.(*ast.Ident); ok && ident.Obj == obj {
+					use = n
+				}
+			}
+			return true
+		})
+		if use != nil {
+			return use
+		}
+		if assigns(stmt, obj) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// detectAppendAfterMake detects slices made with make([]T, n) and then appended to in a
+// loop of the same block, which leaves n zero values before the appended elements. Slices
+// whose elements are assigned or copied into before the loop or in it are not reported.
+func detectAppendAfterMake(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	block, ok := node.(*ast.BlockStmt)
+	if !ok {
+		return nil
+	}
+
+	var issues []*models.Issue
+	for i, stmt := range block.List {
+		slice, length := madeWithLength(stmt)
+		if slice == nil {
+			continue
+		}
+		for _, later := range block.List[i+1:] {
+			if fillsSlice(later, slice.Obj) {
+				break
+			}
+			if call := appendInLoop(later, slice.Obj); call != nil {
+				pos := fset.Position(call.Pos())
+				issues = append(issues, &models.Issue{
+					File:       pos.Filename,
+					Line:       pos.Line,
+					Column:     pos.Column,
+					Message:    fmt.Sprintf("%s is made with length %s and then appended to, so it starts with %s zero values before the appended elements", slice.Name, types.ExprString(length), types.ExprString(length)),
+					Category:   "best-practice",
+					Severity:   "high",
+					Confidence: "medium",
+					Rule:       "append-after-make",
+				})
+				break
+			}
+			if assigns(later, slice.Obj) {
+				break
+			}
+		}
+	}
+
+	return issues
+}
+
+// madeWithLength returns the variable a statement assigns make([]T, n) to and the length n,
+// or nil if the statement does not make a slice with a length other than 0
+func madeWithLength(stmt ast.Stmt) (*ast.Ident, ast.Expr) {
+	var lhs, rhs ast.Expr
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		if len(s.Lhs) == 1 && len(s.Rhs) == 1 {
+			lhs, rhs = s.Lhs[0], s.Rhs[0]
+		}
+	case *ast.DeclStmt:
+		if gen, ok := s.Decl.(*ast.GenDecl); ok && len(gen.Specs) == 1 {
+			if spec, ok := gen.Specs[0].(*ast.ValueSpec); ok && len(spec.Names) == 1 && len(spec.Values) == 1 {
+				lhs, rhs = spec.Names[0], spec.Values[0]
+			}
+		}
+	}
+	ident, ok := lhs.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return nil, nil
+	}
+	call, ok := ast.Unparen(rhs).(*ast.CallExpr)
+	if !ok || !isIdentNamed(call.Fun, "make") || len(call.Args) != 2 || isZero(call.Args[1]) {
+		return nil, nil
+	}
+	if slice, ok := call.Args[0].(*ast.ArrayType); !ok || slice.Len != nil {
+		return nil, nil
+	}
+	return ident, call.Args[1]
+}
+
+// fillsSlice reports whether a node assigns an element of a slice or copies into it
+func fillsSlice(node ast.Node, obj *ast.Object) bool {
+	isSlice := func(expr ast.Expr) bool {
+		ident, ok := ast.Unparen(expr).(*ast.Ident)
+		return ok && ident.Obj == obj
+	}
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if index, ok := lhs.(*ast.IndexExpr); ok && isSlice(index.X) {
+					found = true
+				}
+			}
+		case *ast.CallExpr:
+			if isIdentNamed(n.Fun, "copy") && len(n.Args) == 2 && isSlice(n.Args[0]) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// appendInLoop returns the first s = append(s, ...) of a slice in the body of a loop
+// statement, or nil if the statement is not a loop or does not append to the slice
+func appendInLoop(stmt ast.Stmt, obj *ast.Object) *ast.CallExpr {
+	for {
+		labeled, ok := stmt.(*ast.LabeledStmt)
+		if !ok {
+			break
+		}
+		stmt = labeled.Stmt
+	}
+	var body *ast.BlockStmt
+	switch loop := stmt.(type) {
+	case *ast.ForStmt:
+		body = loop.Body
+	case *ast.RangeStmt:
+		body = loop.Body
+	default:
+		return nil
+	}
+
+	var found *ast.CallExpr
+	ast.Inspect(body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != len(assign.Rhs) {
+			return found == nil
+		}
+		for i, lhs := range assign.Lhs {
+			ident, ok := lhs.(*ast.Ident)
+			if !ok || ident.Obj != obj {
+				continue
+			}
+			call := appendCall(assign.Rhs[i])
+			if call == nil || len(call.Args) < 2 {
+				continue
+			}
+			if src, ok := ast.Unparen(call.Args[0]).(*ast.Ident); ok && src.Obj == obj {
+				found = call
+			}
+		}
+		return found == nil
+	})
+	return found
+}
+
+// detectUnguardedIndex detects indexes of the last element of a slice, s[len(s)-1], and of
+// the first element of a slice parameter, s[0], that panic on an empty slice, when nothing
+// in the function ensures the slice has elements: an enclosing if or for condition, a
+// preceding if statement that leaves when the slice is empty, a range over the slice, or an
+// assignment of a non-empty slice. With type information, maps and arrays are left alone.
+func detectUnguardedIndex(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	funcDecl, ok := node.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil {
+		return nil
+	}
+	params := make(map[*ast.Object]bool)
+	for _, field := range funcDecl.Type.Params.List {
+		switch t := field.Type.(type) {
+		case *ast.ArrayType:
+			if t.Len != nil {
+				continue
+			}
+		case *ast.Ellipsis:
+		default:
+			continue
+		}
+		for _, name := range field.Names {
+			params[name.Obj] = true
+		}
+	}
+
+	var issues []*models.Issue
+	var stack []ast.Node
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+
+		index, ok := n.(*ast.IndexExpr)
+		if !ok || !isPlainReference(index.X) {
+			return true
+		}
+		target := types.ExprString(index.X)
+		var message string
+		if isLastIndex(index.Index, target) {
+			message = fmt.Sprintf("%s panics when %s is empty", types.ExprString(index), target)
+		} else if ident, ok := index.X.(*ast.Ident); ok && isZero(index.Index) && ident.Obj != nil && params[ident.Obj] {
+			message = fmt.Sprintf("%s panics when the %s parameter is empty", types.ExprString(index), target)
+		} else {
+			return true
+		}
+		if info != nil && info.TypeOf(index.X) != nil {
+			if _, ok := info.TypeOf(index.X).Underlying().(*types.Slice); !ok {
+				return true
+			}
+		} else if declaredMap(index.X) {
+			return true
+		}
+		if nonEmpty(stack, target) {
+			return true
+		}
+
+		pos := fset.Position(index.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    message + ", and its length is not checked first",
+			Category:   "best-practice",
+			Severity:   "medium",
+			Confidence: "medium",
+			Rule:       "unguarded-index",
+		})
+		return true
+	})
+
+	return issues
+}
+
+// declaredMap reports whether an expression is a variable declared as a map, with a type or
+// assigned a map literal or make(map...)
+func declaredMap(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return false
+	}
+	var typeExpr ast.Expr
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		typeExpr = decl.Type
+	case *ast.ValueSpec:
+		typeExpr = decl.Type
+	case *ast.AssignStmt:
+		for i, lhs := range decl.Lhs {
+			if name, ok := lhs.(*ast.Ident); ok && name.Obj == ident.Obj && len(decl.Lhs) == len(decl.Rhs) {
+				switch value := ast.Unparen(decl.Rhs[i]).(type) {
+				case *ast.CompositeLit:
+					typeExpr = value.Type
+				case *ast.CallExpr:
+					if isIdentNamed(value.Fun, "make") && len(value.Args) > 0 {
+						typeExpr = value.Args[0]
+					}
+				}
+			}
+		}
+	}
+	_, isMap := typeExpr.(*ast.MapType)
+	return isMap
+}
+
+// isLastIndex reports whether an index expression is len(s)-1 for the expression s
+func isLastIndex(expr ast.Expr, target string) bool {
+	binary, ok := ast.Unparen(expr).(*ast.BinaryExpr)
+	if !ok || binary.Op != token.SUB {
+		return false
+	}
+	one, ok := binary.Y.(*ast.BasicLit)
+	if !ok || one.Kind != token.INT || one.Value != "1" {
+		return false
+	}
+	return lengthOf(binary.X) == target
+}
+
+// lengthOf returns s for an expression len(s), or an empty string for any other expression
+func lengthOf(expr ast.Expr) string {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || !isIdentNamed(call.Fun, "len") || len(call.Args) != 1 {
+		return ""
+	}
+	return types.ExprString(call.Args[0])
+}
+
+// nonEmpty reports whether the innermost node of a path from the body of a function is only
+// reached when target has elements
+func nonEmpty(path []ast.Node, target string) bool {
+	for i := len(path) - 2; i >= 0; i-- {
+		child := path[i+1]
+		switch parent := path[i].(type) {
+		case *ast.IfStmt:
+			if (child == parent.Body && hasLength(parent.Cond, target, true)) || (child == parent.Else && hasLength(parent.Cond, target, false)) {
+				return true
+			}
+		case *ast.ForStmt:
+			if child == parent.Body && parent.Cond != nil && hasLength(parent.Cond, target, true) {
+				return true
+			}
+		case *ast.RangeStmt:
+			if child == parent.Body && types.ExprString(parent.X) == target {
+				return true
+			}
+		case *ast.BinaryExpr:
+			if child == parent.Y && ((parent.Op == token.LAND && hasLength(parent.X, target, true)) || (parent.Op == token.LOR && hasLength(parent.X, target, false))) {
+				return true
+			}
+		case *ast.BlockStmt:
+			if filled, ok := filledBefore(parent.List, child, target); ok {
+				return filled
+			}
+		case *ast.CaseClause:
+			if filled, ok := filledBefore(parent.Body, child, target); ok {
+				return filled
+			}
+		case *ast.CommClause:
+			if filled, ok := filledBefore(parent.Body, child, target); ok {
+				return filled
+			}
+		}
+	}
+	return false
+}
+
+// filledBefore looks at the statements of a list before child, latest first, for one that
+// ensures target has elements: an if statement leaving when it is empty, or an assignment.
+// It reports whether target has elements, and whether a statement decided it.
+func filledBefore(stmts []ast.Stmt, child ast.Node, target string) (filled bool, ok bool) {
+	for j := len(stmts) - 1; j >= 0; j-- {
+		stmt := stmts[j]
+		if stmt.End() > child.Pos() {
+			continue
+		}
+		if ifStmt, ok := stmt.(*ast.IfStmt); ok && ifStmt.Else == nil && terminates(ifStmt.Body) && hasLength(ifStmt.Cond, target, false) {
+			return true, true
+		}
+		if filled, ok := assignedSlice(stmt, target); ok {
+			return filled, true
+		}
+	}
+	return false, false
+}
+
+// hasLength reports whether a condition, when it has the given outcome, ensures that target
+// has elements, from comparisons of len(target) with an integer literal
+func hasLength(cond ast.Expr, target string, outcome bool) bool {
+	switch c := ast.Unparen(cond).(type) {
+	case *ast.UnaryExpr:
+		return c.Op == token.NOT && hasLength(c.X, target, !outcome)
+	case *ast.BinaryExpr:
+		switch {
+		case c.Op == token.LAND && outcome, c.Op == token.LOR && !outcome:
+			return hasLength(c.X, target, outcome) || hasLength(c.Y, target, outcome)
+		}
+
+		// Bring the comparison to the form len(target) op n, true
+		op, length, bound := c.Op, c.X, c.Y
+		if lengthOf(length) != target {
+			length, bound = bound, length
+			switch op {
+			case token.LSS:
+				op = token.GTR
+			case token.GTR:
+				op = token.LSS
+			case token.LEQ:
+				op = token.GEQ
+			case token.GEQ:
+				op = token.LEQ
+			}
+		}
+		lit, ok := bound.(*ast.BasicLit)
+		if lengthOf(length) != target || !ok || lit.Kind != token.INT {
+			return false
+		}
+		n, err := strconv.Atoi(lit.Value)
+		if err != nil {
+			return false
+		}
+		if !outcome {
+			switch op {
+			case token.EQL:
+				op = token.NEQ
+			case token.NEQ:
+				op = token.EQL
+			case token.LSS:
+				op = token.GEQ
+			case token.GEQ:
+				op = token.LSS
+			case token.GTR:
+				op = token.LEQ
+			case token.LEQ:
+				op = token.GTR
+			}
+		}
+		switch op {
+		case token.GTR:
+			return n >= 0
+		case token.GEQ, token.EQL:
+			return n >= 1
+		case token.NEQ:
+			return n == 0
+		}
+	}
+	return false
+}
+
+// assignedSlice reports whether a statement assigns target, and if so, whether the value
+// assigned has elements: the result of append, strings.Split, or bytes.Split, or a literal
+// with elements
+func assignedSlice(stmt ast.Stmt, target string) (filled bool, ok bool) {
+	assign, isAssign := stmt.(*ast.AssignStmt)
+	if !isAssign {
+		return false, false
+	}
+	for i, lhs := range assign.Lhs {
+		if types.ExprString(lhs) != target {
+			continue
+		}
+		if len(assign.Lhs) != len(assign.Rhs) {
+			return false, true
+		}
+		switch value := ast.Unparen(assign.Rhs[i]).(type) {
+		case *ast.CompositeLit:
+			return len(value.Elts) > 0, true
+		case *ast.CallExpr:
+			if appendCall(value) != nil && len(value.Args) > 1 {
+				return true, true
+			}
+			if sel, ok := value.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Split" && (isIdentNamed(sel.X, "strings") || isIdentNamed(sel.X, "bytes")) {
+				return true, true
+			}
+		}
+		return false, true
+	}
+	return false, false
+}
+
+// detectUnnecessaryElse detects else blocks following an if block that ends in a return, a
+// branch, or a call that does not return, whose statements can follow the if statement
+// instead. Like golint, only a plain else block is reported: else if chains are left alone,
+// as are if statements whose else uses a variable declared by the if.
+func detectUnnecessaryElse(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Issue {
+	file, ok := node.(*ast.File)
+	if !ok {
+		return nil
+	}
+
+	chained := make(map[*ast.IfStmt]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ifStmt, ok := n.(*ast.IfStmt); ok {
+			if elseIf, ok := ifStmt.Else.(*ast.IfStmt); ok {
+				chained[elseIf] = true
+			}
+		}
+		return true
+	})
+
+	var issues []*models.Issue
+	ast.Inspect(file, func(n ast.Node) bool {
+		ifStmt, ok := n.(*ast.IfStmt)
+		if !ok || chained[ifStmt] || len(ifStmt.Body.List) == 0 {
+			return true
+		}
+		if _, ok := ifStmt.Else.(*ast.BlockStmt); !ok {
+			return true
+		}
+		exit := leavingStmt(ifStmt.Body.List[len(ifStmt.Body.List)-1])
+		if exit == "" || usesInitVars(ifStmt) {
+			return true
+		}
+
+		pos := fset.Position(ifStmt.Body.Rbrace)
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    fmt.Sprintf("The if block ends with %s, so the else is unnecessary", exit),
+			Category:   "code-smell",
+			Severity:   "low",
+			Confidence: "high",
+			Suggestion: "Drop the else and move the statements of its block after the if statement",
+			Rule:       "unnecessary-else",
+		})
+		return true
+	})
+
+	return issues
+}
+
+// leavingStmt describes a statement after which control does not continue with the next
+// statement, such as "a return" or "a call to panic", or returns an empty string
+func leavingStmt(stmt ast.Stmt) string {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		return "a return"
+	case *ast.BranchStmt:
+		if s.Tok != token.FALLTHROUGH {
+			return "a " + s.Tok.String()
+		}
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return ""
+		}
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			if fun.Name == "panic" {
+				return "a call to panic"
+			}
+		case *ast.SelectorExpr:
+			if leavingCalls[fun.Sel.Name] {
+				return "a call to " + types.ExprString(fun)
+			}
+		}
+	}
+	return ""
+}
+
+// usesInitVars reports whether the else of an if statement uses a variable declared by its
+// init statement, which goes out of scope when the else is dropped
+func usesInitVars(ifStmt *ast.IfStmt) bool {
+	assign, ok := ifStmt.Init.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE {
+		return false
+	}
+	declared := make(map[*ast.Object]bool)
+	for _, lhs := range assign.Lhs {
+		if ident, ok := lhs.(*ast.Ident); ok && ident.Obj != nil {
+			declared[ident.Obj] = true
+		}
+	}
+
+	found := false
+	ast.Inspect(ifStmt.Else, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && declared[ident.Obj] {
+			found = true
+		}
+		return !found
+	})
+	return found
 }
@@ -0,0 +1,277 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// cacheFileName is the name of the analysis cache file inside the cache directory
+const cacheFileName = "analysis-cache.json"
+
+// cacheEntry holds the analysis results of a single file
+type cacheEntry struct {
+	Size      int64              `json:"size"`
+	ModTime   time.Time          `json:"mod_time"`
+	Hash      string             `json:"hash"` // SHA-256 of the file contents
+	Issues    []*models.Issue    `json:"issues"`
+	Functions []*models.Function `json:"functions"`
+	// Signatures of the directories whose files the results also depend on, such as the
+	// other files of a type-checked package, keyed by path; see dirSignature
+	Deps map[string]string `json:"deps,omitempty"`
+}
+
+// cacheData is the on-disk representation of the cache
+type cacheData struct {
+	Key     string                 `json:"key"`
+	Entries map[string]*cacheEntry `json:"entries"` // Keyed by relative file path
+}
+
+// Cache stores per-file analysis results on disk so unchanged files are not analyzed again.
+// Entries are only reused while the build of the tool and the rule set stay the same, and
+// the directories the results depend on are unchanged.
+type Cache struct {
+	path  string
+	data  cacheData
+	dirty bool
+	mutex sync.Mutex
+}
+
+// NewCache loads the cache stored in a directory. key identifies the tool version and
+// rule set the cached results were produced with; a cache written with a different key
+// starts out empty.
+func NewCache(dir, key string) (*Cache, error) {
+	c := &Cache{
+		path: filepath.Join(dir, cacheFileName),
+		data: cacheData{
+			Key:     key,
+			Entries: make(map[string]*cacheEntry),
+		},
+	}
+
+	content, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	var data cacheData
+	if err := json.Unmarshal(content, &data); err != nil {
+		return c, fmt.Errorf("failed to parse cache: %w", err)
+	}
+	if data.Key == key && data.Entries != nil {
+		c.data = data
+	} else {
+		// Results of another version or rule set must not be reused
+		c.dirty = true
+	}
+
+	return c, nil
+}
+
+// Get returns the cached results of a file if neither its contents nor the directories
+// its results depend on have changed
+func (c *Cache) Get(file *models.File) ([]*models.Issue, []*models.Function, bool) {
+	c.mutex.Lock()
+	entry, ok := c.data.Entries[file.RelPath]
+	c.mutex.Unlock()
+	if !ok {
+		return nil, nil, false
+	}
+	for dir, signature := range entry.Deps {
+		if dirSignature(dir) != signature {
+			return nil, nil, false
+		}
+	}
+
+	// Fast path: size and modification time are unchanged
+	if entry.Size == file.Size && entry.ModTime.Equal(file.ModTime) {
+		return entry.Issues, entry.Functions, true
+	}
+
+	// The file was touched, but its contents may still be the same
+	hash, err := hashFile(file.Path)
+	if err != nil || hash != entry.Hash {
+		return nil, nil, false
+	}
+
+	c.mutex.Lock()
+	entry.Size = file.Size
+	entry.ModTime = file.ModTime
+	c.dirty = true
+	c.mutex.Unlock()
+
+	return entry.Issues, entry.Functions, true
+}
+
+// Put stores the results of a file, which also depend on the files of the directories deps
+func (c *Cache) Put(file *models.File, deps []string, issues []*models.Issue, functions []*models.Function) error {
+	hash, err := hashFile(file.Path)
+	if err != nil {
+		return err
+	}
+	var signatures map[string]string
+	if len(deps) > 0 {
+		signatures = make(map[string]string, len(deps))
+		for _, dir := range deps {
+			signatures[dir] = dirSignature(dir)
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.data.Entries[file.RelPath] = &cacheEntry{
+		Size:      file.Size,
+		ModTime:   file.ModTime,
+		Hash:      hash,
+		Issues:    issues,
+		Functions: functions,
+		Deps:      signatures,
+	}
+	c.dirty = true
+
+	return nil
+}
+
+// Save writes the cache to disk if it has changed
+func (c *Cache) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	content, err := json.Marshal(c.data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted run cannot corrupt the cache
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// hashFile computes the SHA-256 of a file's contents
+func hashFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// dirSignature identifies the state of the Go files of a directory and of its go.mod and
+// go.sum files by their names, sizes, and modification times, like the fast path of Get.
+// It is empty when the directory cannot be read.
+func dirSignature(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.New()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (filepath.Ext(name) != ".go" && name != "go.mod" && name != "go.sum") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return ""
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\n", name, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// executableHash identifies the build of the running executable. Builds of the same version
+// may differ in their rules, so results cached by one are not reused by another. It is
+// empty when the executable cannot be read.
+var executableHash = sync.OnceValue(func() string {
+	path, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return ""
+	}
+	return hash
+})
+
+// cacheKey identifies the build of the tool and the configuration that affects per-file results
+func (a *Analyzer) cacheKey() string {
+	var rules []string
+	for _, p := range a.patterns {
+		rules = append(rules, p.Name)
+	}
+	for _, ap := range a.antiPatterns {
+		rules = append(rules, ap.Name)
+	}
+	for _, bp := range a.bestPractices {
+		rules = append(rules, bp.Name)
+	}
+	for _, sr := range a.securityRules {
+		rules = append(rules, sr.ID)
+	}
+	for _, rule := range a.customRules {
+		rules = append(rules, "custom:"+rule.ID())
+	}
+	if a.config.CategoryEnabled("code-smell") && a.config.RuleEnabled("cyclomatic-complexity") {
+		rules = append(rules, fmt.Sprintf("cyclomatic-complexity:%d", a.config.MaxComplexity))
+	}
+	rules = append(rules, fmt.Sprintf("limits:%d:%d:%d:%d:%d", a.config.MaxParams, a.config.MaxFunctionLines, a.config.MaxInterfaceMethods, a.config.MaxInitLines, a.config.MaxMapKeys))
+	rules = append(rules, fmt.Sprintf("secret-entropy:%g", a.config.SecretMinEntropy))
+	for rule, severity := range a.config.RuleSeverities {
+		rules = append(rules, rule+"="+severity)
+	}
+	for rule := range a.relaxed {
+		rules = append(rules, "relax:"+rule)
+	}
+	for path, reason := range a.config.BannedImports {
+		rules = append(rules, "banned:"+path+"="+reason)
+	}
+	for _, pattern := range a.config.SecretAllowlist {
+		rules = append(rules, "secret-allow:"+pattern)
+	}
+	for _, sentinel := range a.config.SentinelErrorAllowlist {
+		rules = append(rules, "sentinel:"+sentinel)
+	}
+	for _, dir := range a.config.TestDirs {
+		rules = append(rules, "test-dir:"+dir)
+	}
+	sort.Strings(rules)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n", Version, executableHash())
+	for _, rule := range rules {
+		fmt.Fprintf(hash, "%s\n", rule)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// analyzeFunctions computes metrics for every function declared in a file and
+// reports functions whose cyclomatic complexity exceeds the configured threshold
+func (a *Analyzer) analyzeFunctions(astFile *ast.File, file *models.File) ([]*models.Function, []*models.Issue) {
+	var functions []*models.Function
+	var issues []*models.Issue
+
+	for _, decl := range astFile.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil {
+			continue
+		}
+
+		function := &models.Function{
+			Name:       functionName(funcDecl),
+			File:       file.RelPath,
+			StartLine:  a.fset.Position(funcDecl.Pos()).Line,
+			EndLine:    a.fset.Position(funcDecl.End()).Line,
+			Complexity: cyclomaticComplexity(funcDecl),
+			Parameters: funcDecl.Type.Params.NumFields(),
+			Returns:    funcDecl.Type.Results.NumFields(),
+		}
+		functions = append(functions, function)
+
+		if function.Complexity > a.config.MaxComplexity && a.config.CategoryEnabled("code-smell") && a.config.RuleEnabled("cyclomatic-complexity") {
+			pos := a.fset.Position(funcDecl.Pos())
+			issues = append(issues, &models.Issue{
+				File:       file.RelPath,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Message:    fmt.Sprintf("Function '%s' has a cyclomatic complexity of %d (lines %d-%d)", function.Name, function.Complexity, function.StartLine, function.EndLine),
+				Category:   "code-smell",
+				Severity:   "medium",
+				Confidence: "high",
+				Suggestion: fmt.Sprintf("Reduce the number of branches below %d by extracting helper functions or simplifying conditions", a.config.MaxComplexity+1),
+				Rule:       "cyclomatic-complexity",
+			})
+		}
+	}
+
+	return functions, issues
+}
+
+// cyclomaticComplexity computes the cyclomatic complexity of a function: one for
+// the function entry plus one for each branch point
+func cyclomaticComplexity(funcDecl *ast.FuncDecl) int {
+	complexity := 1
+
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			// The default clause is not a branch of its own
+			if n.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+
+	return complexity
+}
+
+// functionName returns the display name of a function, qualified by its receiver type for methods
+func functionName(funcDecl *ast.FuncDecl) string {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return funcDecl.Name.Name
+	}
+
+	recvType := funcDecl.Recv.List[0].Type
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = star.X
+	}
+	// Strip type parameters from generic receivers
+	switch t := recvType.(type) {
+	case *ast.IndexExpr:
+		recvType = t.X
+	case *ast.IndexListExpr:
+		recvType = t.X
+	}
+
+	if ident, ok := recvType.(*ast.Ident); ok {
+		return ident.Name + "." + funcDecl.Name.Name
+	}
+	return funcDecl.Name.Name
+}
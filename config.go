@@ -1,39 +1,139 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
+// DefaultConfigFiles are the configuration files looked up in the repository root, in
+// order, when no configuration file is given
+var DefaultConfigFiles = []string{".review.yaml", ".review.toml", ".review.json"}
+
 // Config represents the application configuration
 type Config struct {
 	// General settings
-	Verbose bool `json:"verbose"`
+	Verbose bool `json:"verbose" yaml:"verbose" toml:"verbose"`
 	
 	// Analysis settings
-	IncludeTests      bool     `json:"include_tests"`
-	ExcludeDirs       []string `json:"exclude_dirs"`
-	ExcludeFiles      []string `json:"exclude_files"`
-	MaxFileSize       int64    `json:"max_file_size"`
+	IncludeTests      bool     `json:"include_tests" yaml:"include_tests" toml:"include_tests"`
+	ExcludeDirs       []string `json:"exclude_dirs" yaml:"exclude_dirs" toml:"exclude_dirs"`
+	ExcludeFiles      []string `json:"exclude_files" yaml:"exclude_files" toml:"exclude_files"`
+	MaxFileSize       int64    `json:"max_file_size" yaml:"max_file_size" toml:"max_file_size"`
+	SkipGenerated     bool     `json:"skip_generated" yaml:"skip_generated" toml:"skip_generated"`
+	GeneratedPatterns []string `json:"generated_patterns" yaml:"generated_patterns" toml:"generated_patterns"`
+	Concurrency       int      `json:"concurrency" yaml:"concurrency" toml:"concurrency"` // Maximum number of files analyzed in parallel
+	TestDirs          []string `json:"test_dirs" yaml:"test_dirs" toml:"test_dirs"` // Directories holding test code besides _test.go files
 	
 	// Analyzer settings
-	EnabledAnalyzers  []string `json:"enabled_analyzers"`
-	DisabledAnalyzers []string `json:"disabled_analyzers"`
+	EnabledAnalyzers  []string `json:"enabled_analyzers" yaml:"enabled_analyzers" toml:"enabled_analyzers"`
+	DisabledAnalyzers []string `json:"disabled_analyzers" yaml:"disabled_analyzers" toml:"disabled_analyzers"`
+	DisabledRules     []string `json:"disabled_rules" yaml:"disabled_rules" toml:"disabled_rules"`
+	RelaxInTests      []string `json:"relax_in_tests" yaml:"relax_in_tests" toml:"relax_in_tests"` // Rules that are not reported in test code
 	
 	// Security settings
-	SecuritySeverity  string   `json:"security_severity"`
+	SecuritySeverity  string   `json:"security_severity" yaml:"security_severity" toml:"security_severity"`
+	EnableGosec       bool     `json:"enable_gosec" yaml:"enable_gosec" toml:"enable_gosec"`
+	DedupeSecurity    bool     `json:"dedupe_security" yaml:"dedupe_security" toml:"dedupe_security"` // Report a problem found by both gosec and a custom security rule once
+	
+	// Shannon entropy in bits per character below which hardcoded secrets are reported with
+	// low confidence, as likely placeholders such as "changeme"; zero disables the check
+	SecretMinEntropy  float64  `json:"secret_min_entropy" yaml:"secret_min_entropy" toml:"secret_min_entropy"`
+	
+	// Regular expressions of values that are not reported as hardcoded secrets, such as
+	// placeholders of test fixtures
+	SecretAllowlist   []string `json:"secret_allowlist" yaml:"secret_allowlist" toml:"secret_allowlist"`
+
+	// Scan files that are not Go source, such as .env files and Dockerfiles, for hardcoded
+	// secrets, the files whose extension or name is in SecretScanExtensions
+	ScanNonGoSecrets     bool     `json:"scan_non_go_secrets" yaml:"scan_non_go_secrets" toml:"scan_non_go_secrets"`
+	SecretScanExtensions []string `json:"secret_scan_extensions" yaml:"secret_scan_extensions" toml:"secret_scan_extensions"`
+	
+	// Import paths banned by banned-import, including the packages below them, mapped to
+	// the reason given as suggestion
+	BannedImports     map[string]string `json:"banned_imports" yaml:"banned_imports" toml:"banned_imports"`
+	
+	// Sentinel errors, such as io.EOF, that error-comparison allows to compare with ==
+	SentinelErrorAllowlist []string `json:"sentinel_error_allowlist" yaml:"sentinel_error_allowlist" toml:"sentinel_error_allowlist"`
 	
 	// Pattern detection settings
-	PatternSeverity   string   `json:"pattern_severity"`
+	PatternSeverity   string   `json:"pattern_severity" yaml:"pattern_severity" toml:"pattern_severity"`
+	MaxComplexity     int      `json:"max_complexity" yaml:"max_complexity" toml:"max_complexity"`
+	
+	// Size in bytes above which structs should not be passed by value, zero disables OPT009
+	MaxStructSize     int      `json:"max_struct_size" yaml:"max_struct_size" toml:"max_struct_size"`
+	
+	// Limits above which the size rules report an issue, zero disables the rule
+	MaxParams           int    `json:"max_params" yaml:"max_params" toml:"max_params"`
+	MaxFunctionLines    int    `json:"max_function_lines" yaml:"max_function_lines" toml:"max_function_lines"`
+	MaxInterfaceMethods int    `json:"max_interface_methods" yaml:"max_interface_methods" toml:"max_interface_methods"`
+	MaxInitLines        int    `json:"max_init_lines" yaml:"max_init_lines" toml:"max_init_lines"`
+	MaxMapKeys          int    `json:"max_map_keys" yaml:"max_map_keys" toml:"max_map_keys"`
+	
+	// Number of changed lines above which PR summaries list a file as a large change
+	LargeChangeLines  int      `json:"large_change_lines" yaml:"large_change_lines" toml:"large_change_lines"`
 	
 	// Machine learning settings
-	EnableLearning    bool     `json:"enable_learning"`
-	ModelPath         string   `json:"model_path"`
+	EnableLearning    bool     `json:"enable_learning" yaml:"enable_learning" toml:"enable_learning"`
+	ModelPath         string   `json:"model_path" yaml:"model_path" toml:"model_path"`
+	StorageBackend    string   `json:"storage_backend" yaml:"storage_backend" toml:"storage_backend"` // Learning data storage: "json" or "sqlite"
+	
+	// Directory of the analysis cache, disabled when empty
+	CachePath         string   `json:"cache_path" yaml:"cache_path" toml:"cache_path"`
 	
 	// Custom rules
-	CustomRulesPath   string   `json:"custom_rules_path"`
+	CustomRulesPath   string   `json:"custom_rules_path" yaml:"custom_rules_path" toml:"custom_rules_path"`
+	
+	// Per-rule severity overrides keyed by rule ID
+	RuleSeverities    map[string]string `json:"rule_severities" yaml:"rule_severities" toml:"rule_severities"`
+	
+	// Issue filters applied to the results, an empty list keeps all issues
+	OnlyCategories    []string `json:"only_categories" yaml:"only_categories" toml:"only_categories"`
+	OnlySeverities    []string `json:"only_severities" yaml:"only_severities" toml:"only_severities"`
+	OnlyRules         []string `json:"only_rules" yaml:"only_rules" toml:"only_rules"`
+	
+	// Minimum confidence of the reported issues: "high", "medium", or "low"
+	MinConfidence     string   `json:"min_confidence" yaml:"min_confidence" toml:"min_confidence"`
+	
+	// Maximum size in bytes of the markdown report, unlimited when zero
+	MarkdownMaxSize   int      `json:"markdown_max_size" yaml:"markdown_max_size" toml:"markdown_max_size"`
+	
+	// Token that clients of the HTTP server must send, REVIEW_SERVER_TOKEN when empty
+	ServerToken       string   `json:"server_token" yaml:"server_token" toml:"server_token"`
+	
+	// Problems in the configuration file that did not prevent loading it
+	Warnings          []string `json:"-" yaml:"-" toml:"-"`
+	
+	// Called by the analysis with the number of files analyzed and found so far after each
+	// file, from several goroutines at once
+	OnProgress        func(done, found int) `json:"-" yaml:"-" toml:"-"`
+}
+
+// Categories lists the categories of the built-in analyzers
+var Categories = []string{"code-smell", "anti-pattern", "best-practice", "documentation", "performance", "security"}
+
+// validSeverities lists the severity levels accepted in configuration
+var validSeverities = map[string]bool{
+	"critical": true,
+	"high":     true,
+	"medium":   true,
+	"low":      true,
+}
+
+// validConfidences lists the confidence levels accepted in configuration
+var validConfidences = map[string]bool{
+	"high":   true,
+	"medium": true,
+	"low":    true,
 }
 
 // DefaultConfig returns the default configuration
@@ -44,17 +144,48 @@ func DefaultConfig() *Config {
 		ExcludeDirs:       []string{".git", "vendor", "node_modules"},
 		ExcludeFiles:      []string{},
 		MaxFileSize:       1024 * 1024, // 1MB
+		SkipGenerated:     true,
+		GeneratedPatterns: []string{"*.pb.go", "*_gen.go", "*_generated.go"},
+		Concurrency:       runtime.GOMAXPROCS(0),
+		TestDirs:          []string{},
 		EnabledAnalyzers:  []string{"all"},
 		DisabledAnalyzers: []string{},
+		DisabledRules:     []string{},
+		RelaxInTests:      []string{},
 		SecuritySeverity:  "high",
+		EnableGosec:       true,
+		DedupeSecurity:    true,
+		SecretMinEntropy:  3.5,
+		SecretAllowlist:   []string{},
+		ScanNonGoSecrets:  false,
+		SecretScanExtensions: []string{".env", ".yaml", ".yml", ".json", ".toml", ".properties", "Dockerfile"},
+		BannedImports:     map[string]string{},
+		SentinelErrorAllowlist: []string{"io.EOF"},
 		PatternSeverity:   "medium",
+		MaxComplexity:     10,
+		MaxStructSize:     64,
+		MaxParams:         5,
+		MaxFunctionLines:  50,
+		MaxInterfaceMethods: 5,
+		MaxInitLines:      10,
+		MaxMapKeys:        3,
+		LargeChangeLines:  50,
 		EnableLearning:    true,
 		ModelPath:         "",
+		StorageBackend:    "json",
+		CachePath:         "",
 		CustomRulesPath:   "",
+		RuleSeverities:    map[string]string{},
+		MinConfidence:     "low",
+		MarkdownMaxSize:   65000, // GitHub comments are limited to 65536 characters
+		ServerToken:       "",
 	}
 }
 
-// LoadConfig loads configuration from a file
+// LoadConfig loads configuration from a file. Files with a .yaml or .yml extension are
+// parsed as YAML, files with a .toml extension as TOML, any other file as JSON, in which
+// lines starting with // are comments. Unknown keys in TOML files are reported in
+// Config.Warnings.
 func LoadConfig(configPath string) (*Config, error) {
 	config := DefaultConfig()
 	
@@ -75,20 +206,257 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 	
-	// Parse JSON
-	if err := json.Unmarshal(data, config); err != nil {
+	// Parse YAML, TOML, or JSON depending on the extension
+	switch strings.ToLower(filepath.Ext(absPath)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, config)
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), config)
+		for _, key := range meta.Undecoded() {
+			config.Warnings = append(config.Warnings, fmt.Sprintf("unknown key %q in %s", key.String(), configPath))
+		}
+	default:
+		err = json.Unmarshal(stripJSONComments(data), config)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 	
+	// Validate settings
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+	
 	return config, nil
 }
 
-// SaveConfig saves configuration to a file
+// stripJSONComments blanks the lines of a JSON file that start with //, keeping line
+// numbers in parse errors. Strings cannot span lines, so such lines are always comments.
+func stripJSONComments(data []byte) []byte {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("//")) {
+			lines[i] = nil
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// FindConfig returns the path of the first of DefaultConfigFiles that exists in the
+// repository root, or an empty string if there is none
+func FindConfig(repoPath string) string {
+	for _, name := range DefaultConfigFiles {
+		path := filepath.Join(repoPath, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	
+	return ""
+}
+
+// CategoryEnabled reports whether detectors of the given category (e.g. "security", "code-smell") should run
+func (c *Config) CategoryEnabled(category string) bool {
+	for _, disabled := range c.DisabledAnalyzers {
+		if disabled == category {
+			return false
+		}
+	}
+	
+	// An empty list behaves like "all"
+	if len(c.EnabledAnalyzers) == 0 {
+		return true
+	}
+	for _, enabled := range c.EnabledAnalyzers {
+		if enabled == "all" || enabled == category {
+			return true
+		}
+	}
+	
+	return false
+}
+
+// EnabledCategories returns the built-in analyzer categories that should run
+func (c *Config) EnabledCategories() []string {
+	var enabled []string
+	for _, category := range Categories {
+		if c.CategoryEnabled(category) {
+			enabled = append(enabled, category)
+		}
+	}
+	
+	return enabled
+}
+
+// RuleEnabled reports whether a rule, identified by any of its IDs or names, should run
+func (c *Config) RuleEnabled(ids ...string) bool {
+	for _, disabled := range c.DisabledRules {
+		for _, id := range ids {
+			if disabled == id {
+				return false
+			}
+		}
+	}
+	
+	return true
+}
+
+// RuleRelaxedInTests reports whether a rule, identified by any of its IDs or names, is not
+// reported in test code
+func (c *Config) RuleRelaxedInTests(ids ...string) bool {
+	for _, relaxed := range c.RelaxInTests {
+		for _, id := range ids {
+			if relaxed == id {
+				return true
+			}
+		}
+	}
+	
+	return false
+}
+
+// TestFile reports whether a file, given by its path relative to the repository root,
+// holds test code: a _test.go file or a file below one of the TestDirs. Directories
+// containing a / are matched against the path from the repository root, others by name
+// at any depth.
+func (c *Config) TestFile(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	if strings.HasSuffix(relPath, "_test.go") {
+		return true
+	}
+	
+	dirs := strings.Split(path.Dir(relPath), "/")
+	for _, testDir := range c.TestDirs {
+		testDir = strings.Trim(filepath.ToSlash(testDir), "/")
+		if strings.Contains(testDir, "/") {
+			if strings.HasPrefix(relPath, testDir+"/") {
+				return true
+			}
+			continue
+		}
+		for _, dir := range dirs {
+			if dir == testDir {
+				return true
+			}
+		}
+	}
+	
+	return false
+}
+
+// SecretScanFile reports whether a file that is not Go source is scanned for secrets when
+// ScanNonGoSecrets is set: its name ends with an extension of SecretScanExtensions, or is a
+// name of SecretScanExtensions, possibly followed by an extension as in Dockerfile.prod
+// or .env.local
+func (c *Config) SecretScanFile(name string) bool {
+	for _, entry := range c.SecretScanExtensions {
+		if name == entry || strings.HasPrefix(name, entry+".") {
+			return true
+		}
+		if strings.HasPrefix(entry, ".") && strings.HasSuffix(name, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueSelected reports whether an issue with the given category, severity, and rule
+// passes the OnlyCategories, OnlySeverities, and OnlyRules filters
+func (c *Config) IssueSelected(category, severity, rule string) bool {
+	return selected(c.OnlyCategories, category) && selected(c.OnlySeverities, severity) && selected(c.OnlyRules, rule)
+}
+
+// selected reports whether a value is in a filter list, where an empty list selects everything
+func selected(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, v := range filter {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the configuration values are valid
+func (c *Config) Validate() error {
+	switch c.StorageBackend {
+	case "", "json", "sqlite":
+	default:
+		return fmt.Errorf("invalid storage backend %q (must be json or sqlite)", c.StorageBackend)
+	}
+	
+	if c.Concurrency < 0 {
+		return fmt.Errorf("invalid concurrency %d (must not be negative)", c.Concurrency)
+	}
+	
+	for _, severity := range c.OnlySeverities {
+		if !validSeverities[severity] {
+			return fmt.Errorf("invalid severity filter %q (must be critical, high, medium, or low)", severity)
+		}
+	}
+	
+	if c.SecretMinEntropy < 0 {
+		return fmt.Errorf("invalid secret_min_entropy %g (must not be negative)", c.SecretMinEntropy)
+	}
+	
+	for _, pattern := range c.SecretAllowlist {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid secret_allowlist pattern %q: %w", pattern, err)
+		}
+	}
+	
+	for _, name := range c.SecretScanExtensions {
+		if name == "" || name == "." || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("invalid secret_scan_extensions entry %q (must be an extension such as .env or a file name such as Dockerfile)", name)
+		}
+	}
+	
+	if c.MaxStructSize < 0 {
+		return fmt.Errorf("invalid maximum struct size %d (must not be negative)", c.MaxStructSize)
+	}
+	
+	limits := []struct {
+		name  string
+		value int
+	}{
+		{"max_params", c.MaxParams},
+		{"max_function_lines", c.MaxFunctionLines},
+		{"max_interface_methods", c.MaxInterfaceMethods},
+		{"max_init_lines", c.MaxInitLines},
+		{"max_map_keys", c.MaxMapKeys},
+		{"large_change_lines", c.LargeChangeLines},
+	}
+	for _, limit := range limits {
+		if limit.value < 0 {
+			return fmt.Errorf("invalid %s %d (must not be negative)", limit.name, limit.value)
+		}
+	}
+	
+	if c.MarkdownMaxSize < 0 {
+		return fmt.Errorf("invalid markdown report size %d (must not be negative)", c.MarkdownMaxSize)
+	}
+	
+	if c.MinConfidence != "" && !validConfidences[c.MinConfidence] {
+		return fmt.Errorf("invalid minimum confidence %q (must be high, medium, or low)", c.MinConfidence)
+	}
+	
+	for rule, severity := range c.RuleSeverities {
+		if !validSeverities[severity] {
+			return fmt.Errorf("invalid severity %q for rule %s (must be critical, high, medium, or low)", severity, rule)
+		}
+	}
+	
+	return nil
+}
+
+// SaveConfig saves configuration to a file, in the format LoadConfig reads for its extension
 func SaveConfig(config *Config, configPath string) error {
-	// Marshal to JSON with indentation
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := marshalConfig(config, configPath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return err
 	}
 	
 	// Write to file
@@ -98,3 +466,25 @@ func SaveConfig(config *Config, configPath string) error {
 	
 	return nil
 }
+
+// marshalConfig encodes a configuration as YAML, TOML, or indented JSON depending on the
+// extension of configPath
+func marshalConfig(config *Config, configPath string) ([]byte, error) {
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(config)
+	case ".toml":
+		var buf bytes.Buffer
+		err = toml.NewEncoder(&buf).Encode(config)
+		data = buf.Bytes()
+	default:
+		data, err = json.MarshalIndent(config, "", "  ")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	
+	return data, nil
+}
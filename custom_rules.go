@@ -3,9 +3,13 @@ package security
 import (
 	"go/ast"
 	"go/token"
+	"math"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/user/code-review-assistant/internal/config"
 	"github.com/user/code-review-assistant/internal/models"
 )
 
@@ -15,11 +19,13 @@ type CustomSecurityRule struct {
 	Name        string
 	Description string
 	Severity    string
-	Detector    func(fset *token.FileSet, node ast.Node) *models.Issue
+	Suggestion  string // Suggested fix for the issues found
+	Detector    func(fset *token.FileSet, node ast.Node) []*models.Issue
 }
 
-// GetCustomSecurityRules returns a list of custom security rules
-func GetCustomSecurityRules() []*CustomSecurityRule {
+// GetCustomSecurityRules returns a list of custom security rules, with the settings of the
+// configuration
+func GetCustomSecurityRules(cfg *config.Config) []*CustomSecurityRule {
 	return []*CustomSecurityRule{
 		// Hardcoded secrets in string literals
 		{
@@ -27,7 +33,8 @@ func GetCustomSecurityRules() []*CustomSecurityRule {
 			Name:        "hardcoded-secret",
 			Description: "Hardcoded secret or credential",
 			Severity:    "critical",
-			Detector:    detectHardcodedSecrets,
+			Suggestion:  "Store secrets in environment variables or a secure vault, not in source code",
+			Detector:    hardcodedSecrets(cfg.SecretMinEntropy, compileAllowlist(cfg.SecretAllowlist)),
 		},
 		// Insecure random number generation
 		{
@@ -35,6 +42,7 @@ func GetCustomSecurityRules() []*CustomSecurityRule {
 			Name:        "insecure-random",
 			Description: "Insecure random number generation",
 			Severity:    "high",
+			Suggestion:  "Use crypto/rand for security-sensitive operations",
 			Detector:    detectInsecureRandom,
 		},
 		// Missing content type in HTTP responses
@@ -43,6 +51,7 @@ func GetCustomSecurityRules() []*CustomSecurityRule {
 			Name:        "missing-content-type",
 			Description: "Missing Content-Type header in HTTP response",
 			Severity:    "medium",
+			Suggestion:  "Set Content-Type header before writing to the response",
 			Detector:    detectMissingContentType,
 		},
 		// Insecure cookie settings
@@ -51,6 +60,7 @@ func GetCustomSecurityRules() []*CustomSecurityRule {
 			Name:        "insecure-cookie",
 			Description: "Insecure cookie settings",
 			Severity:    "high",
+			Suggestion:  "Set both Secure and HttpOnly flags to true for cookies",
 			Detector:    detectInsecureCookie,
 		},
 		// Weak cryptographic key size
@@ -77,65 +87,166 @@ func GetCustomSecurityRules() []*CustomSecurityRule {
 			Severity:    "medium",
 			Detector:    detectSensitiveLogging,
 		},
+		// SQL queries built from variable input
+		{
+			ID:          "CS008",
+			Name:        "sql-injection",
+			Description: "SQL query built by string concatenation or formatting",
+			Severity:    "critical",
+			Suggestion:  "Use a parameterized query with placeholders such as ? or $1 and pass the values as arguments",
+			Detector:    detectSQLInjection,
+		},
+		// Secrets encoded with the rest of a struct
+		{
+			ID:          "CS009",
+			Name:        "serialized-secret",
+			Description: "Sensitive struct field included in JSON, XML, or YAML encoding",
+			Severity:    "medium",
+			Suggestion:  "Tag the field with `json:\"-\"` to leave it out of the encoding, or return a separate type without it",
+			Detector:    detectSerializedSecret,
+		},
 	}
 }
 
-// detectHardcodedSecrets detects hardcoded secrets in string literals
-func detectHardcodedSecrets(fset *token.FileSet, node ast.Node) *models.Issue {
-	basicLit, ok := node.(*ast.BasicLit)
-	if !ok || basicLit.Kind != token.STRING {
-		return nil
+// secretKeys match the names that secrets are assigned to
+var secretKeys = []string{
+	`password`,
+	`passwd`,
+	`pwd`,
+	`secret`,
+	`api[_-]?key`,
+	`access[_-]?token`,
+	`auth[_-]?token`,
+	`credentials`,
+}
+
+// secretPatterns match assignments of secrets in string literals, capturing the value
+var secretPatterns = keyPatterns(`\s*=\s*['"](.+?)['"]`)
+
+// keyPatterns returns a case-insensitive pattern for each secret key followed by an
+// assignment expression
+func keyPatterns(assignment string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(secretKeys))
+	for _, key := range secretKeys {
+		patterns = append(patterns, regexp.MustCompile(`(?i)`+key+assignment))
 	}
+	return patterns
+}
 
-	// Remove quotes from string literal
-	value := strings.Trim(basicLit.Value, `"'`)
+// hardcodedSecrets returns a detector of hardcoded secrets in string literals. Secrets
+// whose value matches the allowlist are left out. Secrets whose value has a Shannon entropy
+// below minEntropy bits per character, such as "changeme" or "password123", are reported
+// with low confidence as likely placeholders, and others with high confidence.
+func hardcodedSecrets(minEntropy float64, allowlist []*regexp.Regexp) func(fset *token.FileSet, node ast.Node) []*models.Issue {
+	return func(fset *token.FileSet, node ast.Node) []*models.Issue {
+		basicLit, ok := node.(*ast.BasicLit)
+		if !ok || basicLit.Kind != token.STRING {
+			return nil
+		}
 
-	// Skip short strings
-	if len(value) < 8 {
-		return nil
+		// Remove quotes from string literal, keeping quotes that end the value
+		value, err := strconv.Unquote(basicLit.Value)
+		if err != nil {
+			return nil
+		}
+
+		// Skip short strings
+		if len(value) < 8 {
+			return nil
+		}
+
+		// Report every secret in the literal, once even if several patterns match it
+		var issues []*models.Issue
+		reported := make(map[int]bool)
+		for _, pattern := range secretPatterns {
+			for _, match := range pattern.FindAllStringSubmatchIndex(value, -1) {
+				if reported[match[0]] {
+					continue
+				}
+				reported[match[0]] = true
+				secret := value[match[2]:match[3]]
+				if allowedSecret(allowlist, secret) {
+					continue
+				}
+
+				pos := fset.Position(basicLit.Pos())
+				issues = append(issues, secretIssue(pos.Filename, pos.Line, pos.Column, secret, minEntropy))
+			}
+		}
+
+		return issues
 	}
+}
 
-	// Patterns for potential secrets
-	patterns := []*regexp.Regexp{
-		regexp.MustCompile(`(?i)password\s*=\s*['"](.+?)['"]`),
-		regexp.MustCompile(`(?i)passwd\s*=\s*['"](.+?)['"]`),
-		regexp.MustCompile(`(?i)pwd\s*=\s*['"](.+?)['"]`),
-		regexp.MustCompile(`(?i)secret\s*=\s*['"](.+?)['"]`),
-		regexp.MustCompile(`(?i)api[_-]?key\s*=\s*['"](.+?)['"]`),
-		regexp.MustCompile(`(?i)access[_-]?token\s*=\s*['"](.+?)['"]`),
-		regexp.MustCompile(`(?i)auth[_-]?token\s*=\s*['"](.+?)['"]`),
-		regexp.MustCompile(`(?i)credentials\s*=\s*['"](.+?)['"]`),
+// secretIssue returns the CS001 issue of a secret found at a position, with low confidence
+// when its entropy is below minEntropy
+func secretIssue(file string, line, column int, secret string, minEntropy float64) *models.Issue {
+	message := "Hardcoded secret or credential detected"
+	confidence := "high"
+	if shannonEntropy(secret) < minEntropy {
+		message += ", though its value looks like a placeholder"
+		confidence = "low"
+	}
+	return &models.Issue{
+		File:       file,
+		Line:       line,
+		Column:     column,
+		Message:    message,
+		Category:   "security",
+		Severity:   "critical",
+		Confidence: confidence,
+		Rule:       "CS001",
 	}
+}
 
+// compileAllowlist compiles the secret_allowlist patterns of the configuration, which
+// Validate has checked
+func compileAllowlist(patterns []string) []*regexp.Regexp {
+	allowlist := make([]*regexp.Regexp, 0, len(patterns))
 	for _, pattern := range patterns {
-		if pattern.MatchString(value) {
-			pos := fset.Position(basicLit.Pos())
-			return &models.Issue{
-				File:       pos.Filename,
-				Line:       pos.Line,
-				Column:     pos.Column,
-				Message:    "Hardcoded secret or credential detected",
-				Category:   "security",
-				Severity:   "critical",
-				Confidence: "medium",
-				Suggestion: "Store secrets in environment variables or a secure vault, not in source code",
-				Rule:       "CS001",
-			}
+		if re, err := regexp.Compile(pattern); err == nil {
+			allowlist = append(allowlist, re)
 		}
 	}
+	return allowlist
+}
 
-	return nil
+// allowedSecret reports whether the value of a secret matches the allowlist
+func allowedSecret(allowlist []*regexp.Regexp, value string) bool {
+	for _, re := range allowlist {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// shannonEntropy returns the Shannon entropy of a string in bits per character, from about
+// 2.5 for words such as "changeme" to 4 and more for random tokens
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
 }
 
 // detectInsecureRandom detects insecure random number generation
-func detectInsecureRandom(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectInsecureRandom(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Look for imports of math/rand
 	importSpec, ok := node.(*ast.ImportSpec)
 	if ok {
 		path := strings.Trim(importSpec.Path.Value, `"`)
 		if path == "math/rand" {
 			pos := fset.Position(importSpec.Pos())
-			return &models.Issue{
+			return []*models.Issue{{
 				File:       pos.Filename,
 				Line:       pos.Line,
 				Column:     pos.Column,
@@ -143,9 +254,8 @@ func detectInsecureRandom(fset *token.FileSet, node ast.Node) *models.Issue {
 				Category:   "security",
 				Severity:   "high",
 				Confidence: "high",
-				Suggestion: "Use crypto/rand for security-sensitive operations",
 				Rule:       "CS002",
-			}
+			}}
 		}
 		return nil
 	}
@@ -166,7 +276,7 @@ func detectInsecureRandom(fset *token.FileSet, node ast.Node) *models.Issue {
 			selectorExpr.Sel.Name == "Intn" || 
 			selectorExpr.Sel.Name == "Float64") {
 			pos := fset.Position(callExpr.Pos())
-			return &models.Issue{
+			return []*models.Issue{{
 				File:       pos.Filename,
 				Line:       pos.Line,
 				Column:     pos.Column,
@@ -174,46 +284,127 @@ func detectInsecureRandom(fset *token.FileSet, node ast.Node) *models.Issue {
 				Category:   "security",
 				Severity:   "high",
 				Confidence: "medium",
-				Suggestion: "Use crypto/rand for security-sensitive operations",
 				Rule:       "CS002",
-			}
+			}}
 		}
 	}
 
 	return nil
 }
 
-// detectMissingContentType detects missing Content-Type header in HTTP responses
-func detectMissingContentType(fset *token.FileSet, node ast.Node) *models.Issue {
-	// Look for http.ResponseWriter.Write calls without setting Content-Type
-	callExpr, ok := node.(*ast.CallExpr)
-	if !ok {
+// detectMissingContentType detects writes to an http.ResponseWriter before its Content-Type
+// header is set. Without type information, a writer is recognized as a function parameter
+// declared as http.ResponseWriter.
+func detectMissingContentType(fset *token.FileSet, node ast.Node) []*models.Issue {
+	var funcType *ast.FuncType
+	var body *ast.BlockStmt
+	switch fn := node.(type) {
+	case *ast.FuncDecl:
+		funcType, body = fn.Type, fn.Body
+	case *ast.FuncLit:
+		funcType, body = fn.Type, fn.Body
+	default:
 		return nil
 	}
+	writers := responseWriterParams(funcType)
+	if body == nil || len(writers) == 0 {
+		return nil
+	}
+
+	// Walk the body in source order, so a header set after the first write does not count
+	var issues []*models.Issue
+	ast.Inspect(body, func(n ast.Node) bool {
+		// Function literals with their own writers are checked separately
+		if lit, ok := n.(*ast.FuncLit); ok && len(responseWriterParams(lit.Type)) > 0 {
+			return false
+		}
+		callExpr, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		if writer := contentTypeWriter(callExpr); writer != "" {
+			delete(writers, writer)
+			return true
+		}
+
+		selectorExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+		if !ok || selectorExpr.Sel.Name != "Write" {
+			return true
+		}
+		ident, ok := selectorExpr.X.(*ast.Ident)
+		if !ok || !writers[ident.Name] {
+			return true
+		}
+
+		// Only the first write matters, as it sends the headers
+		delete(writers, ident.Name)
+		pos := fset.Position(callExpr.Pos())
+		issues = append(issues, &models.Issue{
+			File:       pos.Filename,
+			Line:       pos.Line,
+			Column:     pos.Column,
+			Message:    "Potential missing Content-Type header in HTTP response",
+			Category:   "security",
+			Severity:   "medium",
+			Confidence: "low",
+			Rule:       "CS003",
+		})
+		return true
+	})
+
+	return issues
+}
+
+// responseWriterParams returns the names of the http.ResponseWriter parameters of a function
+func responseWriterParams(funcType *ast.FuncType) map[string]bool {
+	writers := make(map[string]bool)
+	if funcType.Params == nil {
+		return writers
+	}
+	for _, field := range funcType.Params.List {
+		selectorExpr, ok := field.Type.(*ast.SelectorExpr)
+		if !ok || selectorExpr.Sel.Name != "ResponseWriter" {
+			continue
+		}
+		if ident, ok := selectorExpr.X.(*ast.Ident); !ok || ident.Name != "http" {
+			continue
+		}
+		for _, name := range field.Names {
+			writers[name.Name] = true
+		}
+	}
+	return writers
+}
 
+// contentTypeWriter returns the name of the writer whose Content-Type header a call of the
+// form w.Header().Set("Content-Type", ...) sets, or an empty string for any other call
+func contentTypeWriter(callExpr *ast.CallExpr) string {
 	selectorExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
-	if !ok || selectorExpr.Sel.Name != "Write" {
-		return nil
+	if !ok || (selectorExpr.Sel.Name != "Set" && selectorExpr.Sel.Name != "Add") || len(callExpr.Args) == 0 {
+		return ""
+	}
+	key, ok := callExpr.Args[0].(*ast.BasicLit)
+	if !ok || key.Kind != token.STRING || !strings.EqualFold(strings.Trim(key.Value, "`\""), "Content-Type") {
+		return ""
 	}
 
-	// This is a simplified check and would need more context analysis
-	// to determine if it's an HTTP response writer and if Content-Type is set
-	pos := fset.Position(callExpr.Pos())
-	return &models.Issue{
-		File:       pos.Filename,
-		Line:       pos.Line,
-		Column:     pos.Column,
-		Message:    "Potential missing Content-Type header in HTTP response",
-		Category:   "security",
-		Severity:   "medium",
-		Confidence: "low",
-		Suggestion: "Set Content-Type header before writing to the response",
-		Rule:       "CS003",
+	headerCall, ok := selectorExpr.X.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	headerSelector, ok := headerCall.Fun.(*ast.SelectorExpr)
+	if !ok || headerSelector.Sel.Name != "Header" {
+		return ""
 	}
+	if ident, ok := headerSelector.X.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
 }
 
 // detectInsecureCookie detects insecure cookie settings
-func detectInsecureCookie(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectInsecureCookie(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Look for http.Cookie creation without Secure and HttpOnly flags
 	compositeLit, ok := node.(*ast.CompositeLit)
 	if !ok {
@@ -254,7 +445,7 @@ func detectInsecureCookie(fset *token.FileSet, node ast.Node) *models.Issue {
 					message += "HttpOnly flag"
 				}
 
-				return &models.Issue{
+				return []*models.Issue{{
 					File:       pos.Filename,
 					Line:       pos.Line,
 					Column:     pos.Column,
@@ -262,9 +453,8 @@ func detectInsecureCookie(fset *token.FileSet, node ast.Node) *models.Issue {
 					Category:   "security",
 					Severity:   "high",
 					Confidence: "high",
-					Suggestion: "Set both Secure and HttpOnly flags to true for cookies",
 					Rule:       "CS004",
-				}
+				}}
 			}
 		}
 	}
@@ -273,19 +463,260 @@ func detectInsecureCookie(fset *token.FileSet, node ast.Node) *models.Issue {
 }
 
 // detectWeakCryptoKey detects weak cryptographic key sizes
-func detectWeakCryptoKey(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectWeakCryptoKey(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Implementation will be added
 	return nil
 }
 
 // detectUnvalidatedRedirect detects unvalidated redirects
-func detectUnvalidatedRedirect(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectUnvalidatedRedirect(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Implementation will be added
 	return nil
 }
 
 // detectSensitiveLogging detects logging of sensitive information
-func detectSensitiveLogging(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectSensitiveLogging(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Implementation will be added
 	return nil
 }
+
+// sensitiveFields lists the names of struct fields holding secrets, in lower case without
+// underscores
+var sensitiveFields = map[string]bool{
+	"password":     true,
+	"passwd":       true,
+	"passwordhash": true,
+	"secret":       true,
+	"secretkey":    true,
+	"clientsecret": true,
+	"token":        true,
+	"accesstoken":  true,
+	"refreshtoken": true,
+	"authtoken":    true,
+	"apikey":       true,
+	"privatekey":   true,
+}
+
+// encodingTags lists the struct tag keys of the encodings that include exported fields
+// unless tagged "-"
+var encodingTags = []string{"json", "xml", "yaml"}
+
+// detectSerializedSecret detects sensitive fields, such as Password or APIKey, in structs
+// that are encoded to JSON, XML, or YAML, which is likely to send the secrets in API
+// responses. A struct is taken to be encoded when its fields have tags of the encoding,
+// and a sensitive field is reported unless its tag for each of those encodings is "-".
+func detectSerializedSecret(fset *token.FileSet, node ast.Node) []*models.Issue {
+	structType, ok := node.(*ast.StructType)
+	if !ok || structType.Fields == nil {
+		return nil
+	}
+
+	// Find the encodings of the struct
+	encoded := make(map[string]bool)
+	for _, field := range structType.Fields.List {
+		tag := fieldTag(field)
+		for _, key := range encodingTags {
+			if _, ok := tag.Lookup(key); ok {
+				encoded[key] = true
+			}
+		}
+	}
+	if len(encoded) == 0 {
+		return nil
+	}
+
+	var issues []*models.Issue
+	for _, field := range structType.Fields.List {
+		var included []string
+		tag := fieldTag(field)
+		for _, key := range encodingTags {
+			if encoded[key] && tag.Get(key) != "-" {
+				included = append(included, strings.ToUpper(key))
+			}
+		}
+		if len(included) == 0 {
+			continue
+		}
+
+		for _, name := range field.Names {
+			if !name.IsExported() || !sensitiveFields[strings.ToLower(strings.ReplaceAll(name.Name, "_", ""))] {
+				continue
+			}
+			pos := fset.Position(name.Pos())
+			issues = append(issues, &models.Issue{
+				File:       pos.Filename,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Message:    "Sensitive field " + name.Name + " is included in the " + strings.Join(included, " and ") + " encoding of its struct",
+				Category:   "security",
+				Severity:   "medium",
+				Confidence: "medium",
+				Rule:       "CS009",
+			})
+		}
+	}
+	return issues
+}
+
+// fieldTag returns the tag of a struct field, empty if it has none or it is malformed
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+// sqlQueryArgs maps the database/sql methods that run a query to the index of the query argument
+var sqlQueryArgs = map[string]int{
+	"Query":           0,
+	"QueryRow":        0,
+	"Exec":            0,
+	"Prepare":         0,
+	"QueryContext":    1,
+	"QueryRowContext": 1,
+	"ExecContext":     1,
+	"PrepareContext":  1,
+}
+
+// requestInputFields lists the fields and methods of *http.Request that return client input
+var requestInputFields = map[string]bool{
+	"FormValue":     true,
+	"PostFormValue": true,
+	"Form":          true,
+	"PostForm":      true,
+	"URL":           true,
+	"Header":        true,
+	"Body":          true,
+	"Cookie":        true,
+	"PathValue":     true,
+}
+
+// detectSQLInjection detects queries passed to database/sql methods that are built with +
+// or fmt.Sprintf from values that are not constants. Without type information, a query
+// held in a variable is followed to the expression it was first assigned.
+func detectSQLInjection(fset *token.FileSet, node ast.Node) []*models.Issue {
+	callExpr, ok := node.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	selectorExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	index, ok := sqlQueryArgs[selectorExpr.Sel.Name]
+	if !ok || index >= len(callExpr.Args) {
+		return nil
+	}
+
+	query := callExpr.Args[index]
+	if ident, ok := query.(*ast.Ident); ok {
+		query = assignedValue(ident)
+	}
+	if query == nil || !dynamicString(query) {
+		return nil
+	}
+
+	confidence := "medium"
+	if readsRequestInput(query) {
+		confidence = "high"
+	}
+
+	pos := fset.Position(callExpr.Pos())
+	return []*models.Issue{{
+		File:       pos.Filename,
+		Line:       pos.Line,
+		Column:     pos.Column,
+		Message:    "SQL query passed to " + selectorExpr.Sel.Name + " is built from non-constant values, which allows SQL injection",
+		Category:   "security",
+		Severity:   "critical",
+		Confidence: confidence,
+		Rule:       "CS008",
+	}}
+}
+
+// assignedValue returns the expression a local variable is initialized with, or nil if it
+// cannot be determined
+func assignedValue(ident *ast.Ident) ast.Expr {
+	if ident.Obj == nil || ident.Obj.Kind != ast.Var {
+		return nil
+	}
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.AssignStmt:
+		if len(decl.Lhs) != len(decl.Rhs) {
+			return nil
+		}
+		for i, lhs := range decl.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok && id.Obj == ident.Obj {
+				return decl.Rhs[i]
+			}
+		}
+	case *ast.ValueSpec:
+		if len(decl.Names) != len(decl.Values) {
+			return nil
+		}
+		for i, name := range decl.Names {
+			if name.Obj == ident.Obj {
+				return decl.Values[i]
+			}
+		}
+	}
+	return nil
+}
+
+// dynamicString reports whether an expression concatenates or formats a string from
+// values that are not constants
+func dynamicString(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return dynamicString(e.X)
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD && (!constantExpr(e.X) || !constantExpr(e.Y))
+	case *ast.CallExpr:
+		selectorExpr, ok := e.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		pkg, ok := selectorExpr.X.(*ast.Ident)
+		if !ok || pkg.Name != "fmt" || selectorExpr.Sel.Name != "Sprintf" {
+			return false
+		}
+		for _, arg := range e.Args {
+			if !constantExpr(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// constantExpr reports whether an expression is made of literals and constants only
+func constantExpr(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.ParenExpr:
+		return constantExpr(e.X)
+	case *ast.BinaryExpr:
+		return constantExpr(e.X) && constantExpr(e.Y)
+	case *ast.Ident:
+		return e.Obj != nil && e.Obj.Kind == ast.Con
+	}
+	return false
+}
+
+// readsRequestInput reports whether an expression reads a field or method of an HTTP
+// request that holds client input, such as r.FormValue or r.URL.Query()
+func readsRequestInput(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if selectorExpr, ok := n.(*ast.SelectorExpr); ok && requestInputFields[selectorExpr.Sel.Name] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
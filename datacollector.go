@@ -1,10 +1,11 @@
 package ml
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/user/code-review-assistant/internal/config"
@@ -15,6 +16,8 @@ import (
 type DataCollector struct {
 	config    *config.Config
 	dataPath  string
+	storage   storage
+	mutex     sync.Mutex
 	issueData map[string][]models.LearningData // Map of rule ID to learning data
 }
 
@@ -39,98 +42,134 @@ func (c *DataCollector) Initialize() error {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
+	// Open the configured storage backend
+	storage, err := newStorage(c.config.StorageBackend, c.dataPath)
+	if err != nil {
+		return err
+	}
+	c.storage = storage
+
 	// Load existing data
 	return c.loadData()
 }
 
-// loadData loads existing learning data
-func (c *DataCollector) loadData() error {
-	dataFile := filepath.Join(c.dataPath, "learning_data.json")
-
-	// Check if file exists
-	if _, err := os.Stat(dataFile); os.IsNotExist(err) {
-		// File doesn't exist, initialize empty data
-		c.issueData = make(map[string][]models.LearningData)
+// Close releases the storage backend
+func (c *DataCollector) Close() error {
+	if c.storage == nil {
 		return nil
 	}
-
-	// Read file
-	data, err := os.ReadFile(dataFile)
-	if err != nil {
-		return fmt.Errorf("failed to read learning data: %w", err)
-	}
-
-	// Parse JSON
-	if err := json.Unmarshal(data, &c.issueData); err != nil {
-		return fmt.Errorf("failed to parse learning data: %w", err)
-	}
-
-	return nil
+	return c.storage.close()
 }
 
-// saveData saves learning data to disk
-func (c *DataCollector) saveData() error {
-	dataFile := filepath.Join(c.dataPath, "learning_data.json")
-
-	// Marshal to JSON with indentation
-	data, err := json.MarshalIndent(c.issueData, "", "  ")
+// loadData loads existing learning data
+func (c *DataCollector) loadData() error {
+	issueData, err := c.storage.load()
 	if err != nil {
-		return fmt.Errorf("failed to marshal learning data: %w", err)
+		return err
 	}
 
-	// Write to file
-	if err := os.WriteFile(dataFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write learning data: %w", err)
-	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
+	c.issueData = issueData
 	return nil
 }
 
 // RecordIssue records an issue for learning
 func (c *DataCollector) RecordIssue(issue *models.Issue, repository string) error {
-	if !c.config.EnableLearning {
+	return c.RecordIssues([]*models.Issue{issue}, repository)
+}
+
+// RecordIssues records a batch of issues for learning, saving the data only once
+func (c *DataCollector) RecordIssues(issues []*models.Issue, repository string) error {
+	if !c.config.EnableLearning || len(issues) == 0 {
 		return nil
 	}
 
-	// Create learning data
-	learningData := models.LearningData{
-		Issue:      issue,
-		Accepted:   false, // Will be updated when feedback is received
-		Repository: repository,
-		Timestamp:  time.Now(),
+	now := time.Now()
+	added := make([]models.LearningData, 0, len(issues))
+	for _, issue := range issues {
+		added = append(added, models.LearningData{
+			Issue:      issue,
+			Accepted:   false, // Will be updated when feedback is received
+			Repository: repository,
+			Timestamp:  now,
+		})
 	}
 
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	// Add to data
-	c.issueData[issue.Rule] = append(c.issueData[issue.Rule], learningData)
+	for _, data := range added {
+		c.issueData[data.Issue.Rule] = append(c.issueData[data.Issue.Rule], data)
+	}
 
 	// Save data
-	return c.saveData()
+	return c.storage.addIssues(c.issueData, added)
 }
 
-// RecordFeedback records feedback for an issue
+// RecordFeedback records feedback for an issue, identified by its fingerprint or its ID
 func (c *DataCollector) RecordFeedback(issueID string, accepted bool) error {
 	if !c.config.EnableLearning {
 		return nil
 	}
+	fingerprint := fingerprintOf(issueID)
 
-	// Find issue in data
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Find issue in data, the same issue is recorded once per run
 	for ruleID, issues := range c.issueData {
 		for i, data := range issues {
-			if data.Issue.File+":"+fmt.Sprint(data.Issue.Line)+":"+data.Issue.Message == issueID {
+			if data.Issue != nil && data.Issue.Fingerprint() == fingerprint {
 				// Update acceptance
 				c.issueData[ruleID][i].Accepted = accepted
-				
-				// Save data
-				return c.saveData()
 			}
 		}
 	}
 
-	return fmt.Errorf("issue not found: %s", issueID)
+	// Save data; the storage may know of issues recorded by other runs since loading
+	found, err := c.storage.setAccepted(c.issueData, fingerprint, accepted)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("issue not found: %s", issueID)
+	}
+
+	return nil
+}
+
+// fingerprintOf returns the fingerprint of an issue ID, dropping the number that analysis
+// appends to the IDs of later issues of a rule sharing a line, as they share the fingerprint
+func fingerprintOf(issueID string) string {
+	if fingerprint, n, ok := strings.Cut(issueID, "-"); ok {
+		if _, err := strconv.Atoi(n); err == nil {
+			return fingerprint
+		}
+	}
+	return issueID
+}
+
+// ruleData returns a copy of the learning data recorded for a rule
+func (c *DataCollector) ruleData(ruleID string) []models.LearningData {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]models.LearningData(nil), c.issueData[ruleID]...)
 }
 
 // GetAcceptanceRate returns the acceptance rate for a rule
 func (c *DataCollector) GetAcceptanceRate(ruleID string) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.acceptanceRate(ruleID)
+}
+
+// acceptanceRate computes the acceptance rate for a rule, the caller must hold the mutex
+func (c *DataCollector) acceptanceRate(ruleID string) float64 {
 	issues, ok := c.issueData[ruleID]
 	if !ok || len(issues) == 0 {
 		return 0.5 // Default to 50% if no data
@@ -168,13 +207,15 @@ func (c *DataCollector) GetTopRules(n int) []string {
 		Rate float64
 	}
 	
+	c.mutex.Lock()
 	var rates []ruleRate
 	for ruleID := range c.issueData {
 		rates = append(rates, ruleRate{
 			ID:   ruleID,
-			Rate: c.GetAcceptanceRate(ruleID),
+			Rate: c.acceptanceRate(ruleID),
 		})
 	}
+	c.mutex.Unlock()
 	
 	// Sort by acceptance rate (descending)
 	for i := 0; i < len(rates); i++ {
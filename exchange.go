@@ -0,0 +1,138 @@
+package ml
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// exportVersion is the version of the export format written by Export. It is incremented
+// whenever the format changes in a way older versions cannot read, and Import refuses
+// exports of newer versions rather than importing part of them.
+const exportVersion = 1
+
+// export is an exported copy of the learning data
+type export struct {
+	Version  int                              `json:"version"`
+	Exported time.Time                        `json:"exported"`
+	Data     map[string][]models.LearningData `json:"data"` // Map of rule ID to learning data
+}
+
+// ImportResult counts what Import changed in the learning data
+type ImportResult struct {
+	Added    int // Records that were not known yet
+	Skipped  int // Records already known, such as those of a previous import of the same data
+	Accepted int // Known issues marked accepted by the feedback of the imported data
+}
+
+// Export writes all learning data to a file that can be imported into the learning data of
+// another machine
+func (c *DataCollector) Export(path string) error {
+	c.mutex.Lock()
+	data, err := json.MarshalIndent(&export{Version: exportVersion, Exported: time.Now(), Data: c.issueData}, "", "  ")
+	c.mutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal learning data: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write learning data export: %w", err)
+	}
+	return nil
+}
+
+// Import merges a file written by Export into the learning data. A record is the issue
+// found by one run, so records of the same issue and time are duplicates and are added only
+// once. Feedback applies to an issue on every run it was found, so an issue accepted in
+// either copy is accepted in the result.
+func (c *DataCollector) Import(path string) (*ImportResult, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read learning data export: %w", err)
+	}
+
+	// Check the version before the data, whose format depends on it
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(content, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse learning data export: %w", err)
+	}
+	switch {
+	case header.Version == 0:
+		return nil, fmt.Errorf("%s is not a learning data export", path)
+	case header.Version > exportVersion:
+		return nil, fmt.Errorf("%s was exported by a newer version (format %d, this version reads up to %d)", path, header.Version, exportVersion)
+	}
+	var imported export
+	if err := json.Unmarshal(content, &imported); err != nil {
+		return nil, fmt.Errorf("failed to parse learning data export: %w", err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	type recordKey struct {
+		fingerprint string
+		timestamp   int64
+	}
+	known := make(map[recordKey]bool)
+	for _, records := range c.issueData {
+		for _, data := range records {
+			if data.Issue != nil {
+				known[recordKey{data.Issue.Fingerprint(), data.Timestamp.UnixNano()}] = true
+			}
+		}
+	}
+
+	result := &ImportResult{}
+	var added []models.LearningData
+	acceptedIDs := make(map[string]bool)
+	for _, records := range imported.Data {
+		for _, data := range records {
+			if data.Issue == nil || data.Issue.Rule == "" {
+				continue
+			}
+			fingerprint := data.Issue.Fingerprint()
+			if data.Accepted {
+				acceptedIDs[fingerprint] = true
+			}
+
+			key := recordKey{fingerprint, data.Timestamp.UnixNano()}
+			if known[key] {
+				result.Skipped++
+				continue
+			}
+			known[key] = true
+			added = append(added, data)
+			c.issueData[data.Issue.Rule] = append(c.issueData[data.Issue.Rule], data)
+		}
+	}
+	result.Added = len(added)
+
+	// Mark the issues accepted in the import on all of their records
+	var accepted []string
+	for fingerprint := range acceptedIDs {
+		changed := false
+		for ruleID, records := range c.issueData {
+			for i, data := range records {
+				if !data.Accepted && data.Issue != nil && data.Issue.Fingerprint() == fingerprint {
+					c.issueData[ruleID][i].Accepted = true
+					changed = true
+				}
+			}
+		}
+		if changed {
+			accepted = append(accepted, fingerprint)
+		}
+	}
+	result.Accepted = len(accepted)
+
+	if len(added) == 0 && len(accepted) == 0 {
+		return result, nil
+	}
+	return result, c.storage.merge(c.issueData, added, accepted)
+}
@@ -0,0 +1,579 @@
+package optimization
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// ErrNotFixable is returned by ApplyFix when an optimization cannot be applied automatically
+var ErrNotFixable = errors.New("optimization cannot be applied automatically")
+
+// fixer computes the edits applying an optimization reported at a line of a parsed file
+type fixer func(fset *token.FileSet, file *ast.File, src []byte, line int) ([]edit, error)
+
+// fixers maps the IDs of the rules that have an automatic fix to their fixer
+var fixers = map[string]fixer{
+	"OPT001": fixStringConcat,
+	"OPT003": fixSliceCapacity,
+}
+
+// integerTypes are the type names that can be ranged over directly
+var integerTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+// edit replaces the source between two byte offsets with text
+type edit struct {
+	start int
+	end   int
+	text  string
+}
+
+// CanFix reports whether the rule of an optimization has an automatic fix
+func CanFix(opt *models.Optimization) bool {
+	_, ok := fixers[opt.Rule]
+	return ok
+}
+
+// ApplyFix rewrites src, the contents of the file of an optimization, so that the
+// optimization is applied, and returns the result formatted with go/format. The error
+// wraps ErrNotFixable when the rule has no automatic fix or when the code does not have
+// the exact shape the fix can rewrite safely.
+func ApplyFix(opt *models.Optimization, src []byte) ([]byte, error) {
+	fix, ok := fixers[opt.Rule]
+	if !ok {
+		return nil, fmt.Errorf("%w: rule %s has no fix", ErrNotFixable, opt.Rule)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, opt.File, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	edits, err := fix(fset, file, src, opt.Line)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(applyEdits(src, edits))
+}
+
+// fixStringConcat replaces the `s += x` statements of a loop with writes to a
+// strings.Builder declared before the loop, and assigns the built string after it
+func fixStringConcat(fset *token.FileSet, file *ast.File, src []byte, line int) ([]edit, error) {
+	loop, stmt := findLoopStmt(fset, file, line, func(stmt ast.Stmt) bool {
+		assign, ok := stmt.(*ast.AssignStmt)
+		return ok && assign.Tok == token.ADD_ASSIGN
+	})
+	if loop == nil {
+		return nil, fmt.Errorf("%w: no concatenation in a loop at line %d", ErrNotFixable, line)
+	}
+
+	assign := stmt.(*ast.AssignStmt)
+	target, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok || len(assign.Lhs) != 1 || target.Obj == nil {
+		return nil, fmt.Errorf("%w: concatenation target is not a local variable", ErrNotFixable)
+	}
+
+	decl, ok := target.Obj.Decl.(ast.Node)
+	if !ok || decl.Pos() >= loop.Pos() {
+		return nil, fmt.Errorf("%w: %s is not declared before the loop", ErrNotFixable, target.Name)
+	}
+	initial, ok := stringDeclValue(decl, target.Name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not declared as a string", ErrNotFixable, target.Name)
+	}
+
+	outer, parent := enclosingNodes(file, loop)
+	if parent == nil {
+		return nil, fmt.Errorf("%w: loop is not in a function body", ErrNotFixable)
+	}
+
+	// Every use of the variable inside the loop must be a concatenation, since the
+	// variable is only updated once the loop ends
+	var concats []*ast.AssignStmt
+	var unsupported error
+	ast.Inspect(loop, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncLit:
+			if references(n, target.Obj) {
+				unsupported = fmt.Errorf("%w: %s is used in a closure", ErrNotFixable, target.Name)
+			}
+			return false
+		case *ast.AssignStmt:
+			if ident, ok := n.Lhs[0].(*ast.Ident); ok && ident.Obj == target.Obj {
+				if n.Tok != token.ADD_ASSIGN || len(n.Lhs) != 1 || references(n.Rhs[0], target.Obj) {
+					unsupported = fmt.Errorf("%w: %s is assigned inside the loop", ErrNotFixable, target.Name)
+					return false
+				}
+				concats = append(concats, n)
+				ast.Inspect(n.Rhs[0], func(node ast.Node) bool {
+					if lit, ok := node.(*ast.FuncLit); ok && references(lit, target.Obj) {
+						unsupported = fmt.Errorf("%w: %s is used in a closure", ErrNotFixable, target.Name)
+					}
+					return true
+				})
+				return false
+			}
+		case *ast.Ident:
+			if n.Obj == target.Obj {
+				unsupported = fmt.Errorf("%w: %s is read inside the loop", ErrNotFixable, target.Name)
+			}
+		}
+		return true
+	})
+	if unsupported != nil {
+		return nil, unsupported
+	}
+
+	pkgName, importEdit := stringsImport(fset, file, src)
+	if shadowed(file, pkgName) {
+		return nil, fmt.Errorf("%w: package %s is shadowed", ErrNotFixable, pkgName)
+	}
+
+	builder := unusedName(file, target.Name+"Builder")
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	prelude := fmt.Sprintf("var %s %s.Builder\n", builder, pkgName)
+	if initial != "" || changedBefore(parent, decl, outer, target.Obj) {
+		prelude += fmt.Sprintf("%s.WriteString(%s)\n", builder, target.Name)
+	}
+
+	edits := []edit{
+		{start: offset(outer.Pos()), end: offset(outer.Pos()), text: prelude},
+		{start: offset(outer.End()), end: offset(outer.End()), text: fmt.Sprintf("\n%s = %s.String()", target.Name, builder)},
+	}
+	for _, concat := range concats {
+		value := src[offset(concat.Rhs[0].Pos()):offset(concat.Rhs[0].End())]
+		edits = append(edits, edit{
+			start: offset(concat.Pos()),
+			end:   offset(concat.End()),
+			text:  fmt.Sprintf("%s.WriteString(%s)", builder, value),
+		})
+	}
+	if importEdit != nil {
+		edits = append(edits, *importEdit)
+	}
+
+	return edits, nil
+}
+
+// fixSliceCapacity replaces the declaration of a slice that is appended to in the loop
+// directly following it with a make call pre-allocating the number of iterations
+func fixSliceCapacity(fset *token.FileSet, file *ast.File, src []byte, line int) ([]edit, error) {
+	loop, stmt := findLoopStmt(fset, file, line, func(stmt ast.Stmt) bool {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok {
+			return false
+		}
+		for _, rhs := range assign.Rhs {
+			if call, ok := rhs.(*ast.CallExpr); ok && isIdent(call.Fun, "append") && fset.Position(call.Pos()).Line == line {
+				return true
+			}
+		}
+		return false
+	})
+	if loop == nil {
+		return nil, fmt.Errorf("%w: no append in a loop at line %d", ErrNotFixable, line)
+	}
+
+	assign := stmt.(*ast.AssignStmt)
+	if assign.Tok != token.ASSIGN || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return nil, fmt.Errorf("%w: append result is not assigned to a single variable", ErrNotFixable)
+	}
+	target, ok := assign.Lhs[0].(*ast.Ident)
+	call, _ := assign.Rhs[0].(*ast.CallExpr)
+	if !ok || target.Obj == nil || call == nil || len(call.Args) == 0 {
+		return nil, fmt.Errorf("%w: append target is not a local variable", ErrNotFixable)
+	}
+	if arg, ok := call.Args[0].(*ast.Ident); !ok || arg.Obj != target.Obj {
+		return nil, fmt.Errorf("%w: append does not extend %s", ErrNotFixable, target.Name)
+	}
+
+	capacity, err := iterationCount(src, fset, loop, target.Obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// The declaration must directly precede the loop so that the capacity expression
+	// means the same thing where it is moved to
+	outer, parent := enclosingNodes(file, loop)
+	if parent == nil {
+		return nil, fmt.Errorf("%w: loop is not in a function body", ErrNotFixable)
+	}
+	var previous ast.Stmt
+	for i, s := range parent.List {
+		if s == outer && i > 0 {
+			previous = parent.List[i-1]
+		}
+	}
+
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	text := func(node ast.Node) string { return string(src[offset(node.Pos()):offset(node.End())]) }
+
+	switch s := previous.(type) {
+	case *ast.DeclStmt:
+		gen, _ := s.Decl.(*ast.GenDecl)
+		if gen == nil || gen.Tok != token.VAR || gen.Lparen.IsValid() || len(gen.Specs) != 1 {
+			break
+		}
+		spec := gen.Specs[0].(*ast.ValueSpec)
+		if len(spec.Names) != 1 || spec.Names[0].Obj != target.Obj || len(spec.Values) != 0 || !isSliceType(spec.Type) {
+			break
+		}
+		return []edit{{
+			start: offset(s.Pos()),
+			end:   offset(s.End()),
+			text:  fmt.Sprintf("%s := make(%s, 0, %s)", target.Name, text(spec.Type), capacity),
+		}}, nil
+	case *ast.AssignStmt:
+		if s.Tok != token.DEFINE || len(s.Lhs) != 1 || len(s.Rhs) != 1 {
+			break
+		}
+		lit, ok := s.Rhs[0].(*ast.CompositeLit)
+		if ident, _ := s.Lhs[0].(*ast.Ident); ident == nil || ident.Obj != target.Obj || !ok || len(lit.Elts) != 0 || !isSliceType(lit.Type) {
+			break
+		}
+		return []edit{{
+			start: offset(lit.Pos()),
+			end:   offset(lit.End()),
+			text:  fmt.Sprintf("make(%s, 0, %s)", text(lit.Type), capacity),
+		}}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s is not declared as an empty slice directly before the loop", ErrNotFixable, target.Name)
+}
+
+// iterationCount returns the source of an expression evaluating to the number of
+// iterations of a loop, for loops counting from zero and loops ranging over a variable.
+// The expression must not depend on slice, the object being allocated.
+func iterationCount(src []byte, fset *token.FileSet, loop ast.Stmt, slice *ast.Object) (string, error) {
+	text := func(node ast.Node) string {
+		return string(src[fset.Position(node.Pos()).Offset:fset.Position(node.End()).Offset])
+	}
+
+	switch l := loop.(type) {
+	case *ast.RangeStmt:
+		if references(l.X, slice) {
+			break
+		}
+		switch x := l.X.(type) {
+		case *ast.BasicLit:
+			if x.Kind == token.INT {
+				return x.Value, nil
+			}
+		case *ast.Ident, *ast.SelectorExpr:
+			if !isSimpleExpr(x) {
+				break
+			}
+			if ident, ok := x.(*ast.Ident); ok && isIntegerVar(ident) {
+				return ident.Name, nil
+			}
+			return "len(" + text(x) + ")", nil
+		}
+	case *ast.ForStmt:
+		init, ok := l.Init.(*ast.AssignStmt)
+		if !ok || init.Tok != token.DEFINE || len(init.Lhs) != 1 || len(init.Rhs) != 1 {
+			break
+		}
+		counter, ok := init.Lhs[0].(*ast.Ident)
+		if start, _ := init.Rhs[0].(*ast.BasicLit); !ok || start == nil || start.Value != "0" {
+			break
+		}
+		cond, ok := l.Cond.(*ast.BinaryExpr)
+		if !ok || cond.Op != token.LSS || !isIdent(cond.X, counter.Name) || !isSimpleExpr(cond.Y) || references(cond.Y, slice) {
+			break
+		}
+		post, ok := l.Post.(*ast.IncDecStmt)
+		if !ok || post.Tok != token.INC || !isIdent(post.X, counter.Name) {
+			break
+		}
+		return text(cond.Y), nil
+	}
+
+	return "", fmt.Errorf("%w: number of loop iterations is unknown", ErrNotFixable)
+}
+
+// findLoopStmt returns the loop at any depth of a file whose body directly contains a
+// statement on the given line accepted by match, along with that statement
+func findLoopStmt(fset *token.FileSet, file *ast.File, line int, match func(ast.Stmt) bool) (ast.Stmt, ast.Stmt) {
+	var loop, found ast.Stmt
+	ast.Inspect(file, func(node ast.Node) bool {
+		if found != nil {
+			return false
+		}
+
+		var body *ast.BlockStmt
+		switch n := node.(type) {
+		case *ast.ForStmt:
+			body = n.Body
+		case *ast.RangeStmt:
+			body = n.Body
+		default:
+			return true
+		}
+
+		for _, stmt := range body.List {
+			if fset.Position(stmt.Pos()).Line == line && match(stmt) {
+				loop, found = node.(ast.Stmt), stmt
+				return false
+			}
+		}
+		return true
+	})
+	return loop, found
+}
+
+// enclosingNodes returns the statement of a block that holds a loop, which is the loop
+// itself or a labeled statement around it, and that block
+func enclosingNodes(file *ast.File, loop ast.Stmt) (ast.Stmt, *ast.BlockStmt) {
+	var stack []ast.Node
+	var path []ast.Node
+	ast.Inspect(file, func(node ast.Node) bool {
+		if path != nil {
+			return false
+		}
+		if node == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if node == loop {
+			path = append([]ast.Node(nil), stack...)
+			return false
+		}
+		stack = append(stack, node)
+		return true
+	})
+
+	outer := loop
+	for i := len(path) - 1; i >= 0; i-- {
+		switch n := path[i].(type) {
+		case *ast.LabeledStmt:
+			outer = n
+		case *ast.BlockStmt:
+			return outer, n
+		default:
+			return outer, nil
+		}
+	}
+	return outer, nil
+}
+
+// stringDeclValue reports whether a declaration declares name as a string variable and
+// returns the source of its initial value, or an empty string for the zero value
+func stringDeclValue(decl ast.Node, name string) (string, bool) {
+	switch d := decl.(type) {
+	case *ast.ValueSpec:
+		for i, ident := range d.Names {
+			if ident.Name != name {
+				continue
+			}
+			var value ast.Expr
+			if i < len(d.Values) {
+				value = d.Values[i]
+			} else if len(d.Values) > 0 {
+				return "", false
+			}
+			if d.Type != nil && !isIdent(d.Type, "string") {
+				return "", false
+			}
+			if value == nil {
+				return "", d.Type != nil
+			}
+			return stringLiteral(value)
+		}
+	case *ast.AssignStmt:
+		if d.Tok != token.DEFINE || len(d.Lhs) != len(d.Rhs) {
+			return "", false
+		}
+		for i, lhs := range d.Lhs {
+			if isIdent(lhs, name) {
+				return stringLiteral(d.Rhs[i])
+			}
+		}
+	}
+	return "", false
+}
+
+// stringLiteral returns the value of a string literal expression
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+// stringsImport returns the name under which the file imports the strings package, and
+// the edit adding the import when the file does not import it yet
+func stringsImport(fset *token.FileSet, file *ast.File, src []byte) (string, *edit) {
+	for _, spec := range file.Imports {
+		if spec.Path.Value != `"strings"` {
+			continue
+		}
+		if spec.Name == nil {
+			return "strings", nil
+		}
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			break
+		}
+		return spec.Name.Name, nil
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Lparen.IsValid() {
+			offset := fset.Position(gen.Lparen).Offset + 1
+			return "strings", &edit{start: offset, end: offset, text: "\n\"strings\""}
+		}
+		// Turn the single import into a group
+		start, end := fset.Position(gen.Pos()).Offset, fset.Position(gen.End()).Offset
+		spec := fset.Position(gen.Specs[0].Pos()).Offset
+		return "strings", &edit{start: start, end: end, text: "import (\n" + string(src[spec:end]) + "\n\"strings\"\n)"}
+	}
+
+	offset := fset.Position(file.Name.End()).Offset
+	return "strings", &edit{start: offset, end: offset, text: "\n\nimport \"strings\""}
+}
+
+// shadowed reports whether a package name is declared as something else anywhere in a file
+func shadowed(file *ast.File, name string) bool {
+	found := false
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == name && ident.Obj != nil {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// references reports whether a node refers to an object
+func references(node ast.Node, obj *ast.Object) bool {
+	found := false
+	ast.Inspect(node, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Obj == obj {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// changedBefore reports whether a variable may have been assigned between its declaration
+// and a statement of a block. Variables declared outside the block are assumed changed.
+func changedBefore(block *ast.BlockStmt, decl ast.Node, stmt ast.Stmt, obj *ast.Object) bool {
+	declared := false
+	for _, s := range block.List {
+		if s == stmt {
+			break
+		}
+		if s.Pos() <= decl.Pos() && decl.End() <= s.End() {
+			declared = true
+			continue
+		}
+		if declared && references(s, obj) {
+			return true
+		}
+	}
+	return !declared
+}
+
+// unusedName returns name, or name followed by a number, so that it is not an identifier
+// already used in the file
+func unusedName(file *ast.File, name string) string {
+	used := make(map[string]bool)
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			used[ident.Name] = true
+		}
+		return true
+	})
+
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+// isIntegerVar reports whether an identifier is declared with an integer type or value
+func isIntegerVar(ident *ast.Ident) bool {
+	if ident.Obj == nil {
+		return false
+	}
+	switch d := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		typ, ok := d.Type.(*ast.Ident)
+		return ok && integerTypes[typ.Name]
+	case *ast.ValueSpec:
+		if typ, ok := d.Type.(*ast.Ident); ok {
+			return integerTypes[typ.Name]
+		}
+		for i, name := range d.Names {
+			if name.Name == ident.Name && i < len(d.Values) {
+				lit, ok := d.Values[i].(*ast.BasicLit)
+				return ok && lit.Kind == token.INT
+			}
+		}
+	case *ast.AssignStmt:
+		for i, lhs := range d.Lhs {
+			if isIdent(lhs, ident.Name) && len(d.Lhs) == len(d.Rhs) {
+				lit, ok := d.Rhs[i].(*ast.BasicLit)
+				return ok && lit.Kind == token.INT
+			}
+		}
+	}
+	return false
+}
+
+// isSimpleExpr reports whether an expression can be evaluated again without side effects:
+// an identifier, a literal, a field selector, or len of one of those
+func isSimpleExpr(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return true
+	case *ast.SelectorExpr:
+		return isSimpleExpr(e.X)
+	case *ast.CallExpr:
+		return isIdent(e.Fun, "len") && len(e.Args) == 1 && isSimpleExpr(e.Args[0])
+	}
+	return false
+}
+
+// isSliceType reports whether an expression is a slice type
+func isSliceType(expr ast.Expr) bool {
+	array, ok := expr.(*ast.ArrayType)
+	return ok && array.Len == nil
+}
+
+// isIdent reports whether an expression is the identifier name
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// applyEdits returns src with the edits applied; edits must not overlap
+func applyEdits(src []byte, edits []edit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+
+	result := append([]byte(nil), src...)
+	for _, e := range edits {
+		result = append(result[:e.start], append([]byte(e.text), result[e.end:]...)...)
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// generatedHeaderPattern matches the standard marker of generated Go code, see https://go.dev/s/generatedcode
+var generatedHeaderPattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// maxHeaderLines is the number of lines read when looking for the generated code marker
+const maxHeaderLines = 20
+
+// matchesGeneratedPattern checks if a file matches one of the configured generated file patterns.
+// Patterns containing a slash are matched against the relative path, others against the file name.
+func matchesGeneratedPattern(relPath string, patterns []string) bool {
+	relPath = filepath.ToSlash(relPath)
+	name := filepath.Base(relPath)
+
+	for _, pattern := range patterns {
+		target := name
+		if strings.Contains(pattern, "/") {
+			target = relPath
+		}
+		if matched, _ := filepath.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// inspectHeader reads the first lines of a file and reports whether it carries the
+// generated code marker and whether it looks like a binary file
+func inspectHeader(path string) (generated, binary bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < maxHeaderLines && scanner.Scan(); i++ {
+		line := scanner.Bytes()
+		if bytes.IndexByte(line, 0) >= 0 {
+			return false, true, nil
+		}
+		if generatedHeaderPattern.Match(bytes.TrimRight(line, "\r")) {
+			return true, false, nil
+		}
+		// The marker must appear before the package clause
+		if bytes.HasPrefix(line, []byte("package ")) {
+			break
+		}
+	}
+
+	// Overlong lines are not Go source written by hand
+	if err := scanner.Err(); err == bufio.ErrTooLong {
+		return false, true, nil
+	}
+
+	return false, false, nil
+}
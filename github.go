@@ -0,0 +1,205 @@
+package prsummary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// DefaultGitHubAPIURL is the base URL of the public GitHub REST API
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// defaultGitHubTimeout bounds each request to the GitHub API
+const defaultGitHubTimeout = 30 * time.Second
+
+// githubFilesPerPage is the maximum page size of the pull request files endpoint
+const githubFilesPerPage = 100
+
+var (
+	// ErrPullRequestNotFound is returned when the pull request or repository does not exist
+	ErrPullRequestNotFound = errors.New("pull request not found")
+
+	// ErrRateLimited is returned when the GitHub API rate limit has been exceeded
+	ErrRateLimited = errors.New("GitHub API rate limit exceeded")
+)
+
+// githubPullRequest is the subset of the GitHub pull request resource used for summaries
+type githubPullRequest struct {
+	Additions    int `json:"additions"`
+	Deletions    int `json:"deletions"`
+	ChangedFiles int `json:"changed_files"`
+	Base         struct {
+		SHA string `json:"sha"`
+	} `json:"base"`
+	Head struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+// githubFile is a file changed by a pull request
+type githubFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"` // added, removed, modified, renamed, copied, changed, unchanged
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Changes   int    `json:"changes"`
+	Patch     string `json:"patch"` // Omitted by GitHub for binary and very large diffs
+}
+
+// SetHTTPClient sets the HTTP client used to call the GitHub API
+func (g *PRSummaryGenerator) SetHTTPClient(client *http.Client) {
+	g.httpClient = client
+}
+
+// SetGitHubAPIURL sets the base URL of the GitHub API, e.g. for GitHub Enterprise
+func (g *PRSummaryGenerator) SetGitHubAPIURL(url string) {
+	g.githubAPIURL = strings.TrimSuffix(url, "/")
+}
+
+// GenerateSummaryFromGitHub generates a summary of a pull request using the GitHub API
+// instead of a local checkout. If token is empty, the GITHUB_TOKEN environment variable is used.
+func (g *PRSummaryGenerator) GenerateSummaryFromGitHub(owner, repo string, prNumber int, token string) (*models.PRSummary, error) {
+	if owner == "" || repo == "" {
+		return nil, fmt.Errorf("owner and repository are required")
+	}
+	if prNumber <= 0 {
+		return nil, fmt.Errorf("invalid pull request number: %d", prNumber)
+	}
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+
+	// Get the pull request
+	var pr githubPullRequest
+	prPath := fmt.Sprintf("/repos/%s/%s/pulls/%d", owner, repo, prNumber)
+	if err := g.githubGet(prPath, token, &pr); err != nil {
+		return nil, fmt.Errorf("failed to get pull request %s/%s#%d: %w", owner, repo, prNumber, err)
+	}
+
+	if g.config.Verbose {
+		fmt.Fprintf(os.Stderr, "Summarizing %s/%s#%d (%s...%s)\n", owner, repo, prNumber, shortSHA(pr.Base.SHA), shortSHA(pr.Head.SHA))
+	}
+
+	// Get the changed files, one page at a time
+	var files []githubFile
+	for page := 1; ; page++ {
+		var pageFiles []githubFile
+		filesPath := fmt.Sprintf("%s/files?per_page=%d&page=%d", prPath, githubFilesPerPage, page)
+		if err := g.githubGet(filesPath, token, &pageFiles); err != nil {
+			return nil, fmt.Errorf("failed to get files of pull request %s/%s#%d: %w", owner, repo, prNumber, err)
+		}
+
+		files = append(files, pageFiles...)
+		if len(pageFiles) < githubFilesPerPage {
+			break
+		}
+	}
+
+	var changedFiles, newFiles, deletedFiles []string
+	var largeChanges []largeChange
+	var patches strings.Builder
+	for _, file := range files {
+		changedFiles = append(changedFiles, file.Filename)
+
+		switch file.Status {
+		case "added":
+			newFiles = append(newFiles, file.Filename)
+		case "removed":
+			deletedFiles = append(deletedFiles, file.Filename)
+		}
+
+		if g.config.LargeChangeLines > 0 && file.Changes > g.config.LargeChangeLines {
+			largeChanges = append(largeChanges, largeChange{
+				file:  file.Filename,
+				lines: file.Changes,
+			})
+		}
+
+		patches.WriteString(file.Patch)
+		patches.WriteString("\n")
+	}
+
+	summary := &models.PRSummary{
+		FilesChanged:  pr.ChangedFiles,
+		Additions:     pr.Additions,
+		Deletions:     pr.Deletions,
+		KeyChanges:    g.summarizeKeyChanges(changedFiles, newFiles, deletedFiles, largeChanges, parseInterfaceChanges(patches.String())),
+		AffectedAreas: g.analyzeAffectedAreas(changedFiles),
+	}
+
+	return summary, nil
+}
+
+// githubGet performs a GET request against the GitHub API and decodes the JSON response
+func (g *PRSummaryGenerator) githubGet(path, token string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, g.githubAPIURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return githubError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode GitHub API response: %w", err)
+	}
+
+	return nil
+}
+
+// githubError converts an unsuccessful GitHub API response into an error
+func githubError(resp *http.Response) error {
+	// GitHub explains most failures in a JSON body with a message field
+	var body struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	json.Unmarshal(data, &body)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrPullRequestNotFound
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return fmt.Errorf("%w (resets at %s)", ErrRateLimited, time.Unix(reset, 0).Format(time.RFC3339))
+		}
+		return ErrRateLimited
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("GitHub API authentication failed, check GITHUB_TOKEN: %s", body.Message)
+	}
+
+	if body.Message != "" {
+		return fmt.Errorf("GitHub API returned %s: %s", resp.Status, body.Message)
+	}
+	return fmt.Errorf("GitHub API returned %s", resp.Status)
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,49 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// annotationDataEscaper escapes the message of a GitHub Actions workflow command
+var annotationDataEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// annotationPropertyEscaper escapes the property values of a GitHub Actions workflow command
+var annotationPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// WriteGitHubAnnotations writes issues as GitHub Actions workflow commands, which show up as
+// inline annotations on pull requests. Critical and high issues become errors, medium and
+// low issues warnings.
+func WriteGitHubAnnotations(w io.Writer, issues []*models.Issue) error {
+	for _, issue := range issues {
+		command := "warning"
+		if models.SeverityScore(issue.Severity) >= models.SeverityScore("high") {
+			command = "error"
+		}
+
+		properties := []string{"file=" + annotationPropertyEscaper.Replace(issue.File)}
+		if issue.Line > 0 {
+			properties = append(properties, fmt.Sprintf("line=%d", issue.Line))
+		}
+		if issue.Column > 0 {
+			properties = append(properties, fmt.Sprintf("col=%d", issue.Column))
+		}
+		if issue.Rule != "" {
+			properties = append(properties, "title="+annotationPropertyEscaper.Replace(issue.Rule))
+		}
+
+		message := issue.Message
+		if issue.Suggestion != "" {
+			message += "\n" + issue.Suggestion
+		}
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(properties, ","), annotationDataEscaper.Replace(message)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
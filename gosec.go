@@ -1,11 +1,13 @@
 package security
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -43,18 +45,28 @@ type GosecResults struct {
 
 // GosecScanner is responsible for scanning code for security vulnerabilities using gosec
 type GosecScanner struct {
-	config *config.Config
+	config    *config.Config
+	allowlist []*regexp.Regexp // Compiled secret_allowlist, applied to G101 issues
 }
 
 // NewGosecScanner creates a new gosec scanner
 func NewGosecScanner(cfg *config.Config) *GosecScanner {
 	return &GosecScanner{
-		config: cfg,
+		config:    cfg,
+		allowlist: compileAllowlist(cfg.SecretAllowlist),
 	}
 }
 
-// Scan scans a repository for security vulnerabilities
-func (s *GosecScanner) Scan(repoPath string) ([]*models.Issue, error) {
+// Available reports whether the gosec binary is installed
+func (s *GosecScanner) Available() bool {
+	_, err := exec.LookPath("gosec")
+	return err == nil
+}
+
+// Scan scans a repository for security vulnerabilities. dirs restricts the scan to the
+// packages in these directories, relative to the repository root; without any, the whole
+// repository is scanned. Cancelling the context kills the gosec process.
+func (s *GosecScanner) Scan(ctx context.Context, repoPath string, dirs ...string) ([]*models.Issue, error) {
 	// Create a temporary file to store gosec results
 	tmpFile, err := os.CreateTemp("", "gosec-results-*.json")
 	if err != nil {
@@ -64,7 +76,14 @@ func (s *GosecScanner) Scan(repoPath string) ([]*models.Issue, error) {
 	tmpFile.Close()
 
 	// Build gosec command
-	cmd := exec.Command("gosec", "-fmt=json", "-out="+tmpFile.Name(), "-exclude-dir=vendor", "./...")
+	args := []string{"-fmt=json", "-out=" + tmpFile.Name(), "-exclude-dir=vendor"}
+	if len(dirs) == 0 {
+		args = append(args, "./...")
+	}
+	for _, dir := range dirs {
+		args = append(args, "./"+filepath.ToSlash(dir))
+	}
+	cmd := exec.CommandContext(ctx, "gosec", args...)
 	cmd.Dir = repoPath
 
 	// Run gosec
@@ -72,6 +91,9 @@ func (s *GosecScanner) Scan(repoPath string) ([]*models.Issue, error) {
 		fmt.Println("Running gosec...")
 	}
 	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		// gosec returns non-zero exit code when issues are found, so we need to check if the output file exists
 		if _, statErr := os.Stat(tmpFile.Name()); statErr != nil {
@@ -94,6 +116,10 @@ func (s *GosecScanner) Scan(repoPath string) ([]*models.Issue, error) {
 	// Convert gosec results to our model
 	issues := make([]*models.Issue, 0, len(gosecResults.Issues))
 	for _, result := range gosecResults.Issues {
+		if s.allowedCredential(result) {
+			continue
+		}
+
 		// Convert line and column to integers
 		line, _ := strconv.Atoi(result.Line)
 		column, _ := strconv.Atoi(result.Column)
@@ -117,7 +143,7 @@ func (s *GosecScanner) Scan(repoPath string) ([]*models.Issue, error) {
 			Severity:   severity,
 			Confidence: strings.ToLower(result.Confidence),
 			Rule:       result.Rule,
-			Code:       result.Code,
+			Code:       stripLineNumbers(result.Code),
 			Suggestion: getSuggestionForRule(result.Rule, result.CWE.Description),
 		}
 
@@ -131,6 +157,56 @@ func (s *GosecScanner) Scan(repoPath string) ([]*models.Issue, error) {
 	return issues, nil
 }
 
+// stringLiteral matches the interpreted and raw string literals of a line of Go code
+var stringLiteral = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`")
+
+// allowedCredential reports whether a G101 result of gosec only hardcodes values of the
+// secret allowlist, taken from the string literals on the reported line of its code snippet
+func (s *GosecScanner) allowedCredential(result GosecResult) bool {
+	if result.Rule != "G101" || len(s.allowlist) == 0 {
+		return false
+	}
+	prefix := result.Line + ": "
+	for _, line := range strings.Split(result.Code, "\n") {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		literals := stringLiteral.FindAllString(line[len(prefix):], -1)
+		for _, literal := range literals {
+			value, err := strconv.Unquote(literal)
+			if err != nil || !allowedSecret(s.allowlist, value) {
+				return false
+			}
+		}
+		return len(literals) > 0
+	}
+	return false
+}
+
+// gosecCustomRules maps gosec rules to the custom security rules that detect the same problems
+var gosecCustomRules = map[string]string{
+	"G101": "CS001", // Hardcoded credentials
+	"G404": "CS002", // Insecure random number source
+	"G403": "CS005", // RSA keys shorter than 2048 bits
+	"G201": "CS008", // SQL query built with a format string
+	"G202": "CS008", // SQL query built by concatenation
+}
+
+// CustomRuleFor returns the ID of the custom security rule that detects the same problem as
+// a gosec rule, if there is one
+func CustomRuleFor(gosecRule string) (string, bool) {
+	rule, ok := gosecCustomRules[gosecRule]
+	return rule, ok
+}
+
+// gosecLinePrefix matches the line number gosec puts in front of each line of a code snippet
+var gosecLinePrefix = regexp.MustCompile(`(?m)^\d+: `)
+
+// stripLineNumbers removes the line numbers from a gosec code snippet
+func stripLineNumbers(code string) string {
+	return strings.TrimSpace(gosecLinePrefix.ReplaceAllString(code, ""))
+}
+
 // mapGosecSeverity maps gosec severity to our severity levels
 func mapGosecSeverity(severity string) string {
 	switch severity {
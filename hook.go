@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookScript is the pre-commit hook written by InstallHook, formatted with the quoted path
+// of the executable and the -fail-on severity. Git runs hooks at the root of the working
+// tree, where the staged paths are relative to.
+const hookScript = `#!/bin/sh
+# Installed by code-review-assistant -install-hook: analyzes the staged Go files and
+# rejects the commit when issues of %[2]s severity or above are found.
+# Run git commit --no-verify to commit anyway.
+git diff --cached --name-only --diff-filter=ACMR -z -- '*.go' |
+	xargs -0 -r %[1]s -analyze -quiet -fail-on %[2]s --
+`
+
+// InstallHook writes a git pre-commit hook into the repository at repoPath that runs binary
+// on the staged Go files and fails the commit when issues at or above the failOn severity
+// are found. It returns the path of the hook. An existing hook is only replaced when
+// overwrite is set; otherwise an error wrapping os.ErrExist is returned.
+func InstallHook(repoPath, binary, failOn string, overwrite bool) (string, error) {
+	// Ask git for the hooks directory, which core.hooksPath and worktrees can move
+	output, err := exec.Command("git", "-C", repoPath, "rev-parse", "--git-path", "hooks/pre-commit").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not a git repository: %w", repoPath, err)
+	}
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoPath, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(path, flags, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create hook: %w", err)
+	}
+	if _, err := fmt.Fprintf(file, hookScript, shellQuote(binary), failOn); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write hook: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write hook: %w", err)
+	}
+
+	// The mode given to OpenFile does not apply to a replaced hook
+	if err := os.Chmod(path, 0755); err != nil {
+		return "", fmt.Errorf("failed to make hook executable: %w", err)
+	}
+	return path, nil
+}
+
+// shellQuote quotes a string as a single word for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
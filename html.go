@@ -0,0 +1,185 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"sort"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// levelMeaning explains a severity or confidence level in the report legends
+type levelMeaning struct {
+	Level   string
+	Meaning string
+}
+
+// severityLegend explains the severities, most severe first
+var severityLegend = []levelMeaning{
+	{"critical", "Security vulnerability or bug that is likely to cause failures, fix before merging"},
+	{"high", "Probable bug, such as an unhandled error or a leaked resource, fix soon"},
+	{"medium", "Maintainability or performance problem worth addressing"},
+	{"low", "Style issue or minor improvement"},
+}
+
+// confidenceLegend explains the confidence levels, most confident first
+var confidenceLegend = []levelMeaning{
+	{"high", "Found with type information or an unambiguous pattern, rarely a false positive"},
+	{"medium", "Found by a heuristic that is usually right, check the surrounding code"},
+	{"low", "Found by a heuristic that may well be a false positive"},
+}
+
+// issueCount is the number of issues of a category or severity
+type issueCount struct {
+	Name    string
+	Issues  int
+	Percent int // Issues relative to the largest category, for the length of its bar
+}
+
+// countCategories returns the categories that have issues, most issues first
+func countCategories(issues []*models.Issue) []issueCount {
+	counts := make(map[string]int)
+	for _, issue := range issues {
+		counts[issue.Category]++
+	}
+
+	categories := make([]issueCount, 0, len(counts))
+	largest := 0
+	for category, n := range counts {
+		categories = append(categories, issueCount{Name: category, Issues: n})
+		if n > largest {
+			largest = n
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Issues != categories[j].Issues {
+			return categories[i].Issues > categories[j].Issues
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	for i := range categories {
+		categories[i].Percent = categories[i].Issues * 100 / largest
+	}
+	return categories
+}
+
+// htmlReport holds the data of the HTML report template
+type htmlReport struct {
+	SummaryOnly      bool // Only the counts and the chart, without issues and legend
+	Total            int
+	Severities       []issueCount
+	Categories       []issueCount
+	Issues           []*models.Issue
+	SeverityLegend   []levelMeaning
+	ConfidenceLegend []levelMeaning
+}
+
+// htmlTemplate renders the HTML report. The bar chart only uses CSS, so the report has no
+// scripts and can be viewed offline.
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Code Review</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+.bar { background: #4a78c2; height: 1em; min-width: 2px; }
+.chart td { border: none; }
+.chart td.track { width: 20em; }
+.critical { color: #8b0000; font-weight: bold; }
+.high { color: #c0392b; }
+.medium { color: #b7791f; }
+.low { color: #2c7a7b; }
+</style>
+</head>
+<body>
+<h1>Code Review</h1>
+{{if eq .Total 0 -}}
+<p>No issues found.</p>
+{{- else -}}
+<p><strong>{{.Total}} {{if eq .Total 1}}issue{{else}}issues{{end}}</strong>:
+{{- range $i, $s := .Severities}}{{if $i}},{{end}} <span class="{{$s.Name}}">{{$s.Issues}} {{$s.Name}}</span>{{end}}</p>
+
+<h2>Issues by category</h2>
+<table class="chart">
+{{- range .Categories}}
+<tr><td>{{.Name}}</td><td class="track"><div class="bar" style="width: {{.Percent}}%"></div></td><td>{{.Issues}}</td></tr>
+{{- end}}
+</table>
+{{- if not .SummaryOnly}}
+
+<h2>Issues</h2>
+<table>
+<tr><th>Severity</th><th>Confidence</th><th>Location</th><th>Rule</th><th>Message</th><th>Suggestion</th></tr>
+{{- range .Issues}}
+<tr><td class="{{.Severity}}">{{.Severity}}</td><td>{{.Confidence}}</td><td>{{.File}}{{if gt .Line 0}}:{{.Line}}{{end}}</td><td>{{.Rule}}</td><td>{{.Message}}</td><td>{{.Suggestion}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- if not .SummaryOnly}}
+
+<h2>Legend</h2>
+<table>
+<tr><th>Severity</th><th>Meaning</th></tr>
+{{- range .SeverityLegend}}
+<tr><td class="{{.Level}}">{{.Level}}</td><td>{{.Meaning}}</td></tr>
+{{- end}}
+</table>
+<table>
+<tr><th>Confidence</th><th>Meaning</th></tr>
+{{- range .ConfidenceLegend}}
+<tr><td>{{.Level}}</td><td>{{.Meaning}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes issues as a standalone HTML report: the counts by severity, a bar chart
+// of the issues by category, a table of the issues sorted by severity and location, and a
+// legend of the severity and confidence levels. Categories without issues are left out of
+// the chart.
+func WriteHTML(w io.Writer, issues []*models.Issue) error {
+	return writeHTML(w, issues, false)
+}
+
+// WriteHTMLSummary writes only the counts of issues as a standalone HTML page: the counts
+// by severity and the bar chart of the issues by category of WriteHTML
+func WriteHTMLSummary(w io.Writer, issues []*models.Issue) error {
+	return writeHTML(w, issues, true)
+}
+
+// writeHTML writes the HTML report of issues, leaving out the issues and the legend if
+// summaryOnly is set
+func writeHTML(w io.Writer, issues []*models.Issue, summaryOnly bool) error {
+	sorted := make([]*models.Issue, len(issues))
+	copy(sorted, issues)
+	sortIssuesByLocation(sorted)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return models.SeverityScore(sorted[i].Severity) > models.SeverityScore(sorted[j].Severity)
+	})
+
+	data := &htmlReport{
+		SummaryOnly:      summaryOnly,
+		Total:            len(issues),
+		Categories:       countCategories(issues),
+		Issues:           sorted,
+		SeverityLegend:   severityLegend,
+		ConfidenceLegend: confidenceLegend,
+	}
+	for _, severity := range markdownSeverities {
+		n := 0
+		for _, issue := range issues {
+			if issue.Severity == severity {
+				n++
+			}
+		}
+		data.Severities = append(data.Severities, issueCount{Name: severity, Issues: n})
+	}
+
+	return htmlTemplate.Execute(w, data)
+}
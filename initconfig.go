@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keyDocs documents the configuration keys in the files written by InitConfig
+var keyDocs = map[string]string{
+	"verbose":                  "Print progress and errors of individual files (true or false)",
+	"include_tests":            "Analyze _test.go files and files below test_dirs (true or false)",
+	"exclude_dirs":             "Directory names skipped at any depth",
+	"exclude_files":            "File names or glob patterns of files to skip",
+	"max_file_size":            "Size in bytes above which files are skipped",
+	"skip_generated":           "Skip files matching generated_patterns or marked \"Code generated ... DO NOT EDIT.\" (true or false)",
+	"generated_patterns":       "Glob patterns of generated files",
+	"concurrency":              "Maximum number of files analyzed in parallel, 0 for the number of CPUs",
+	"test_dirs":                "Directories holding test code besides _test.go files",
+	"enabled_analyzers":        "Categories to run: all, code-smell, anti-pattern, best-practice, documentation, performance, security",
+	"disabled_analyzers":       "Categories not to run, taking precedence over enabled_analyzers",
+	"disabled_rules":           "Rule IDs or names not to run, see -list-rules",
+	"relax_in_tests":           "Rule IDs or names not reported in test code",
+	"security_severity":        "Minimum severity of security issues: critical, high, medium, or low",
+	"enable_gosec":             "Run gosec when it is installed (true or false)",
+	"dedupe_security":          "Report a problem found by both gosec and a built-in security rule once (true or false)",
+	"secret_min_entropy":       "Entropy in bits per character below which hardcoded secrets are reported with low confidence as likely placeholders, 0 disables the check",
+	"secret_allowlist":         "Regular expressions of values not reported as hardcoded secrets, such as placeholders of test fixtures, also applied to gosec G101",
+	"scan_non_go_secrets":      "Scan .env files, YAML, Dockerfiles, and other files that are not Go source for hardcoded secrets (true or false)",
+	"secret_scan_extensions":   "Extensions, such as .env, and file names, such as Dockerfile, of the files scan_non_go_secrets scans",
+	"banned_imports":           "Import paths reported by banned-import, including the packages below them, mapped to the reason given as suggestion",
+	"sentinel_error_allowlist": "Sentinel errors, such as io.EOF, that error-comparison allows to compare with ==",
+	"pattern_severity":         "Minimum severity of pattern issues: critical, high, medium, or low",
+	"max_complexity":           "Cyclomatic complexity above which a function is reported",
+	"max_struct_size":          "Size in bytes above which structs passed by value are reported by OPT009, 0 disables the rule",
+	"max_params":               "Number of parameters above which too-many-params reports a function, 0 disables the rule",
+	"max_function_lines":       "Number of lines above which long-function reports a function, 0 disables the rule",
+	"max_interface_methods":    "Number of methods above which large-interface reports an interface, 0 disables the rule",
+	"max_init_lines":           "Number of lines above which init-misuse reports an init function, 0 disables the rule",
+	"max_map_keys":             "Number of distinct string keys above which map-as-struct reports a map[string]interface{}, 0 disables the rule",
+	"large_change_lines":       "Number of changed lines above which PR summaries list a file as a large change, 0 lists none",
+	"enable_learning":          "Adjust the confidence of issues from recorded feedback (true or false)",
+	"model_path":               "Directory of the machine learning data",
+	"storage_backend":          "Storage of the machine learning data: json or sqlite",
+	"cache_path":               "Directory of the analysis cache, disabled when empty",
+	"custom_rules_path":        "Path to custom rules",
+	"rule_severities":          "Severity overrides keyed by rule ID: critical, high, medium, or low",
+	"only_categories":          "Report only issues of these categories, all when empty",
+	"only_severities":          "Report only issues of these severities, all when empty",
+	"only_rules":               "Report only issues of these rule IDs, all when empty",
+	"min_confidence":           "Minimum confidence of the reported issues: high, medium, or low",
+	"markdown_max_size":        "Maximum size in bytes of the markdown report, 0 for no limit",
+	"server_token":             "Token that clients of -serve must send, REVIEW_SERVER_TOKEN when empty",
+}
+
+// InitConfig writes the default configuration to configPath, in the format given by its
+// extension as with SaveConfig, with a comment documenting every key. An existing file
+// is only replaced when overwrite is set; otherwise an error wrapping os.ErrExist is
+// returned.
+func InitConfig(configPath string, overwrite bool) error {
+	data, err := marshalConfig(DefaultConfig(), configPath)
+	if err != nil {
+		return err
+	}
+
+	// JSON has no comments of its own, LoadConfig skips lines starting with //
+	comment := "# "
+	indent := ""
+	if !isYAMLOrTOML(configPath) {
+		comment = "// "
+		indent = "  "
+	}
+
+	var b bytes.Buffer
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if doc, ok := keyDocs[topLevelKey(line, indent)]; ok {
+			b.WriteString(indent + comment + doc + "\n")
+		}
+		b.WriteString(line)
+	}
+	if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteString("\n")
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(configPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create config file: %w", err)
+	}
+	if _, err := file.Write(b.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return file.Close()
+}
+
+// topLevelKey returns the key set by a line of a marshaled configuration when the line is
+// at the top level, which is indented by indent, or an empty string
+func topLevelKey(line, indent string) string {
+	if !strings.HasPrefix(line, indent) {
+		return ""
+	}
+	line = line[len(indent):]
+	if line == "" || line[0] == ' ' {
+		return ""
+	}
+
+	// Keys end with a colon in JSON and YAML, and with an equal sign in TOML. TOML tables
+	// such as [rule_severities] are documented too.
+	end := strings.IndexAny(line, ":=")
+	if end < 0 {
+		return strings.Trim(strings.TrimSpace(line), "[]")
+	}
+	return strings.Trim(strings.TrimSpace(line[:end]), `"`)
+}
+
+// isYAMLOrTOML reports whether a configuration file is parsed as YAML or TOML rather than JSON
+func isYAMLOrTOML(configPath string) bool {
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
@@ -1,17 +1,36 @@
 package integration
 
 import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/user/code-review-assistant/internal/analyzer/patterns"
+	"github.com/user/code-review-assistant/internal/config"
+	"github.com/user/code-review-assistant/internal/optimization"
+	"github.com/user/code-review-assistant/internal/prsummary"
+	"github.com/user/code-review-assistant/internal/security"
 )
 
 // TestIntegration runs integration tests for the code review assistant
 func TestIntegration(t *testing.T) {
 	// Create test repository
 	testDir := filepath.Join("testdata", "integration")
+	// Start from a clean repository, a previous run leaves its commits and branches behind
+	if err := os.RemoveAll(testDir); err != nil {
+		t.Fatalf("Error cleaning test directory: %v", err)
+	}
 	if err := os.MkdirAll(testDir, 0755); err != nil {
 		t.Fatalf("Error creating test directory: %v", err)
 	}
@@ -35,6 +54,50 @@ func TestIntegration(t *testing.T) {
 	t.Run("FullAnalysis", func(t *testing.T) { testFullAnalysis(t, testDir) })
 	t.Run("PRSummary", func(t *testing.T) { testPRSummary(t, testDir) })
 	t.Run("Optimization", func(t *testing.T) { testOptimization(t, testDir) })
+	t.Run("FailOn", testFailOn)
+	t.Run("QuietOutput", testQuietOutput)
+	t.Run("Explain", testExplain)
+	t.Run("ListRules", testListRules)
+	t.Run("Stdin", testStdin)
+	t.Run("OutputFile", testOutputFile)
+	t.Run("MultipleRepos", testMultipleRepos)
+	t.Run("JSONLines", testJSONLines)
+	t.Run("Timing", testTiming)
+	t.Run("SummaryOnly", testSummaryOnly)
+	t.Run("NoColorWhenPiped", testNoColorWhenPiped)
+	t.Run("Merge", testMerge)
+	t.Run("InstallHook", testInstallHook)
+	t.Run("AnalyzeOptimize", testAnalyzeOptimize)
+	t.Run("AbsPaths", testAbsPaths)
+	t.Run("ChangedSince", testChangedSince)
+	t.Run("DiffOnly", testDiffOnly)
+}
+
+// buildBinary builds the code review assistant executable into a temporary directory
+func buildBinary(t *testing.T) string {
+	t.Helper()
+
+	binary := filepath.Join(t.TempDir(), "code-review-assistant")
+	cmd := exec.Command("go", "build", "-o", binary, "github.com/user/code-review-assistant/cmd/code-review-assistant")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build executable: %v\n%s", err, output)
+	}
+
+	return binary
+}
+
+// exitCode returns the exit code of a finished command
+func exitCode(t *testing.T, err error) int {
+	t.Helper()
+
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Failed to run executable: %v", err)
+	}
+	return exitErr.ExitCode()
 }
 
 // initGitRepo initializes a Git repository
@@ -238,8 +301,56 @@ func ExportedFunction() {
 		t.Fatalf("Failed to commit changes: %v", err)
 	}
 
-	// TODO: Run the PR summary generator on the test repository
-	// This would require building and running the executable
+	// Run the PR summary generator on the test repository
+	generator := prsummary.NewPRSummaryGenerator(config.DefaultConfig())
+	summary, err := generator.GenerateSummary(dir, "HEAD~1", "HEAD")
+	if err != nil {
+		t.Fatalf("Failed to generate PR summary: %v", err)
+	}
+
+	// The new unexported function uses mixed case and is part of the diff
+	found := false
+	for _, issue := range summary.PotentialIssues {
+		if issue.File != "main.go" {
+			t.Errorf("Expected potential issues only in changed files, got %s:%d", issue.File, issue.Line)
+		}
+		if issue.Rule == "function-naming" && issue.Line == 31 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a function-naming issue on main.go:31, got %d potential issues", len(summary.PotentialIssues))
+	}
+
+	// Uncommitted edits moving the lines of the working tree do not change the issues of HEAD
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("// Package main is a test program\n\n"+modifiedFile), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	defer func() {
+		cmd := exec.Command("git", "checkout", "main.go")
+		cmd.Dir = dir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("Failed to restore file: %v", err)
+		}
+	}()
+	summary, err = generator.GenerateSummary(dir, "HEAD~1", "HEAD")
+	if err != nil {
+		t.Fatalf("Failed to generate PR summary: %v", err)
+	}
+	found = false
+	for _, issue := range summary.PotentialIssues {
+		if issue.Rule == "function-naming" && issue.Line == 31 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected the function-naming issue to stay on main.go:31 of HEAD despite uncommitted edits")
+	}
+
+	// A diff that cannot be read fails the summary rather than reporting unfiltered issues
+	if _, err := generator.GenerateSummary(dir, "HEAD~1", "no-such-ref"); err == nil {
+		t.Error("Expected an unknown reference to fail the PR summary")
+	}
 
 	t.Log("PR summary tests completed")
 }
@@ -254,9 +365,986 @@ func testOptimization(t *testing.T, dir string) {
 	t.Log("Optimization tests completed")
 }
 
+// testFailOn tests the exit code of the -fail-on severity threshold
+func testFailOn(t *testing.T) {
+	binary := buildBinary(t)
+
+	// Create a repository with a known critical issue (hardcoded secret)
+	repoDir := t.TempDir()
+	source := `package main
+
+import "fmt"
+
+func main() {
+	dsn := "password='hunter2secret' sslmode=disable"
+	fmt.Println(dsn)
+}
+`
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		failOn   string
+		wantCode int
+	}{
+		{"none", 0},
+		{"critical", 1},
+		{"low", 1},
+	}
+
+	for _, tt := range tests {
+		cmd := exec.Command(binary, "-analyze", "-repo", repoDir, "-format", "json", "-fail-on", tt.failOn)
+		output, err := cmd.Output()
+
+		if code := exitCode(t, err); code != tt.wantCode {
+			t.Errorf("-fail-on %s: expected exit code %d, got %d", tt.failOn, tt.wantCode, code)
+		}
+		if !json.Valid(output) {
+			t.Errorf("-fail-on %s: expected valid JSON output, got %s", tt.failOn, output)
+		}
+	}
+}
+
+func testQuietOutput(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	source := `package main
+
+import "fmt"
+
+func main() {
+	dsn := "password='hunter2secret' sslmode=disable"
+	fmt.Println(dsn)
+}
+`
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	output, err := exec.Command(binary, "-analyze", "-repo", repoDir, "-quiet").Output()
+	if err != nil {
+		t.Fatalf("-quiet failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		t.Fatalf("-quiet: expected issues, got no output")
+	}
+	line := regexp.MustCompile(`^(critical|high|medium|low|info) \S+\.go:\d+ \S+ .+$`)
+	for _, l := range lines {
+		if !line.MatchString(l) {
+			t.Errorf("-quiet: unexpected line %q", l)
+		}
+	}
+	if !strings.Contains(string(output), "critical main.go:6 CS001 ") {
+		t.Errorf("-quiet: expected the hardcoded credentials issue, got:\n%s", output)
+	}
+
+	output, err = exec.Command(binary, "-analyze", "-repo", repoDir, "-no-suggestions").Output()
+	if err != nil {
+		t.Fatalf("-no-suggestions failed: %v", err)
+	}
+	if !strings.Contains(string(output), "File: main.go:6") {
+		t.Errorf("-no-suggestions: expected the grouped format, got:\n%s", output)
+	}
+	if strings.Contains(string(output), "Suggestion:") {
+		t.Errorf("-no-suggestions: expected no suggestions, got:\n%s", output)
+	}
+}
+
+func testOutputFile(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	source := `package main
+
+import "fmt"
+
+func main() {
+	dsn := "password='hunter2secret' sslmode=disable"
+	fmt.Println(dsn)
+}
+`
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	outDir := t.TempDir()
+	outPath := filepath.Join(outDir, "report.json")
+	if err := os.WriteFile(outPath, []byte("stale report"), 0644); err != nil {
+		t.Fatalf("Failed to create stale report: %v", err)
+	}
+	output, err := exec.Command(binary, "-analyze", "-repo", repoDir, "-format", "json", "-output", outPath).Output()
+	if err != nil {
+		t.Fatalf("-output failed: %v", err)
+	}
+	if len(output) != 0 {
+		t.Errorf("-output: expected nothing on stdout, got:\n%s", output)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("-output: failed to read report: %v", err)
+	}
+	var results struct {
+		Issues []struct {
+			File, Rule string
+			Line       int
+		}
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		t.Fatalf("-output: invalid JSON report: %v\n%s", err, data)
+	}
+	found := false
+	for _, issue := range results.Issues {
+		found = found || (issue.Rule == "CS001" && issue.File == "main.go" && issue.Line == 6)
+	}
+	if !found {
+		t.Errorf("-output: expected the hardcoded credentials issue, got %+v", results.Issues)
+	}
+
+	// No temporary file is left next to the report
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatalf("-output: failed to list %s: %v", outDir, err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("-output: expected only the report in %s, got %d files", outDir, len(entries))
+	}
+}
+
+func testExplain(t *testing.T) {
+	binary := buildBinary(t)
+
+	output, err := exec.Command(binary, "-explain", "OPT001").Output()
+	if err != nil {
+		t.Fatalf("-explain OPT001 failed: %v", err)
+	}
+	for _, want := range []string{"OPT001 (inefficient-string-concat)", "Category: performance", "var builder strings.Builder", "builder.WriteString(s)"} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("-explain OPT001: expected %q in output:\n%s", want, output)
+		}
+	}
+
+	// Rules are found by name too, ignoring case
+	output, err = exec.Command(binary, "-explain", "Insecure-Cookie").Output()
+	if err != nil {
+		t.Fatalf("-explain Insecure-Cookie failed: %v", err)
+	}
+	for _, want := range []string{"CS004 (insecure-cookie)", "Default severity: high", "Suggestion: Set both Secure and HttpOnly"} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("-explain Insecure-Cookie: expected %q in output:\n%s", want, output)
+		}
+	}
+
+	_, err = exec.Command(binary, "-explain", "NOPE001").Output()
+	if code := exitCode(t, err); code != 1 {
+		t.Errorf("-explain of an unknown rule: expected exit code 1, got %d", code)
+	}
+}
+
+func testListRules(t *testing.T) {
+	binary := buildBinary(t)
+
+	output, err := exec.Command(binary, "-list-rules", "-format", "json").Output()
+	if err != nil {
+		t.Fatalf("-list-rules failed: %v", err)
+	}
+	var rules []struct {
+		ID, Name, Category, Severity, Description string
+	}
+	if err := json.Unmarshal(output, &rules); err != nil {
+		t.Fatalf("-list-rules: invalid JSON output: %v", err)
+	}
+
+	// Every registry is listed, plus the cyclomatic complexity check
+	cfg := config.DefaultConfig()
+	want := len(patterns.GetGoPatterns(cfg)) + len(patterns.GetGoAntiPatterns(cfg)) + len(patterns.GetGoBestPractices(cfg)) +
+		len(security.GetCustomSecurityRules(cfg)) + len(optimization.GetOptimizationRules(cfg)) + 1
+	if len(rules) != want {
+		t.Errorf("-list-rules: expected %d rules, got %d", want, len(rules))
+	}
+	for _, rule := range rules {
+		if rule.ID == "" || rule.Category == "" || rule.Description == "" {
+			t.Errorf("-list-rules: incomplete rule %+v", rule)
+		}
+	}
+
+	// The text table has a header and a line per rule
+	output, err = exec.Command(binary, "-list-rules").Output()
+	if err != nil {
+		t.Fatalf("-list-rules failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != want+1 || !strings.HasPrefix(lines[0], "RULE") {
+		t.Errorf("-list-rules: expected a header and %d rules, got:\n%s", want, output)
+	}
+}
+
+func testStdin(t *testing.T) {
+	binary := buildBinary(t)
+
+	// The source only exists in the editor buffer piped to the tool
+	source := "package buffer\n\n// Toggle sets the state\nfunc Toggle(on bool) {\n\t_ = on\n}\n"
+	cmd := exec.Command(binary, "-analyze", "-repo", t.TempDir(), "-stdin-filename", "editor/buffer.go", "-format", "json")
+	cmd.Stdin = strings.NewReader(source)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("-stdin-filename failed: %v", err)
+	}
+
+	var results struct {
+		Files  int
+		Issues []struct {
+			File string
+			Line int
+			Rule string
+		}
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		t.Fatalf("-stdin-filename: invalid JSON output: %v", err)
+	}
+	if results.Files != 1 {
+		t.Errorf("-stdin-filename: expected 1 file analyzed, got %d", results.Files)
+	}
+	found := false
+	for _, issue := range results.Issues {
+		if issue.File != "editor/buffer.go" {
+			t.Errorf("-stdin-filename: expected issues in editor/buffer.go, got %s", issue.File)
+		}
+		if issue.Rule == "boolean-param" && issue.Line == 4 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("-stdin-filename: expected a boolean-param issue at editor/buffer.go:4, got:\n%s", output)
+	}
+}
+
 func main() {
 	// Run the tests
 	testing.Main(func(pat, str string) (bool, error) { return true, nil }, []testing.InternalTest{
 		{Name: "TestIntegration", F: TestIntegration},
 	}, nil, nil)
 }
+
+// testMultipleRepos tests analyzing several repositories given to -repo in one report
+func testMultipleRepos(t *testing.T) {
+	binary := buildBinary(t)
+
+	parent := t.TempDir()
+	source := `package main
+
+import "fmt"
+
+func main() {
+	dsn := "password='hunter2secret' sslmode=disable"
+	fmt.Println(dsn)
+}
+`
+	for _, name := range []string{"api", "web"} {
+		if err := os.Mkdir(filepath.Join(parent, name), 0755); err != nil {
+			t.Fatalf("Failed to create repository: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(parent, name, "main.go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	repos := filepath.Join(parent, "api") + "," + filepath.Join(parent, "web")
+
+	output, err := exec.Command(binary, "-analyze", "-repo", repos, "-format", "json").Output()
+	if err != nil {
+		t.Fatalf("Several repositories failed: %v", err)
+	}
+	var results struct {
+		Issues []struct {
+			File, Rule string
+		}
+		Files        int
+		TotalIssues  int
+		Repositories []struct {
+			Name               string
+			Files, TotalIssues int
+		}
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		t.Fatalf("Several repositories: invalid JSON: %v\n%s", err, output)
+	}
+	for _, file := range []string{"api/main.go", "web/main.go"} {
+		found := false
+		for _, issue := range results.Issues {
+			found = found || (issue.Rule == "CS001" && issue.File == file)
+		}
+		if !found {
+			t.Errorf("Several repositories: expected the hardcoded credentials issue in %s, got %+v", file, results.Issues)
+		}
+	}
+	if results.Files != 2 || len(results.Repositories) != 2 {
+		t.Fatalf("Several repositories: expected 2 files in 2 repositories, got %d files in %+v", results.Files, results.Repositories)
+	}
+	total := 0
+	for i, name := range []string{"api", "web"} {
+		repo := results.Repositories[i]
+		if repo.Name != name || repo.Files != 1 || repo.TotalIssues == 0 {
+			t.Errorf("Several repositories: unexpected summary %+v for %s", repo, name)
+		}
+		total += repo.TotalIssues
+	}
+	if total != results.TotalIssues {
+		t.Errorf("Several repositories: repository totals add up to %d, expected %d", total, results.TotalIssues)
+	}
+
+	// The text output breaks the totals down by repository
+	output, _ = exec.Command(binary, "-analyze", "-repo", repos).Output()
+	for _, name := range []string{"api", "web"} {
+		if !strings.Contains(string(output), name+": ") {
+			t.Errorf("Several repositories: expected a summary line for %s, got:\n%s", name, output)
+		}
+	}
+}
+
+// testJSONLines tests the jsonl format, which writes every issue and the summary on a line
+// of its own
+func testJSONLines(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	source := `package main
+
+import "fmt"
+
+func show(verbose bool) {
+	dsn := "password='hunter2secret' sslmode=disable"
+	fmt.Println(dsn, verbose)
+}
+`
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	output, err := exec.Command(binary, "-analyze", "-repo", repoDir, "-format", "jsonl").Output()
+	if err != nil {
+		t.Fatalf("jsonl format failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	if len(lines) < 3 {
+		t.Fatalf("jsonl: expected several issues and a summary, got:\n%s", output)
+	}
+
+	issues := 0
+	for i, line := range lines {
+		var object struct {
+			Type        string `json:"type"`
+			File, Rule  string
+			TotalIssues int
+		}
+		if err := json.Unmarshal([]byte(line), &object); err != nil {
+			t.Fatalf("jsonl: line %d is not valid JSON: %v\n%s", i+1, err, line)
+		}
+		if i < len(lines)-1 {
+			if object.Type != "issue" || object.File != "main.go" || object.Rule == "" {
+				t.Errorf("jsonl: expected an issue on line %d, got %s", i+1, line)
+			}
+			issues++
+			continue
+		}
+		if object.Type != "summary" || object.TotalIssues != issues {
+			t.Errorf("jsonl: expected a summary of %d issues on the last line, got %s", issues, line)
+		}
+	}
+}
+
+// testSummaryOnly tests that -summary-only prints only the counts of the filtered issues
+func testSummaryOnly(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	source := `package main
+
+import "fmt"
+
+func show(verbose bool) {
+	dsn := "password='hunter2secret' sslmode=disable"
+	fmt.Println(dsn, verbose)
+}
+`
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	type counts struct {
+		Issues                                                           []json.RawMessage
+		TotalIssues, CriticalIssues, HighIssues, MediumIssues, LowIssues int
+	}
+	summary := func(args ...string) counts {
+		t.Helper()
+		output, err := exec.Command(binary, append([]string{"-analyze", "-repo", repoDir, "-format", "json"}, args...)...).Output()
+		if err != nil {
+			t.Fatalf("json format failed: %v", err)
+		}
+		var c counts
+		if err := json.Unmarshal(output, &c); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+		}
+		return c
+	}
+
+	full := summary()
+	if full.TotalIssues < 2 || full.CriticalIssues == 0 {
+		t.Fatalf("Expected a critical issue and others, got %+v", full)
+	}
+	only := summary("-summary-only")
+	if only.Issues != nil || only.TotalIssues != full.TotalIssues || only.CriticalIssues != full.CriticalIssues || only.LowIssues != full.LowIssues {
+		t.Errorf("Expected the counts of %+v without issues, got %+v", full, only)
+	}
+	filtered := summary("-summary-only", "-only-severity", "critical")
+	if filtered.TotalIssues != full.CriticalIssues || filtered.LowIssues != 0 {
+		t.Errorf("Expected only the %d critical issues to be counted, got %+v", full.CriticalIssues, filtered)
+	}
+
+	output, err := exec.Command(binary, "-analyze", "-repo", repoDir, "-summary-only").Output()
+	if err != nil {
+		t.Fatalf("text format failed: %v", err)
+	}
+	want := fmt.Sprintf("Total issues: %d (Critical: %d, High: %d, Medium: %d, Low: %d)\n", full.TotalIssues, full.CriticalIssues, full.HighIssues, full.MediumIssues, full.LowIssues)
+	if string(output) != want {
+		t.Errorf("Expected only the totals line %q, got:\n%s", want, output)
+	}
+
+	output, err = exec.Command(binary, "-analyze", "-repo", repoDir, "-summary-only", "-format", "junit").Output()
+	if err != nil {
+		t.Fatalf("junit format failed: %v", err)
+	}
+	var suites struct {
+		Suites []struct {
+			Name       string `xml:"name,attr"`
+			Properties []struct {
+				Name  string `xml:"name,attr"`
+				Value string `xml:"value,attr"`
+			} `xml:"properties>property"`
+			TestCases []struct{} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(output, &suites); err != nil {
+		t.Fatalf("Output is not valid XML: %v\n%s", err, output)
+	}
+	if len(suites.Suites) != 1 || len(suites.Suites[0].TestCases) != 0 {
+		t.Fatalf("Expected a single suite without test cases, got:\n%s", output)
+	}
+	properties := make(map[string]string)
+	for _, p := range suites.Suites[0].Properties {
+		properties[p.Name] = p.Value
+	}
+	if properties["total"] != strconv.Itoa(full.TotalIssues) || properties["critical"] != strconv.Itoa(full.CriticalIssues) || properties["low"] != strconv.Itoa(full.LowIssues) {
+		t.Errorf("Expected the counts of %+v as properties, got %v", full, properties)
+	}
+
+	output, err = exec.Command(binary, "-analyze", "-repo", repoDir, "-summary-only", "-format", "html").Output()
+	if err != nil {
+		t.Fatalf("html format failed: %v", err)
+	}
+	page := string(output)
+	if !strings.Contains(page, fmt.Sprintf("%d issues", full.TotalIssues)) || !strings.Contains(page, "Issues by category") {
+		t.Errorf("Expected the totals and the category chart, got:\n%s", page)
+	}
+	if strings.Contains(page, "<h2>Issues</h2>") || strings.Contains(page, "Legend") {
+		t.Errorf("Expected no issues or legend, got:\n%s", page)
+	}
+}
+
+// testTiming tests that -timing prints the duration of every phase to stderr
+func testTiming(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	cmd := exec.Command(binary, "-analyze", "-repo", repoDir, "-timing")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("-timing failed: %v\n%s", err, stderr.String())
+	}
+
+	_, report, ok := strings.Cut(stderr.String(), "Timing:\n")
+	if !ok {
+		t.Fatalf("-timing: expected a timing report on stderr, got:\n%s", stderr.String())
+	}
+	phases := make(map[string]time.Duration)
+	for _, line := range strings.Split(strings.TrimSpace(report), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			t.Fatalf("-timing: expected a phase and a duration, got %q", line)
+		}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			t.Fatalf("-timing: invalid duration in %q: %v", line, err)
+		}
+		phases[fields[0]] = d
+	}
+	for _, phase := range []string{"scan", "analysis", "gosec", "learning", "total"} {
+		if _, ok := phases[phase]; !ok {
+			t.Errorf("-timing: expected the %s phase in %v", phase, phases)
+		}
+	}
+	if phases["total"] < phases["analysis"] {
+		t.Errorf("-timing: expected the total to include the analysis, got %v", phases)
+	}
+}
+
+// testNoColorWhenPiped tests that text output piped to another program has no ANSI escape
+// sequences, even without NO_COLOR set
+func testNoColorWhenPiped(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	source := `package main
+
+import "fmt"
+
+func show(verbose bool) {
+	dsn := "password='hunter2secret' sslmode=disable"
+	fmt.Println(dsn, verbose)
+}
+`
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var env []string
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "NO_COLOR=") {
+			env = append(env, v)
+		}
+	}
+	for _, args := range [][]string{{}, {"-quiet"}} {
+		cmd := exec.Command(binary, append([]string{"-analyze", "-repo", repoDir}, args...)...)
+		cmd.Env = env
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("Analysis with %v failed: %v", args, err)
+		}
+		if !strings.Contains(string(output), "critical") {
+			t.Fatalf("Expected a critical issue with %v, got:\n%s", args, output)
+		}
+		if strings.Contains(string(output), "\x1b[") {
+			t.Errorf("Expected no ANSI escape sequences in piped output with %v, got %q", args, output)
+		}
+	}
+}
+
+// testMerge tests combining the JSON results of two shards of a repository that overlap in
+// one file into one report
+func testMerge(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	sources := map[string]string{
+		"a.go": "package main\n\nfunc a(verbose bool) bool {\n\treturn verbose\n}\n",
+		"b.go": "package main\n\nfunc b(quiet bool) bool {\n\treturn quiet\n}\n",
+		"c.go": "package main\n\nfunc c(strict bool) bool {\n\treturn strict\n}\n",
+	}
+	for name, source := range sources {
+		if err := os.WriteFile(filepath.Join(repoDir, name), []byte(source), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	type counts struct {
+		Issues      []struct{ File string }
+		TotalIssues int
+		LowIssues   int
+	}
+	analyze := func(args ...string) counts {
+		t.Helper()
+		cmd := exec.Command(binary, append([]string{"-analyze", "-repo", repoDir, "-format", "json"}, args...)...)
+		cmd.Dir = repoDir
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("Analysis with %v failed: %v", args, err)
+		}
+		var c counts
+		if err := json.Unmarshal(output, &c); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+		}
+		return c
+	}
+
+	full := analyze()
+	if full.TotalIssues != 3 {
+		t.Fatalf("Expected one issue per file, got %+v", full)
+	}
+	shards := t.TempDir()
+	first, second := filepath.Join(shards, "first.json"), filepath.Join(shards, "second.json")
+	for output, files := range map[string]string{first: "a.go,b.go", second: "b.go,c.go"} {
+		cmd := exec.Command(binary, "-analyze", "-repo", repoDir, "-format", "json", "-files", files, "-output", output)
+		cmd.Dir = repoDir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("Analysis of %s failed: %v", files, err)
+		}
+	}
+
+	merged := analyze("-merge", first+","+second)
+	if merged.TotalIssues != full.TotalIssues || merged.LowIssues != full.LowIssues || len(merged.Issues) != len(full.Issues) {
+		t.Errorf("Expected the merged shards to match the full analysis %+v, got %+v", full, merged)
+	}
+
+	output, err := exec.Command(binary, "-merge", first+","+second, "-quiet").Output()
+	if err != nil {
+		t.Fatalf("Merging to text failed: %v", err)
+	}
+	if lines := strings.Count(string(output), "\n"); lines != 3 {
+		t.Errorf("Expected 3 issues in the merged text output, got:\n%s", output)
+	}
+
+	if err := exec.Command(binary, "-merge", filepath.Join(shards, "missing.json")).Run(); err == nil {
+		t.Error("Expected merging a missing file to fail")
+	}
+}
+
+// testInstallHook tests that -install-hook writes an executable pre-commit hook that rejects
+// commits of Go files with issues, and only replaces an existing hook with -force
+func testInstallHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell script")
+	}
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	if err := initGitRepo(repoDir); err != nil {
+		t.Fatalf("Error initializing Git repository: %v", err)
+	}
+	hook := filepath.Join(repoDir, ".git", "hooks", "pre-commit")
+
+	install := func(args ...string) error {
+		cmd := exec.Command(binary, append([]string{"-install-hook", "-repo", repoDir}, args...)...)
+		cmd.Stdin = strings.NewReader("")
+		return cmd.Run()
+	}
+	if err := install(); err != nil {
+		t.Fatalf("Installing the hook failed: %v", err)
+	}
+	info, err := os.Stat(hook)
+	if err != nil {
+		t.Fatalf("Expected the hook at %s: %v", hook, err)
+	}
+	if info.Mode()&0111 == 0 {
+		t.Errorf("Expected the hook to be executable, got mode %v", info.Mode())
+	}
+	content, err := os.ReadFile(hook)
+	if err != nil {
+		t.Fatalf("Failed to read hook: %v", err)
+	}
+	if !strings.Contains(string(content), binary) || !strings.Contains(string(content), "-fail-on high") {
+		t.Errorf("Expected the hook to run %s with -fail-on high, got:\n%s", binary, content)
+	}
+
+	if exitCode(t, install("-fail-on", "medium")) != 1 {
+		t.Error("Expected an existing hook not to be replaced without -force")
+	}
+	if err := install("-force", "-fail-on", "medium"); err != nil {
+		t.Fatalf("Replacing the hook failed: %v", err)
+	}
+	if content, _ := os.ReadFile(hook); !strings.Contains(string(content), "-fail-on medium") {
+		t.Errorf("Expected the replaced hook to use -fail-on medium, got:\n%s", content)
+	}
+
+	commit := func(name, source string) error {
+		if err := os.WriteFile(filepath.Join(repoDir, name), []byte(source), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		for _, args := range [][]string{{"add", name}, {"commit", "-m", "Add " + name}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repoDir
+			if output, err := cmd.CombinedOutput(); err != nil {
+				return fmt.Errorf("git %s: %v\n%s", args[0], err, output)
+			}
+		}
+		return nil
+	}
+	if err := commit("main.go", "package main\n\nfunc main() {}\n"); err != nil {
+		t.Errorf("Expected a file without medium issues to be committed: %v", err)
+	}
+	if err := commit("secret.go", "package main\n\nconst dsn = \"password='Zx9vQ2mLp4Rt8sKw'\"\n"); err == nil {
+		t.Error("Expected the hook to reject a file with a hardcoded secret")
+	}
+}
+
+// testAnalyzeOptimize tests that -analyze -optimize reports the optimizations as performance
+// issues of the same report, after the fixes of -fix
+func testAnalyzeOptimize(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	source := "package main\n\nfunc join(parts []string) string {\n\ts := \"\"\n\tfor _, p := range parts {\n\t\ts += p\n\t}\n\treturn s\n}\n"
+	path := filepath.Join(repoDir, "join.go")
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	type report struct {
+		Issues []struct {
+			Category string
+			Rule     string
+		}
+		TotalIssues int
+		LowIssues   int
+	}
+	analyze := func(args ...string) report {
+		t.Helper()
+		output, err := exec.Command(binary, append([]string{"-analyze", "-repo", repoDir, "-format", "json"}, args...)...).Output()
+		if err != nil {
+			t.Fatalf("Analysis with %v failed: %v", args, err)
+		}
+		var r report
+		if err := json.Unmarshal(output, &r); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+		}
+		return r
+	}
+
+	plain := analyze()
+	merged := analyze("-optimize")
+	optimizations := 0
+	for _, issue := range merged.Issues {
+		if strings.HasPrefix(issue.Rule, "OPT") {
+			if issue.Category != "performance" {
+				t.Errorf("Expected optimization %s in the performance category, got %q", issue.Rule, issue.Category)
+			}
+			optimizations++
+		}
+	}
+	if optimizations == 0 {
+		t.Fatalf("Expected the optimizations in the report, got %+v", merged)
+	}
+	if merged.TotalIssues != plain.TotalIssues+optimizations || merged.LowIssues != plain.LowIssues+optimizations {
+		t.Errorf("Expected the counts of %+v plus %d optimizations, got %+v", plain, optimizations, merged)
+	}
+
+	fixed := analyze("-optimize", "-fix")
+	for _, issue := range fixed.Issues {
+		if issue.Rule == "OPT001" {
+			t.Errorf("Expected the fixed concatenation to be left out of the report, got %+v", fixed)
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read fixed file: %v", err)
+	}
+	if !strings.Contains(string(content), "strings.Builder") {
+		t.Errorf("Expected -fix to rewrite the concatenation, got:\n%s", content)
+	}
+}
+
+// testAbsPaths tests that -abs-paths reports the absolute paths of files, in every repository
+// analyzed, while baselines keep relative paths
+func testAbsPaths(t *testing.T) {
+	binary := buildBinary(t)
+
+	var repoDirs []string
+	for _, name := range []string{"first", "second"} {
+		repoDir := filepath.Join(t.TempDir(), name)
+		if err := os.MkdirAll(repoDir, 0755); err != nil {
+			t.Fatalf("Failed to create repository: %v", err)
+		}
+		source := "package main\n\nfunc check(verbose bool) bool {\n\treturn verbose\n}\n"
+		if err := os.WriteFile(filepath.Join(repoDir, "check.go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		repoDirs = append(repoDirs, repoDir)
+	}
+
+	files := func(args ...string) []string {
+		t.Helper()
+		output, err := exec.Command(binary, append([]string{"-analyze", "-format", "json"}, args...)...).Output()
+		if err != nil {
+			t.Fatalf("Analysis with %v failed: %v", args, err)
+		}
+		var results struct {
+			Issues []struct{ File string }
+		}
+		if err := json.Unmarshal(output, &results); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+		}
+		var files []string
+		for _, issue := range results.Issues {
+			files = append(files, issue.File)
+		}
+		return files
+	}
+
+	if got := files("-repo", repoDirs[0]); len(got) == 0 || got[0] != "check.go" {
+		t.Errorf("Expected relative paths by default, got %v", got)
+	}
+	want := filepath.Join(repoDirs[0], "check.go")
+	if got := files("-repo", repoDirs[0], "-abs-paths"); len(got) == 0 || got[0] != want {
+		t.Errorf("Expected %s with -abs-paths, got %v", want, got)
+	}
+
+	got := files("-repo", strings.Join(repoDirs, ","), "-abs-paths")
+	seen := make(map[string]bool)
+	for _, file := range got {
+		seen[file] = true
+	}
+	for _, repoDir := range repoDirs {
+		if want := filepath.Join(repoDir, "check.go"); !seen[want] {
+			t.Errorf("Expected %s in the issues of several repositories, got %v", want, got)
+		}
+	}
+
+	baselineFile := filepath.Join(t.TempDir(), "baseline.json")
+	if err := exec.Command(binary, "-analyze", "-repo", repoDirs[0], "-baseline", baselineFile, "-write-baseline").Run(); err != nil {
+		t.Fatalf("Writing the baseline failed: %v", err)
+	}
+	if got := files("-repo", repoDirs[0], "-abs-paths", "-baseline", baselineFile); len(got) != 0 {
+		t.Errorf("Expected the baseline of relative paths to suppress the issues, got %v", got)
+	}
+}
+
+// testChangedSince tests that -changed-since analyzes the whole files changed since a
+// reference, committed or not, and no others
+func testChangedSince(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	if err := initGitRepo(repoDir); err != nil {
+		t.Fatal(err)
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, output)
+		}
+	}
+	write := func(name string) {
+		t.Helper()
+		source := "package main\n\nfunc " + strings.TrimSuffix(name, ".go") + "(verbose bool) bool {\n\treturn verbose\n}\n"
+		if err := os.WriteFile(filepath.Join(repoDir, name), []byte(source), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	write("committed.go")
+	write("unchanged.go")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+	git("branch", "base")
+
+	// A committed change, a staged file, and an untracked one
+	if err := os.WriteFile(filepath.Join(repoDir, "committed.go"), []byte("package main\n\nfunc committed(verbose bool) bool {\n\treturn !verbose\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to change test file: %v", err)
+	}
+	git("commit", "-am", "Change committed.go")
+	write("staged.go")
+	git("add", "staged.go")
+	write("untracked.go")
+
+	files := func(ref string) map[string]bool {
+		t.Helper()
+		cmd := exec.Command(binary, "-analyze", "-repo", repoDir, "-format", "json", "-changed-since", ref)
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("Analysis of the files changed since %s failed: %v", ref, err)
+		}
+		var results struct {
+			Issues []struct{ File string }
+		}
+		if err := json.Unmarshal(output, &results); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+		}
+		files := make(map[string]bool)
+		for _, issue := range results.Issues {
+			files[issue.File] = true
+		}
+		return files
+	}
+
+	got := files("base")
+	for _, want := range []string{"committed.go", "staged.go", "untracked.go"} {
+		if !got[want] {
+			t.Errorf("Expected the issues of %s, changed since base, got %v", want, got)
+		}
+	}
+	if got["unchanged.go"] {
+		t.Errorf("Expected unchanged.go not to be analyzed, got %v", got)
+	}
+
+	got = files("HEAD")
+	if got["committed.go"] || !got["staged.go"] || !got["untracked.go"] {
+		t.Errorf("Expected only the uncommitted files to have changed since HEAD, got %v", got)
+	}
+
+	git("add", ".")
+	git("commit", "-m", "Add files")
+	output, err := exec.Command(binary, "-analyze", "-repo", repoDir, "-changed-since", "HEAD").CombinedOutput()
+	if err != nil || !strings.Contains(string(output), "No Go files changed since HEAD") {
+		t.Errorf("Expected nothing to analyze without changes, got %v:\n%s", err, output)
+	}
+}
+
+// testDiffOnly tests that -diff-only reports the issues on the lines changed in the working
+// tree without -head, and refuses a -head the working tree does not match
+func testDiffOnly(t *testing.T) {
+	binary := buildBinary(t)
+
+	repoDir := t.TempDir()
+	if err := initGitRepo(repoDir); err != nil {
+		t.Fatal(err)
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, output)
+		}
+	}
+	path := filepath.Join(repoDir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+
+	// An uncommitted function with a boolean parameter
+	source := "package main\n\nfunc main() {}\n\nfunc toggle(verbose bool) bool {\n\treturn !verbose\n}\n"
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to change test file: %v", err)
+	}
+
+	output, err := exec.Command(binary, "-analyze", "-repo", repoDir, "-format", "json", "-diff-only", "-base", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("Analysis of the changed lines failed: %v", err)
+	}
+	var results struct {
+		Issues []struct {
+			Rule string
+			Line int
+		}
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+	found := false
+	for _, issue := range results.Issues {
+		if issue.Line < 4 {
+			t.Errorf("Expected only issues on the uncommitted lines, got %s on line %d", issue.Rule, issue.Line)
+		}
+		if issue.Rule == "boolean-param" && issue.Line == 5 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the boolean-param issue of the uncommitted function, got:\n%s", output)
+	}
+
+	err = exec.Command(binary, "-analyze", "-repo", repoDir, "-diff-only", "-base", "HEAD", "-head", "HEAD").Run()
+	if exitCode(t, err) != 1 {
+		t.Error("Expected -head not matching the working tree to be refused")
+	}
+}
@@ -0,0 +1,160 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite groups the issues of one analyzer category
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	TestCases  []junitTestCase  `xml:"testcase"`
+}
+
+// junitProperties holds the properties of a test suite
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+// junitProperty is a named value of a test suite, such as an issue count
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitTestCase represents a single issue
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure carries the message and suggestion of an issue
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes issues as a JUnit XML report, so that CI test reporting shows them.
+// Every category becomes a test suite and every issue a failing test case. Categories
+// without issues, such as enabled analyzers that found nothing, produce empty suites.
+func WriteJUnit(w io.Writer, issues []*models.Issue, categories []string) error {
+	suites := make(map[string]*junitTestSuite)
+	var order []string
+	addSuite := func(category string) *junitTestSuite {
+		if suite, ok := suites[category]; ok {
+			return suite
+		}
+		suite := &junitTestSuite{Name: category, TestCases: []junitTestCase{}}
+		suites[category] = suite
+		order = append(order, category)
+		return suite
+	}
+
+	for _, category := range categories {
+		addSuite(category)
+	}
+
+	// Categories outside the given list, e.g. of custom rules, follow in alphabetical order
+	extra := make(map[string]bool)
+	for _, issue := range issues {
+		if _, ok := suites[issue.Category]; !ok {
+			extra[issue.Category] = true
+		}
+	}
+	extraNames := make([]string, 0, len(extra))
+	for category := range extra {
+		extraNames = append(extraNames, category)
+	}
+	sort.Strings(extraNames)
+	for _, category := range extraNames {
+		addSuite(category)
+	}
+
+	for _, issue := range issues {
+		text := issue.Message
+		if issue.Suggestion != "" {
+			text += "\nSuggestion: " + issue.Suggestion
+		}
+
+		suite := suites[issue.Category]
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      fmt.Sprintf("%s %s:%d", issue.Rule, issue.File, issue.Line),
+			ClassName: issue.File,
+			File:      issue.File,
+			Line:      issue.Line,
+			Failure: &junitFailure{
+				Message: issue.Message,
+				Type:    issue.Severity,
+				Text:    text,
+			},
+		})
+		suite.Tests++
+		suite.Failures++
+	}
+
+	report := junitTestSuites{Name: "code-review"}
+	for _, category := range order {
+		suite := suites[category]
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, *suite)
+	}
+
+	return writeJUnitReport(w, report)
+}
+
+// WriteJUnitSummary writes only the counts of issues as a JUnit XML report: a summary test
+// suite without test cases, whose properties are the total and per-severity counts
+func WriteJUnitSummary(w io.Writer, issues []*models.Issue) error {
+	suite := junitTestSuite{
+		Name:       "summary",
+		Properties: &junitProperties{[]junitProperty{{Name: "total", Value: strconv.Itoa(len(issues))}}},
+		TestCases:  []junitTestCase{},
+	}
+	for _, severity := range markdownSeverities {
+		n := 0
+		for _, issue := range issues {
+			if issue.Severity == severity {
+				n++
+			}
+		}
+		suite.Properties.Properties = append(suite.Properties.Properties, junitProperty{Name: severity, Value: strconv.Itoa(n)})
+	}
+
+	return writeJUnitReport(w, junitTestSuites{Name: "code-review", Suites: []junitTestSuite{suite}})
+}
+
+// writeJUnitReport writes a JUnit XML report with its XML header
+func writeJUnitReport(w io.Writer, report junitTestSuites) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/user/code-review-assistant/internal/config"
 	"github.com/user/code-review-assistant/internal/models"
@@ -31,11 +32,36 @@ func NewLearningEngine(cfg *config.Config) (*LearningEngine, error) {
 	}, nil
 }
 
+// Close releases the learning data storage
+func (e *LearningEngine) Close() error {
+	return e.dataCollector.Close()
+}
+
 // RecordIssue records an issue for learning
 func (e *LearningEngine) RecordIssue(issue *models.Issue, repository string) error {
 	return e.dataCollector.RecordIssue(issue, repository)
 }
 
+// RecordIssues records a batch of issues for learning
+func (e *LearningEngine) RecordIssues(issues []*models.Issue, repository string) error {
+	return e.dataCollector.RecordIssues(issues, repository)
+}
+
+// RuleStats returns the statistics of the learning data of every rule, see DataCollector.RuleStats
+func (e *LearningEngine) RuleStats(now time.Time) []*RuleStats {
+	return e.dataCollector.RuleStats(now)
+}
+
+// Export writes the learning data to a file, see DataCollector.Export
+func (e *LearningEngine) Export(path string) error {
+	return e.dataCollector.Export(path)
+}
+
+// Import merges a file written by Export into the learning data, see DataCollector.Import
+func (e *LearningEngine) Import(path string) (*ImportResult, error) {
+	return e.dataCollector.Import(path)
+}
+
 // RecordFeedback records feedback for an issue
 func (e *LearningEngine) RecordFeedback(issueID string, accepted bool) error {
 	return e.dataCollector.RecordFeedback(issueID, accepted)
@@ -69,6 +95,9 @@ func (e *LearningEngine) AdjustIssueConfidence(issue *models.Issue) {
 	}
 }
 
+// filterAcceptanceRate is the acceptance rate of a rule below which FilterIssues drops its issues
+const filterAcceptanceRate = 0.3
+
 // FilterIssues filters issues based on learning data
 func (e *LearningEngine) FilterIssues(issues []*models.Issue) []*models.Issue {
 	if !e.config.EnableLearning {
@@ -80,8 +109,7 @@ func (e *LearningEngine) FilterIssues(issues []*models.Issue) []*models.Issue {
 	for _, issue := range issues {
 		rate := e.dataCollector.GetAcceptanceRate(issue.Rule)
 		
-		// Keep issues with acceptance rate >= 0.3
-		if rate >= 0.3 {
+		if rate >= filterAcceptanceRate {
 			filtered = append(filtered, issue)
 		}
 	}
@@ -102,8 +130,8 @@ func (e *LearningEngine) SortIssues(issues []*models.Issue) []*models.Issue {
 	// Sort by a combination of severity and acceptance rate
 	sort.Slice(sorted, func(i, j int) bool {
 		// Get severity scores (critical=4, high=3, medium=2, low=1)
-		iSeverity := getSeverityScore(sorted[i].Severity)
-		jSeverity := getSeverityScore(sorted[j].Severity)
+		iSeverity := models.SeverityScore(sorted[i].Severity)
+		jSeverity := models.SeverityScore(sorted[j].Severity)
 		
 		// Get acceptance rates
 		iRate := e.dataCollector.GetAcceptanceRate(sorted[i].Rule)
@@ -113,29 +141,16 @@ func (e *LearningEngine) SortIssues(issues []*models.Issue) []*models.Issue {
 		iScore := float64(iSeverity) * (0.5 + 0.5*iRate)
 		jScore := float64(jSeverity) * (0.5 + 0.5*jRate)
 		
-		// Sort by score (descending)
-		return iScore > jScore
+		// Sort by score (descending), then by confidence
+		if iScore != jScore {
+			return iScore > jScore
+		}
+		return models.ConfidenceScore(sorted[i].Confidence) > models.ConfidenceScore(sorted[j].Confidence)
 	})
 	
 	return sorted
 }
 
-// getSeverityScore returns a numeric score for a severity level
-func getSeverityScore(severity string) int {
-	switch severity {
-	case "critical":
-		return 4
-	case "high":
-		return 3
-	case "medium":
-		return 2
-	case "low":
-		return 1
-	default:
-		return 0
-	}
-}
-
 // SuggestCustomRules suggests custom rules based on learning data
 func (e *LearningEngine) SuggestCustomRules() []string {
 	if !e.config.EnableLearning {
@@ -235,7 +250,7 @@ func (e *LearningEngine) GetSimilarIssues(issue *models.Issue) []*models.Issue {
 	var similarIssues []*models.Issue
 	
 	// Get all issues for the same rule
-	for _, data := range e.dataCollector.issueData[issue.Rule] {
+	for _, data := range e.dataCollector.ruleData(issue.Rule) {
 		// Skip the same issue
 		if data.Issue.File == issue.File && data.Issue.Line == issue.Line {
 			continue
@@ -0,0 +1,401 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/user/code-review-assistant/internal/analyzer"
+	"github.com/user/code-review-assistant/internal/config"
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// Diagnostic severities of the Language Server Protocol
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+)
+
+// diagnosticSource names the tool in the diagnostics shown by editors
+const diagnosticSource = "code-review-assistant"
+
+// Server is a Language Server Protocol server that publishes the issues found in open Go
+// documents as diagnostics. Documents are analyzed as they are opened and changed, with
+// their unsaved contents. Only full document synchronization is supported.
+type Server struct {
+	root     string
+	config   *config.Config
+	analyzer *analyzer.Analyzer
+	out      io.Writer
+	shutdown bool
+}
+
+// NewServer creates a server for the repository at root. The root sent by the client when
+// it initializes the server takes precedence.
+func NewServer(root string, cfg *config.Config) *Server {
+	return &Server{
+		root:   root,
+		config: cfg,
+	}
+}
+
+// request is a JSON-RPC request, or a notification when it has no ID
+type request struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC response, with either a result or an error
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Result  *json.RawMessage `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+// responseError describes a failed request
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// notification is a JSON-RPC notification sent to the client
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// Position is a zero-based line and UTF-16 character offset in a document
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span of a document
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Diagnostic is an issue shown by the editor in a document
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// PublishDiagnosticsParams are the parameters of a textDocument/publishDiagnostics notification
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     *int         `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// initializeParams holds the parameters of the initialize request used by the server
+type initializeParams struct {
+	RootURI  string `json:"rootUri"`
+	RootPath string `json:"rootPath"`
+}
+
+// textDocumentItem is a document opened by the client
+type textDocumentItem struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+	Text    string `json:"text"`
+}
+
+// didOpenParams are the parameters of a textDocument/didOpen notification
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+// didChangeParams are the parameters of a textDocument/didChange notification. With full
+// synchronization, every change holds the whole text of the document.
+type didChangeParams struct {
+	TextDocument struct {
+		URI     string `json:"uri"`
+		Version int    `json:"version"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// didCloseParams are the parameters of a textDocument/didClose notification
+type didCloseParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+}
+
+// Serve reads requests from in and writes responses and notifications to out until the
+// client sends the exit notification, in reaches its end, or the context is cancelled.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.out = out
+	reader := bufio.NewReader(in)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil
+		}
+
+		body, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit requested before shutdown")
+			}
+			return nil
+		}
+
+		if err := s.handle(ctx, &req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle processes a request or notification. Only errors writing to the client are returned.
+func (s *Server) handle(ctx context.Context, req *request) error {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(req.Params, &params); err == nil {
+			if path := uriToPath(params.RootURI); path != "" {
+				s.root = path
+			} else if params.RootPath != "" {
+				s.root = params.RootPath
+			}
+		}
+		s.analyzer = analyzer.NewAnalyzer(s.root, s.config)
+		return s.reply(req.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": 1, // Full
+			},
+			"serverInfo": map[string]string{
+				"name":    diagnosticSource,
+				"version": analyzer.Version,
+			},
+		}, nil)
+
+	case "shutdown":
+		s.shutdown = true
+		return s.reply(req.ID, nil, nil)
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		doc := params.TextDocument
+		return s.publish(ctx, doc.URI, doc.Version, doc.Text)
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.publish(ctx, params.TextDocument.URI, params.TextDocument.Version, text)
+
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		// Diagnostics of closed documents are cleared
+		return s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+	}
+
+	// Unknown notifications are ignored, unknown requests answered with an error
+	if len(req.ID) > 0 {
+		return s.reply(req.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method})
+	}
+	return nil
+}
+
+// publish analyzes the text of a document and sends its issues as diagnostics. Documents
+// that are not Go files, or that cannot be parsed while being edited, are skipped.
+func (s *Server) publish(ctx context.Context, uri string, version int, text string) error {
+	path := uriToPath(uri)
+	if !strings.HasSuffix(path, ".go") {
+		return nil
+	}
+	if s.analyzer == nil {
+		s.analyzer = analyzer.NewAnalyzer(s.root, s.config)
+	}
+
+	relPath := path
+	if rel, err := filepath.Rel(s.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		relPath = filepath.ToSlash(rel)
+	}
+	file := &models.File{
+		Path:    path,
+		RelPath: relPath,
+		Size:    int64(len(text)),
+		Lines:   strings.Count(text, "\n") + 1,
+	}
+	results, err := s.analyzer.AnalyzeSource(ctx, file, []byte(text))
+	if err != nil {
+		return nil
+	}
+	results.Filter(s.config)
+
+	lines := strings.Split(text, "\n")
+	diagnostics := make([]Diagnostic, 0, len(results.Issues))
+	for _, issue := range results.Issues {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    issueRange(issue, lines),
+			Severity: DiagnosticSeverity(issue.Severity),
+			Code:     issue.Rule,
+			Source:   diagnosticSource,
+			Message:  issue.Message,
+		})
+	}
+
+	return s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         uri,
+		Version:     &version,
+		Diagnostics: diagnostics,
+	})
+}
+
+// DiagnosticSeverity maps the severity of an issue to an LSP diagnostic severity: critical
+// and high issues are errors, medium issues warnings, and low issues information
+func DiagnosticSeverity(severity string) int {
+	switch severity {
+	case "critical", "high":
+		return SeverityError
+	case "medium":
+		return SeverityWarning
+	case "low":
+		return SeverityInformation
+	default:
+		return SeverityHint
+	}
+}
+
+// issueRange returns the range of an issue, from its column to the end of its line
+func issueRange(issue *models.Issue, lines []string) Range {
+	line := issue.Line - 1
+	if line < 0 || line >= len(lines) {
+		return Range{}
+	}
+	text := strings.TrimSuffix(lines[line], "\r")
+
+	// Columns are byte offsets, LSP characters count UTF-16 code units
+	column := issue.Column - 1
+	if column < 0 || column > len(text) {
+		column = 0
+	}
+	start := utf16Len(text[:column])
+	return Range{
+		Start: Position{Line: line, Character: start},
+		End:   Position{Line: line, Character: start + utf16Len(text[column:])},
+	}
+}
+
+// utf16Len returns the length of a string in UTF-16 code units
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// uriToPath returns the file path of a file URI, or an empty string for other URIs
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// reply sends the response to a request
+func (s *Server) reply(id json.RawMessage, result interface{}, respErr *responseError) error {
+	resp := &response{JSONRPC: "2.0", ID: id, Error: respErr}
+	if id == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	if respErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		raw := json.RawMessage(data)
+		resp.Result = &raw
+	}
+	return s.write(resp)
+}
+
+// notify sends a notification to the client
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(&notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write sends a message with its Content-Length header
+func (s *Server) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err = s.out.Write(data)
+	return err
+}
+
+// readMessage reads the body of the next message, whose headers give its length
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read message header: %w", err)
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	return body, nil
+}
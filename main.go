@@ -1,67 +1,132 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/user/code-review-assistant/internal/analyzer"
+	"github.com/user/code-review-assistant/internal/baseline"
 	"github.com/user/code-review-assistant/internal/cmd"
 	"github.com/user/code-review-assistant/internal/config"
+	"github.com/user/code-review-assistant/internal/lsp"
+	"github.com/user/code-review-assistant/internal/ml"
+	"github.com/user/code-review-assistant/internal/models"
+	"github.com/user/code-review-assistant/internal/optimization"
 	"github.com/user/code-review-assistant/internal/prsummary"
+	"github.com/user/code-review-assistant/internal/report"
+	"github.com/user/code-review-assistant/internal/reviewignore"
 	"github.com/user/code-review-assistant/internal/scanner"
+	"github.com/user/code-review-assistant/internal/server"
 )
 
 const (
-	version = "1.0.0"
+	version = analyzer.Version
 )
 
 func main() {
 	// Define command-line flags
 	var (
 		// Common flags
-		repoPath      = flag.String("repo", ".", "Path to the repository to analyze")
-		configFile    = flag.String("config", "", "Path to configuration file")
+		repoPath      = flag.String("repo", ".", "Path to the repository to analyze, or comma-separated paths of several repositories to analyze together")
+		configFile    = flag.String("config", "", "Path to configuration file, JSON, YAML, or TOML (default: .review.yaml, .review.toml, or .review.json in the repository)")
 		verbose       = flag.Bool("verbose", false, "Enable verbose output")
-		outputFormat  = flag.String("format", "text", "Output format (text, json, html)")
+		outputFormat  = flag.String("format", "text", "Output format (text, json, jsonl, html, junit, github, markdown)")
+		outputFile    = flag.String("output", "", "Write the analysis results to this file instead of stdout")
+		quiet         = flag.Bool("quiet", false, "With text output, print one line per issue and nothing else")
+		noSuggestions = flag.Bool("no-suggestions", false, "With text output, omit the suggestions of issues")
+		summaryOnly   = flag.Bool("summary-only", false, "Print only the total and per-severity issue counts, in any output format")
+		noColor       = flag.Bool("no-color", false, "Do not color the severities of text output on a terminal (also set by the NO_COLOR environment variable)")
+		absFilePaths  = flag.Bool("abs-paths", false, "Report the absolute paths of files instead of paths relative to the repository")
 		showVersion   = flag.Bool("version", false, "Show version information")
 		
 		// Analysis flags
 		includeTests  = flag.Bool("include-tests", true, "Include test files in analysis")
 		excludeDirs   = flag.String("exclude-dirs", ".git,vendor,node_modules", "Comma-separated list of directories to exclude")
 		excludeFiles  = flag.String("exclude-files", "", "Comma-separated list of files to exclude")
+		diffOnly      = flag.Bool("diff-only", false, "Only report issues on lines added or modified since -base, in -head or, without it, the working tree")
+		changedSince  = flag.String("changed-since", "", "Analyze only the Go files changed since this Git reference, including staged, unstaged, and untracked files")
+		filesFlag     = flag.String("files", "", "Comma-separated list of files to analyze instead of the whole repository; file arguments are added to it")
+		stdinFilename = flag.String("stdin-filename", "", "Analyze Go source read from stdin instead of the repository, reporting issues in this file name")
+		baselineFile  = flag.String("baseline", "", "Path to a baseline file of known issues to suppress")
+		writeBaseline = flag.Bool("write-baseline", false, "Record all current issues in the baseline file")
+		cachePath     = flag.String("cache", "", "Directory of the analysis cache; unchanged files reuse previous results")
+		metricsFile   = flag.String("metrics", "", "Write aggregate metrics of the analysis as JSON to this file")
+		mergeFiles    = flag.String("merge", "", "Comma-separated list of result files written with -format json to combine into one report instead of analyzing")
+		onlyCategory  = flag.String("only-category", "", "Comma-separated list of issue categories to report (security, code-smell, performance, best-practice, anti-pattern, documentation)")
+		onlySeverity  = flag.String("only-severity", "", "Comma-separated list of issue severities to report (critical, high, medium, low)")
+		onlyRule      = flag.String("only-rule", "", "Comma-separated list of rule IDs to report")
+		minConfidence = flag.String("min-confidence", "", "Minimum confidence of the reported issues (high, medium, low; default: low)")
+		watchFlag     = flag.Bool("watch", false, "Keep running and re-analyze Go files as they change")
+		lspFlag       = flag.Bool("lsp", false, "Run a Language Server Protocol server over stdin and stdout that publishes issues as diagnostics")
+		serveAddr     = flag.String("serve", "", "Serve analyses of repositories over HTTP on this address, e.g. :8080, with POST /analyze")
+		failOn        = flag.String("fail-on", "none", "Exit with code 1 if issues at or above this severity are found (critical, high, medium, low, none)")
+		timing        = flag.Bool("timing", false, "Print how long each phase of the analysis took to stderr")
 		
 		// PR summary flags
 		baseRef       = flag.String("base", "main", "Base reference for PR summary")
 		headRef       = flag.String("head", "HEAD", "Head reference for PR summary")
+		prNumber      = flag.Int("pr", 0, "GitHub pull request number to summarize via the GitHub API instead of local refs")
+		githubRepo    = flag.String("github-repo", "", "GitHub repository of the pull request as owner/repo (default: $GITHUB_REPOSITORY)")
 		
 		// Command flags
 		analyzeCmd    = flag.Bool("analyze", false, "Run code analysis")
 		summaryCmd    = flag.Bool("summary", false, "Generate PR summary")
 		optimizeCmd   = flag.Bool("optimize", false, "Suggest optimizations")
+		fixFlag       = flag.Bool("fix", false, "Rewrite files to apply the optimizations that have an automatic fix")
 		learnCmd      = flag.Bool("learn", false, "Enable machine learning")
 		feedbackCmd   = flag.Bool("feedback", false, "Provide feedback for an issue")
-		issueID       = flag.String("issue-id", "", "Issue ID for feedback")
+		explainRule   = flag.String("explain", "", "Print the documentation of a rule, by ID or name")
+		listRules     = flag.Bool("list-rules", false, "List every available rule (text or json format)")
+		mlReport      = flag.Bool("ml-report", false, "Print the acceptance rate and trend of every rule from the machine learning data (text or json format)")
+		mlExport      = flag.String("ml-export", "", "Write the machine learning data to a file to share with other machines")
+		mlImport      = flag.String("ml-import", "", "Merge a file written by -ml-export into the machine learning data")
+		initConfig    = flag.Bool("init-config", false, "Write a commented default configuration file to the path given as argument (default: .review.json in the repository)")
+		installHook   = flag.Bool("install-hook", false, "Install a git pre-commit hook that analyzes the staged Go files and fails at the -fail-on severity (default: high)")
+		force         = flag.Bool("force", false, "With -init-config or -install-hook, overwrite an existing file without asking")
+		issueID       = flag.String("issue-id", "", "Issue ID for feedback, as shown in the analysis output")
 		accepted      = flag.Bool("accepted", false, "Whether the issue was accepted")
 	)
 	
 	// Custom usage message
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Intelligent Code Review Assistant v%s\n\n", version)
-		fmt.Fprintf(os.Stderr, "Usage: %s [options] [command]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] [command] [files]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Commands:\n")
 		fmt.Fprintf(os.Stderr, "  -analyze              Run code analysis\n")
 		fmt.Fprintf(os.Stderr, "  -summary              Generate PR summary\n")
 		fmt.Fprintf(os.Stderr, "  -optimize             Suggest optimizations\n")
 		fmt.Fprintf(os.Stderr, "  -feedback             Provide feedback for an issue\n")
+		fmt.Fprintf(os.Stderr, "  -explain <rule>       Print the documentation of a rule\n")
+		fmt.Fprintf(os.Stderr, "  -list-rules           List every available rule\n")
+		fmt.Fprintf(os.Stderr, "  -init-config [path]   Write a commented default configuration file\n")
+		fmt.Fprintf(os.Stderr, "  -install-hook         Install a git pre-commit hook analyzing the staged Go files\n")
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s -analyze -repo /path/to/repo\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -analyze main.go util.go\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -summary -base main -head feature-branch\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -summary -pr 42 -github-repo owner/repo\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -optimize -repo /path/to/repo\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  %s -feedback -issue-id \"file.go:10:Error not handled\" -accepted\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -optimize -fix -repo /path/to/repo\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -feedback -issue-id 3f2a9c1d7b4e8a06 -accepted\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -explain OPT001\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -init-config .review.yaml\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -install-hook -fail-on medium\n", os.Args[0])
 	}
 	
 	flag.Parse()
@@ -72,12 +137,70 @@ func main() {
 		os.Exit(0)
 	}
 	
-	// Resolve absolute path for repository
-	absPath, err := filepath.Abs(*repoPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error resolving repository path: %v\n", err)
+	// Resolve absolute paths for repositories, the first one being used for the settings
+	// and commands that apply to a single repository
+	var absPaths []string
+	for _, path := range splitList(*repoPath) {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving repository path: %v\n", err)
+			os.Exit(1)
+		}
+		absPaths = append(absPaths, absPath)
+	}
+	if len(absPaths) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: -repo is empty\n")
 		os.Exit(1)
 	}
+	absPath := absPaths[0]
+	
+	if *summaryOnly && *quiet {
+		fmt.Fprintf(os.Stderr, "Error: -summary-only cannot be combined with -quiet\n")
+		os.Exit(1)
+	}
+	
+	if *failOn != "none" && models.SeverityScore(*failOn) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid -fail-on value %q (must be critical, high, medium, low, or none)\n", *failOn)
+		os.Exit(1)
+	}
+	
+	// Handle init-config command, before loading a configuration that may not exist yet
+	if *initConfig {
+		path := filepath.Join(absPath, ".review.json")
+		if flag.NArg() > 0 {
+			path = flag.Arg(0)
+		}
+		if err := config.InitConfig(path, *force); err != nil {
+			if errors.Is(err, os.ErrExist) {
+				fmt.Fprintf(os.Stderr, "Error: %s already exists, use -force to overwrite it\n", path)
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("Configuration written to %s\n", path)
+		os.Exit(0)
+	}
+	
+	// Handle install-hook command, failing commits at high severity unless -fail-on is given
+	if *installHook {
+		hookFailOn := "high"
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "fail-on" {
+				hookFailOn = *failOn
+			}
+		})
+		if err := installPreCommitHook(absPath, hookFailOn, *force); err != nil {
+			fmt.Fprintf(os.Stderr, "Error installing pre-commit hook: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	
+	// Fall back to a configuration file in the repository root
+	if *configFile == "" {
+		*configFile = config.FindConfig(absPath)
+	}
 	
 	// Load configuration
 	cfg, err := loadConfig(*configFile, *verbose, *includeTests, *excludeDirs, *excludeFiles, *learnCmd)
@@ -85,6 +208,92 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
+	for _, warning := range cfg.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if *cachePath != "" {
+		cfg.CachePath = *cachePath
+	}
+	if *onlyCategory != "" {
+		cfg.OnlyCategories = splitList(*onlyCategory)
+	}
+	if *onlySeverity != "" {
+		cfg.OnlySeverities = splitList(*onlySeverity)
+	}
+	if *onlyRule != "" {
+		cfg.OnlyRules = splitList(*onlyRule)
+	}
+	if *minConfidence != "" {
+		cfg.MinConfidence = *minConfidence
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	
+	if *writeBaseline && *baselineFile == "" {
+		fmt.Fprintf(os.Stderr, "Error: -baseline is required with -write-baseline\n")
+		os.Exit(1)
+	}
+	
+	if *fixFlag && !*optimizeCmd {
+		fmt.Fprintf(os.Stderr, "Error: -optimize is required with -fix\n")
+		os.Exit(1)
+	}
+	
+	// Handle explain command
+	if *explainRule != "" {
+		doc, ok := analyzer.LookupRule(cfg, *explainRule)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown rule %q\n", *explainRule)
+			os.Exit(1)
+		}
+		printRuleDoc(doc)
+		os.Exit(0)
+	}
+	
+	// Handle list-rules command
+	if *listRules {
+		if err := printRules(analyzer.Rules(cfg), *outputFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	
+	// Handle ml-report command
+	if *mlReport {
+		stats, err := cmd.RuleStats(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading learning data: %v\n", err)
+			os.Exit(1)
+		}
+		if err := printRuleStats(os.Stdout, stats, *outputFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	
+	// Handle ml-export and ml-import commands
+	if *mlExport != "" || *mlImport != "" {
+		if *mlImport != "" {
+			result, err := cmd.ImportLearning(*mlImport, cfg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error importing learning data: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Imported %d records from %s (%d already known), %d issues marked accepted\n", result.Added, *mlImport, result.Skipped, result.Accepted)
+		}
+		if *mlExport != "" {
+			if err := cmd.ExportLearning(*mlExport, cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "Error exporting learning data: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Exported learning data to %s\n", *mlExport)
+		}
+		os.Exit(0)
+	}
 	
 	// Check if at least one command is specified
 	if !*analyzeCmd && !*summaryCmd && !*optimizeCmd && !*feedbackCmd {
@@ -110,7 +319,13 @@ func main() {
 	
 	// Handle PR summary command
 	if *summaryCmd {
-		if err := generatePRSummary(absPath, *baseRef, *headRef, cfg); err != nil {
+		var err error
+		if *prNumber > 0 {
+			err = generateGitHubPRSummary(*githubRepo, *prNumber, cfg)
+		} else {
+			err = generatePRSummary(absPath, *baseRef, *headRef, cfg)
+		}
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error generating PR summary: %v\n", err)
 			os.Exit(1)
 		}
@@ -121,35 +336,223 @@ func main() {
 		}
 	}
 	
-	// Initialize repository scanner
-	repoScanner := scanner.NewScanner(absPath, cfg)
+	// Files given by flag or as arguments limit the analysis
+	files := append(splitList(*filesFlag), flag.Args()...)
+	if *stdinFilename != "" && len(files) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: -stdin-filename cannot be combined with a list of files\n")
+		os.Exit(1)
+	}
+	
+	if *changedSince != "" && (*stdinFilename != "" || len(files) > 0) {
+		fmt.Fprintf(os.Stderr, "Error: -changed-since cannot be combined with -stdin-filename or a list of files\n")
+		os.Exit(1)
+	}
 	
-	// Scan repository for Go files
-	files, err := repoScanner.Scan()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error scanning repository: %v\n", err)
+	if *mergeFiles != "" && (*diffOnly || *changedSince != "" || *stdinFilename != "" || len(files) > 0 || *watchFlag || *lspFlag || *serveAddr != "" || *timing) {
+		fmt.Fprintf(os.Stderr, "Error: -merge cannot be combined with -diff-only, -changed-since, -stdin-filename, -watch, -lsp, -serve, -timing, or a list of files\n")
 		os.Exit(1)
 	}
 	
-	if cfg.Verbose {
-		fmt.Printf("Found %d files to analyze\n", len(files))
+	// With -analyze, the optimizations are reported with the issues of the analysis
+	mergeOptimizations := *analyzeCmd && *optimizeCmd && *mergeFiles == ""
+	if mergeOptimizations && *stdinFilename != "" {
+		fmt.Fprintf(os.Stderr, "Error: -stdin-filename cannot be combined with -optimize\n")
+		os.Exit(1)
+	}
+	
+	if len(absPaths) > 1 && (*summaryCmd || *optimizeCmd || *lspFlag || *watchFlag || *diffOnly || *changedSince != "" || *stdinFilename != "" || len(files) > 0) {
+		fmt.Fprintf(os.Stderr, "Error: several repositories can only be analyzed as a whole, without -summary, -optimize, -lsp, -watch, -diff-only, -changed-since, -stdin-filename, or a list of files\n")
+		os.Exit(1)
+	}
+	
+	// Serve editors until they exit
+	if *lspFlag {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := lsp.NewServer(absPath, cfg).Serve(ctx, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving LSP: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	
+	// Serve HTTP requests until interrupted
+	if *serveAddr != "" {
+		if err := serveHTTP(*serveAddr, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving HTTP: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	
+	// Watch the repository until interrupted
+	if *watchFlag {
+		if err := watchCode(absPath, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error watching repository: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	
+	// Analyze only the Go files changed since a reference, as if they were listed
+	if *changedSince != "" {
+		files, err = changedGoFiles(absPath, *changedSince)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(files) == 0 {
+			fmt.Fprintf(os.Stderr, "No Go files changed since %s\n", *changedSince)
+			os.Exit(0)
+		}
 	}
 	
 	// Handle analyze command
+	var results *analyzer.Results
 	if *analyzeCmd {
-		if err := analyzeCode(files, absPath, *outputFormat, cfg); err != nil {
-			fmt.Fprintf(os.Stderr, "Error analyzing code: %v\n", err)
-			os.Exit(1)
+		var changed prsummary.ChangedLines
+		if *diffOnly {
+			// The working tree is analyzed, so it is what the diff compares unless -head is
+			// given, which it must then match for the line numbers to agree
+			diffHead := ""
+			flag.Visit(func(f *flag.Flag) {
+				if f.Name == "head" {
+					diffHead = *headRef
+				}
+			})
+			if diffHead != "" {
+				var at bool
+				if at, err = prsummary.WorkingTreeAt(absPath, diffHead); err != nil {
+					fmt.Fprintf(os.Stderr, "Error reading changed lines: %v\n", err)
+					os.Exit(1)
+				}
+				if !at {
+					fmt.Fprintf(os.Stderr, "Error: the working tree differs from %s, check it out or leave -head out to report the issues of the working tree\n", diffHead)
+					os.Exit(1)
+				}
+			}
+			changed, err = prsummary.ChangedLineRanges(absPath, *baseRef, diffHead)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading changed lines: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		style := textFull
+		if *summaryOnly {
+			style = textSummaryOnly
+		} else if *quiet {
+			style = textQuiet
+		} else if *noSuggestions {
+			style = textNoSuggestions
+		}
+		if *mergeFiles != "" {
+			results, err = mergeResultFiles(splitList(*mergeFiles), cfg)
+			if err == nil {
+				var absRepoPaths []string
+				if *absFilePaths {
+					absRepoPaths = []string{absPath}
+				}
+				err = reportResults(results, absRepoPaths, *outputFormat, *outputFile, style, *noColor, *baselineFile, *writeBaseline, *metricsFile, cfg)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error merging results: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			// Apply the fixes of -fix first, so that the results are those of the fixed files
+			if mergeOptimizations && *fixFlag {
+				optimizeFiles, err := optimizationFiles(absPath, files, cfg)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error scanning repository: %v\n", err)
+					os.Exit(1)
+				}
+				cmd.FixOptimizations(os.Stderr, optimizeFiles, cfg)
+			}
+			
+			start := time.Now()
+			results, err = analyzeCode(absPaths, files, *stdinFilename, changed, mergeOptimizations, *absFilePaths, *outputFormat, *outputFile, style, *noColor, *baselineFile, *writeBaseline, *metricsFile, cfg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error analyzing code: %v\n", err)
+				os.Exit(1)
+			}
+			if *timing {
+				printTimings(os.Stderr, results.Timings, time.Since(start))
+			}
 		}
 	}
 	
 	// Handle optimize command
-	if *optimizeCmd {
-		if err := suggestOptimizations(files, cfg); err != nil {
+	if *optimizeCmd && !mergeOptimizations {
+		// Scan repository for Go files, or look up the files given
+		optimizeFiles, err := optimizationFiles(absPath, files, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning repository: %v\n", err)
+			os.Exit(1)
+		}
+		
+		if cfg.Verbose {
+			fmt.Printf("Found %d files to analyze\n", len(optimizeFiles))
+		}
+		
+		if err := suggestOptimizations(optimizeFiles, cfg, *fixFlag); err != nil {
 			fmt.Fprintf(os.Stderr, "Error suggesting optimizations: %v\n", err)
 			os.Exit(1)
 		}
 	}
+	
+	// Fail the run for CI gating if the severity threshold is met
+	if results != nil && meetsSeverityThreshold(results, *failOn) {
+		os.Exit(1)
+	}
+}
+
+// installPreCommitHook installs the pre-commit hook of -install-hook, running this executable,
+// into a repository. An existing hook is replaced if overwrite is set or the user agrees
+// when asked on a terminal.
+func installPreCommitHook(repoPath, failOn string, overwrite bool) error {
+	binary, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the executable: %w", err)
+	}
+
+	path, err := cmd.InstallHook(repoPath, binary, failOn, overwrite)
+	if errors.Is(err, os.ErrExist) {
+		if !isTerminal(os.Stdin) {
+			return errors.New("a pre-commit hook already exists, use -force to overwrite it")
+		}
+		fmt.Print("A pre-commit hook already exists. Overwrite it? [y/N] ")
+		answer, readErr := bufio.NewReader(os.Stdin).ReadString('\n')
+		if readErr != nil {
+			// No answer, end the line of the question
+			fmt.Println()
+		}
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Println("Pre-commit hook left unchanged")
+			return nil
+		}
+		path, err = cmd.InstallHook(repoPath, binary, failOn, true)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pre-commit hook written to %s\n", path)
+	return nil
+}
+
+// meetsSeverityThreshold checks if any issue is at or above the given severity
+func meetsSeverityThreshold(results *analyzer.Results, threshold string) bool {
+	if threshold == "none" {
+		return false
+	}
+	
+	minScore := models.SeverityScore(threshold)
+	for _, issue := range results.Issues {
+		if models.SeverityScore(issue.Severity) >= minScore {
+			return true
+		}
+	}
+	
+	return false
 }
 
 // loadConfig loads configuration from a file or creates a default configuration
@@ -186,58 +589,426 @@ func loadConfig(configFile string, verbose, includeTests bool, excludeDirs, excl
 	return cfg, nil
 }
 
-// analyzeCode analyzes code and prints results
-func analyzeCode(files []*models.File, repoPath, outputFormat string, cfg *config.Config) error {
-	// Initialize code analyzer
-	codeAnalyzer := analyzer.NewAnalyzer(cfg)
+// splitList splits a comma-separated flag value, ignoring surrounding spaces and empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// changedGoFiles returns the absolute paths of the Go files of a repository changed since
+// a Git reference
+func changedGoFiles(repoPath, ref string) ([]string, error) {
+	changed, err := prsummary.ChangedFiles(repoPath, ref)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range changed {
+		if strings.HasSuffix(file, ".go") {
+			files = append(files, filepath.Join(repoPath, file))
+		}
+	}
+	return files, nil
+}
+
+// analyzeCode analyzes code, prints results, and returns them for further checks. When
+// stdinName is set, the source read from stdin is analyzed as that file; otherwise, when
+// files is not empty, only these files are analyzed instead of the whole repository. When
+// changed is not nil, only the issues on changed lines are kept. With optimize set, the
+// optimizations found in the files are added as performance issues. Several repositories are
+// analyzed one after the other and their results merged, with the paths of their issues
+// prefixed with names given by repoNames. With absolute set, the issues are reported with
+// absolute paths instead. Results are printed to outputFile, or to stdout when it is empty.
+func analyzeCode(repoPaths []string, files []string, stdinName string, changed prsummary.ChangedLines, optimize, absolute bool, outputFormat, outputFile string, style textStyle, noColor bool, baselineFile string, writeBaseline bool, metricsFile string, cfg *config.Config) (*analyzer.Results, error) {
+	var results *analyzer.Results
+	var err error
+	if len(repoPaths) == 1 {
+		results, err = analyzeRepo(repoPaths[0], files, stdinName, changed, optimize, cfg)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		results = &analyzer.Results{}
+		for i, name := range repoNames(repoPaths) {
+			repoResults, err := analyzeRepo(repoPaths[i], files, stdinName, changed, optimize, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", repoPaths[i], err)
+			}
+			results.Merge(name, repoResults)
+		}
+	}
+	
+	var absRepoPaths []string
+	if absolute {
+		absRepoPaths = repoPaths
+	}
+	if err := reportResults(results, absRepoPaths, outputFormat, outputFile, style, noColor, baselineFile, writeBaseline, metricsFile, cfg); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// mergeResultFiles loads results written with -format json to several files, such as the
+// shards of a CI job, and combines them with analyzer.MergeResults, keeping the issues
+// selected by the configuration
+func mergeResultFiles(paths []string, cfg *config.Config) (*analyzer.Results, error) {
+	all := make([]*analyzer.Results, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var results analyzer.Results
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, fmt.Errorf("%s is not a result file of -format json: %w", path, err)
+		}
+		all = append(all, &results)
+	}
 	
-	// Analyze files
-	results, err := codeAnalyzer.Analyze(files)
+	merged := analyzer.MergeResults(all...)
+	merged.Filter(cfg)
+	return merged, nil
+}
+
+// reportResults records or applies the baseline of known issues to analysis results, writes
+// the metrics file if requested, and writes the results to outputFile, or to stdout when it
+// is empty. When absRepoPaths is not nil, the paths of the results, relative to the
+// repositories at these absolute paths, are made absolute after the baseline, which keeps
+// relative paths.
+func reportResults(results *analyzer.Results, absRepoPaths []string, outputFormat, outputFile string, style textStyle, noColor bool, baselineFile string, writeBaseline bool, metricsFile string, cfg *config.Config) error {
+	// Record or apply the baseline of known issues
+	if writeBaseline {
+		if err := baseline.New(results.Issues).Save(baselineFile); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Baseline with %d issues written to %s\n", len(results.Issues), baselineFile)
+	} else if baselineFile != "" {
+		known, err := baseline.Load(baselineFile)
+		if err != nil {
+			return err
+		}
+		results.Issues = known.Filter(results.Issues)
+		results.Recount()
+	}
+	
+	if absRepoPaths != nil {
+		absoluteResultPaths(results, absRepoPaths)
+	}
+	
+	if metricsFile != "" {
+		if err := writeMetrics(metricsFile, results.Metrics); err != nil {
+			return err
+		}
+	}
+	
+	if outputFile == "" {
+		return writeResults(os.Stdout, results, outputFormat, style, useColor(os.Stdout, noColor), cfg)
+	}
+	
+	err := writeFileAtomic(outputFile, func(w io.Writer) error {
+		return writeResults(w, results, outputFormat, style, false, cfg)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to analyze code: %w", err)
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
+	return nil
+}
+
+// analyzeRepo analyzes a repository as described for analyzeCode and applies its ignore
+// file, printing warnings to stderr
+func analyzeRepo(repoPath string, files []string, stdinName string, changed prsummary.ChangedLines, optimize bool, cfg *config.Config) (*analyzer.Results, error) {
+	// Show progress on terminals, unless verbose messages are printed
+	stopProgress := func() {}
+	if stdinName == "" && !cfg.Verbose && isTerminal(os.Stderr) {
+		stopProgress = showProgress(cfg)
 	}
 	
-	// Apply machine learning if enabled
-	if cfg.EnableLearning {
-		sortedIssues, insights, err := cmd.ApplyLearning(results.Issues, repoPath, cfg)
+	var results *analyzer.Results
+	var err error
+	if stdinName != "" {
+		var content []byte
+		content, err = io.ReadAll(os.Stdin)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to apply machine learning: %v\n", err)
-		} else {
-			results.Issues = sortedIssues
-			
-			// Print insights
-			if len(insights) > 0 {
-				fmt.Println("\nProject Insights:")
-				for _, insight := range insights {
-					fmt.Printf("- %s\n", insight)
-				}
-				fmt.Println()
-			}
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
 		}
+		results, err = analyzer.RunSource(context.Background(), repoPath, stdinName, content, cfg)
+	} else if len(files) > 0 {
+		results, err = analyzer.RunFiles(context.Background(), repoPath, files, cfg)
+	} else {
+		results, err = analyzer.Run(context.Background(), repoPath, cfg)
+	}
+	stopProgress()
+	if err != nil {
+		return nil, err
 	}
 	
-	// Record issues for learning
-	if cfg.EnableLearning {
-		for _, issue := range results.Issues {
-			if err := cmd.RecordIssue(issue, repoPath, cfg); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to record issue for learning: %v\n", err)
+	for _, warning := range results.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	
+	if cfg.Verbose {
+		fmt.Fprintf(os.Stderr, "Analyzed %d files (%d cached)\n", results.Files, results.CachedFiles)
+	}
+	
+	// Report the optimizations of -optimize as performance issues
+	if optimize {
+		optimizeFiles, err := optimizationFiles(repoPath, files, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan repository: %w", err)
+		}
+		optimizations, err := optimization.NewAnalyzer(cfg).Analyze(optimizeFiles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze optimizations: %w", err)
+		}
+		results.Issues = append(results.Issues, optimization.ToIssues(optimizations)...)
+		results.Filter(cfg)
+	}
+	
+	// Only report issues on lines changed since the base reference
+	if changed != nil {
+		results.Issues = changed.Filter(results.Issues)
+		results.Recount()
+	}
+	
+	// Suppress the rule and path pairs listed in the repository's ignore file
+	ignore, err := reviewignore.Load(filepath.Join(repoPath, reviewignore.FileName))
+	if err == nil {
+		results.Issues = ignore.Filter(results.Issues)
+		results.Recount()
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return results, nil
+}
+
+// absoluteResultPaths replaces the paths of the issues and functions of results with
+// absolute paths. The paths are relative to the single repository of repoPaths, or prefixed
+// with the names given by repoNames to several.
+func absoluteResultPaths(results *analyzer.Results, repoPaths []string) {
+	names := repoNames(repoPaths)
+	resolve := func(file string) string {
+		if filepath.IsAbs(file) {
+			return file
+		}
+		if len(repoPaths) == 1 {
+			return filepath.Join(repoPaths[0], file)
+		}
+
+		// Names can be prefixes of each other, as in a and a/svc, so the longest one wins
+		match := -1
+		for i, name := range names {
+			if strings.HasPrefix(file, name+"/") && (match < 0 || len(name) > len(names[match])) {
+				match = i
 			}
 		}
+		if match < 0 {
+			return file
+		}
+		return filepath.Join(repoPaths[match], strings.TrimPrefix(file, names[match]+"/"))
+	}
+
+	for _, issue := range results.Issues {
+		issue.File = resolve(issue.File)
+	}
+	for _, function := range results.Functions {
+		function.File = resolve(function.File)
+	}
+	results.Recount()
+}
+
+// repoNames returns the names prefixing the paths of the repositories analyzed together:
+// the base names of their paths, or the whole paths for base names shared by several
+func repoNames(repoPaths []string) []string {
+	count := make(map[string]int)
+	for _, path := range repoPaths {
+		count[filepath.Base(path)]++
+	}
+	names := make([]string, len(repoPaths))
+	for i, path := range repoPaths {
+		names[i] = filepath.Base(path)
+		if count[names[i]] > 1 {
+			names[i] = strings.TrimPrefix(filepath.ToSlash(path), "/")
+		}
+	}
+	return names
+}
+
+// printTimings writes the duration of each phase of an analysis to w, one per line, and
+// the total time including the report
+func printTimings(w io.Writer, timings analyzer.Timings, total time.Duration) {
+	fmt.Fprintln(w, "Timing:")
+	fmt.Fprintf(w, "  scan      %v\n", timings.Scan.Round(time.Microsecond))
+	fmt.Fprintf(w, "  analysis  %v\n", timings.Analysis.Round(time.Microsecond))
+	fmt.Fprintf(w, "  gosec     %v\n", timings.Gosec.Round(time.Microsecond))
+	fmt.Fprintf(w, "  learning  %v\n", timings.Learning.Round(time.Microsecond))
+	fmt.Fprintf(w, "  total     %v\n", total.Round(time.Microsecond))
+}
+
+// progressMinFiles is the number of files above which the progress of an analysis is shown
+const progressMinFiles = 500
+
+// progressInterval is the minimum time between two updates of the progress line
+const progressInterval = 200 * time.Millisecond
+
+// showProgress makes the analysis print its progress to stderr on a line rewritten as
+// files are analyzed, once more than progressMinFiles files have been found. The returned
+// function clears the line, and must be called when the analysis is over.
+func showProgress(cfg *config.Config) func() {
+	var mutex sync.Mutex
+	var last time.Time
+	shown := false
+	cfg.OnProgress = func(done, found int) {
+		if found <= progressMinFiles {
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if time.Since(last) < progressInterval {
+			return
+		}
+		last = time.Now()
+		shown = true
+		fmt.Fprintf(os.Stderr, "\rAnalyzed %d/%d files (%d%%)", done, found, 100*done/found)
+	}
+	
+	return func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		cfg.OnProgress = nil
+		if shown {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+		}
+	}
+}
+
+// isTerminal reports whether a file is a terminal rather than a pipe or regular file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor reports whether the text output written to a file is colored: the file must be a
+// terminal, and neither -no-color nor the NO_COLOR environment variable may be set
+func useColor(f *os.File, noColor bool) bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(f)
+}
+
+// writeResults writes analysis results to w in the given output format, with the
+// severities colored in the text format if color is set
+func writeResults(w io.Writer, results *analyzer.Results, outputFormat string, style textStyle, color bool, cfg *config.Config) error {
+	// Print insights, keeping machine-readable output valid
+	if len(results.Insights) > 0 {
+		out := w
+		if outputFormat != "text" || style == textQuiet || style == textSummaryOnly {
+			out = os.Stderr
+		}
+		fmt.Fprintln(out, "\nProject Insights:")
+		for _, insight := range results.Insights {
+			fmt.Fprintf(out, "- %s\n", insight)
+		}
+		fmt.Fprintln(out)
+	}
+	
+	if style == textSummaryOnly {
+		return writeSummary(w, results, outputFormat)
 	}
 	
 	// Output results based on format
 	switch outputFormat {
 	case "text":
-		printTextResults(results)
+		printTextResults(w, results, style, color)
+		return nil
 	case "json":
-		printJSONResults(results)
+		return printJSONResults(w, results)
+	case "jsonl":
+		return printJSONLinesResults(w, results)
 	case "html":
-		printHTMLResults(results)
+		return report.WriteHTML(w, results.Issues)
+	case "junit":
+		return report.WriteJUnit(w, results.Issues, cfg.EnabledCategories())
+	case "github":
+		return report.WriteGitHubAnnotations(w, results.Issues)
+	case "markdown":
+		return report.WriteMarkdown(w, results.Issues, cfg.MarkdownMaxSize)
 	default:
 		return fmt.Errorf("unsupported output format: %s", outputFormat)
 	}
+}
+
+// writeFileAtomic writes a file through a temporary file in the same directory that is
+// renamed over path once complete, so that a failure never leaves a partial file behind
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
 	
-	return nil
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// serveHTTP serves analyses of repositories on addr until the process is interrupted, then
+// lets the analyses in progress finish
+func serveHTTP(addr string, cfg *config.Config) error {
+	handler, err := server.NewServer(cfg, "")
+	if err != nil {
+		return err
+	}
+	
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	
+	httpServer := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "Serving analyses on %s, press Ctrl-C to stop\n", addr)
+	
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return httpServer.Shutdown(context.Background())
+	}
+}
+
+// watchCode analyzes the repository and prints how the issues change as files are edited,
+// until the process is interrupted
+func watchCode(repoPath string, cfg *config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	
+	ready := func(results *analyzer.Results) {
+		fmt.Printf("Watching %s for changes (%d issues), press Ctrl-C to stop\n", repoPath, len(results.Issues))
+	}
+	onChange := func(change *analyzer.FileChange) {
+		fmt.Printf("%s: %d new, %d resolved, %d remaining\n", change.File, len(change.New), len(change.Resolved), len(change.Issues))
+		for _, issue := range change.New {
+			fmt.Printf("  + [%s] %s:%d: %s\n", issue.Severity, issue.File, issue.Line, issue.Message)
+		}
+		for _, issue := range change.Resolved {
+			fmt.Printf("  - [%s] %s:%d: %s\n", issue.Severity, issue.File, issue.Line, issue.Message)
+		}
+	}
+	
+	return analyzer.Watch(ctx, repoPath, cfg, ready, onChange)
 }
 
 // generatePRSummary generates a PR summary
@@ -260,31 +1031,201 @@ func generatePRSummary(repoPath, baseRef, headRef string, cfg *config.Config) er
 	return nil
 }
 
-// suggestOptimizations suggests code optimizations
-func suggestOptimizations(files []*models.File, cfg *config.Config) error {
-	return cmd.AnalyzeOptimizations(files, cfg)
-}
-
-// printTextResults prints analysis results in text format
-func printTextResults(results *analyzer.Results) {
-	fmt.Println("Code Review Results:")
-	fmt.Println("====================")
+// generateGitHubPRSummary generates a summary of a GitHub pull request using the GitHub API
+func generateGitHubPRSummary(githubRepo string, prNumber int, cfg *config.Config) error {
+	if githubRepo == "" {
+		githubRepo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	owner, repo, ok := strings.Cut(githubRepo, "/")
+	if !ok || owner == "" || repo == "" {
+		return fmt.Errorf("invalid GitHub repository %q (expected owner/repo)", githubRepo)
+	}
 	
-	if len(results.Issues) == 0 {
-		fmt.Println("No issues found!")
-		return
+	generator := prsummary.NewPRSummaryGenerator(cfg)
+	
+	// The token is read from GITHUB_TOKEN
+	summary, err := generator.GenerateSummaryFromGitHub(owner, repo, prNumber, "")
+	if err != nil {
+		return fmt.Errorf("failed to generate PR summary: %w", err)
 	}
 	
-	for _, issue := range results.Issues {
-		fmt.Printf("[%s] %s: %s\n", issue.Severity, issue.Category, issue.Message)
-		fmt.Printf("  File: %s:%d\n", issue.File, issue.Line)
-		if issue.Suggestion != "" {
-			fmt.Printf("  Suggestion: %s\n", issue.Suggestion)
+	fmt.Println(generator.FormatSummary(summary))
+	
+	return nil
+}
+
+// optimizationFiles scans a repository for the Go files of -optimize, or looks up the files
+// given
+func optimizationFiles(repoPath string, files []string, cfg *config.Config) ([]*models.File, error) {
+	repoScanner := scanner.NewScanner(repoPath, cfg)
+	if len(files) > 0 {
+		return repoScanner.Files(files)
+	}
+	return repoScanner.Scan()
+}
+
+// suggestOptimizations suggests code optimizations, applying the automatic fixes if fix is set
+func suggestOptimizations(files []*models.File, cfg *config.Config, fix bool) error {
+	return cmd.AnalyzeOptimizations(files, cfg, fix)
+}
+
+// printRules prints a list of rules as a table or, with the json format, as JSON
+func printRules(rules []*analyzer.RuleDoc, format string) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(rules, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal rules: %w", err)
 		}
-		fmt.Println()
+		fmt.Println(string(data))
+	case "text":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RULE\tCATEGORY\tSEVERITY\tDESCRIPTION")
+		for _, rule := range rules {
+			id := rule.ID
+			if rule.Name != rule.ID {
+				id = fmt.Sprintf("%s (%s)", rule.ID, rule.Name)
+			}
+			severity := rule.Severity
+			if severity == "" {
+				severity = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", id, rule.Category, severity, rule.Description)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unsupported format %q for -list-rules (must be text or json)", format)
 	}
-	
-	fmt.Printf("Total issues: %d (Critical: %d, High: %d, Medium: %d, Low: %d)\n",
+	return nil
+}
+
+// ruleStatsJSON is the JSON form of the statistics of a rule, with the derived values
+type ruleStatsJSON struct {
+	*ml.RuleStats
+	Rate             float64
+	Trend            string
+	SuggestDisabling bool
+}
+
+// printRuleStats writes the acceptance statistics of rules to w in text or JSON format.
+// The text format is a table followed by the rules that are worth disabling.
+func printRuleStats(w io.Writer, stats []*ml.RuleStats, format string) error {
+	switch format {
+	case "json":
+		out := make([]ruleStatsJSON, 0, len(stats))
+		for _, s := range stats {
+			out = append(out, ruleStatsJSON{RuleStats: s, Rate: s.Rate(), Trend: s.Trend(), SuggestDisabling: s.SuggestDisabling()})
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal rule statistics: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "text":
+		if len(stats) == 0 {
+			_, err := fmt.Fprintln(w, "No learning data recorded, run the analysis with -learn first")
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "RULE\tRECORDS\tACCEPTED\tRATE\tLAST 30 DAYS\tOLDER\tTREND")
+		for _, s := range stats {
+			trend := s.Trend()
+			if trend == "" {
+				trend = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.0f%%\t%s\t%s\t%s\n", s.Rule, s.Records, s.Accepted, 100*s.Rate(), acceptedOf(s.RecentAccepted, s.RecentRecords), acceptedOf(s.OlderAccepted, s.OlderRecords), trend)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		for _, s := range stats {
+			if s.SuggestDisabling() {
+				fmt.Fprintf(w, "Consider adding %s to disabled_rules: only %d of its %d issues were accepted\n", s.Rule, s.Accepted, s.Records)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q for -ml-report (must be text or json)", format)
+	}
+}
+
+// acceptedOf formats a number of accepted records out of a total, or - without records
+func acceptedOf(accepted, records int) string {
+	if records == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", accepted, records)
+}
+
+// printRuleDoc prints the documentation of a rule
+func printRuleDoc(doc *analyzer.RuleDoc) {
+	if doc.Name != doc.ID {
+		fmt.Printf("%s (%s)\n", doc.ID, doc.Name)
+	} else {
+		fmt.Println(doc.ID)
+	}
+	fmt.Printf("  Category: %s\n", doc.Category)
+	if doc.Severity != "" {
+		fmt.Printf("  Default severity: %s\n", doc.Severity)
+	}
+	fmt.Printf("  Description: %s\n", doc.Description)
+	if doc.Suggestion != "" {
+		fmt.Printf("  Suggestion: %s\n", doc.Suggestion)
+	}
+	if doc.Example != "" {
+		fmt.Println("  Example:")
+		for _, line := range strings.Split(doc.Example, "\n") {
+			fmt.Println(strings.TrimRight("    "+line, " "))
+		}
+	}
+}
+
+// textStyle selects how much detail the text output shows
+type textStyle int
+
+const (
+	textFull          textStyle = iota // Issues with their ID and suggestion, and a summary
+	textNoSuggestions                  // Like textFull without suggestions
+	textQuiet                          // One line per issue and nothing else
+	textSummaryOnly                    // Only the totals, in the other formats too
+)
+
+// writeSummary writes only the issue counts of analysis results to w: the totals line for
+// text and markdown, the counts as a JSON object for json and jsonl, a notice for github, a
+// test suite with the counts as properties for junit, and the totals and chart for html
+func writeSummary(w io.Writer, results *analyzer.Results, outputFormat string) error {
+	switch outputFormat {
+	case "text":
+		_, err := fmt.Fprintln(w, totalsLine(results))
+		return err
+	case "markdown":
+		_, err := fmt.Fprintf(w, "**%s**\n", totalsLine(results))
+		return err
+	case "github":
+		_, err := fmt.Fprintf(w, "::notice title=Code review::%s\n", totalsLine(results))
+		return err
+	case "json":
+		data, err := json.MarshalIndent(newResultsSummary(results), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal summary: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "jsonl":
+		return json.NewEncoder(w).Encode(&jsonLinesSummary{Type: "summary", resultsSummary: newResultsSummary(results), Warnings: results.Warnings})
+	case "junit":
+		return report.WriteJUnitSummary(w, results.Issues)
+	case "html":
+		return report.WriteHTMLSummary(w, results.Issues)
+	default:
+		return fmt.Errorf("summary-only output is not available in the %s format", outputFormat)
+	}
+}
+
+// totalsLine returns the line of the text output counting the issues by severity
+func totalsLine(results *analyzer.Results) string {
+	return fmt.Sprintf("Total issues: %d (Critical: %d, High: %d, Medium: %d, Low: %d)",
 		results.TotalIssues,
 		results.CriticalIssues,
 		results.HighIssues,
@@ -293,14 +1234,139 @@ func printTextResults(results *analyzer.Results) {
 	)
 }
 
-// printJSONResults prints analysis results in JSON format
-func printJSONResults(results *analyzer.Results) {
-	// Placeholder for JSON output
-	fmt.Println("JSON output not yet implemented")
+// severityColors are the ANSI escape sequences coloring the severities of text output
+var severityColors = map[string]string{
+	"critical": "\033[31m", // Red
+	"high":     "\033[35m", // Magenta
+	"medium":   "\033[33m", // Yellow
+	"low":      "\033[36m", // Cyan
+}
+
+// colorReset ends a colored severity
+const colorReset = "\033[0m"
+
+// severityText returns a severity as printed in text output, colored if color is set
+func severityText(severity string, color bool) string {
+	code, ok := severityColors[severity]
+	if !color || !ok {
+		return severity
+	}
+	return code + severity + colorReset
+}
+
+// printTextResults writes analysis results to w in text format, with the severities
+// colored if color is set
+func printTextResults(w io.Writer, results *analyzer.Results, style textStyle, color bool) {
+	if style == textQuiet {
+		for _, issue := range results.Issues {
+			fmt.Fprintf(w, "%s %s:%d %s %s\n", severityText(issue.Severity, color), issue.File, issue.Line, issue.Rule, issue.Message)
+		}
+		return
+	}
+	
+	fmt.Fprintln(w, "Code Review Results:")
+	fmt.Fprintln(w, "====================")
+	
+	if len(results.Issues) == 0 {
+		fmt.Fprintln(w, "No issues found!")
+		return
+	}
+	
+	for _, issue := range results.Issues {
+		fmt.Fprintf(w, "[%s] %s: %s\n", severityText(issue.Severity, color), issue.Category, issue.Message)
+		fmt.Fprintf(w, "  File: %s:%d\n", issue.File, issue.Line)
+		fmt.Fprintf(w, "  ID: %s\n", issue.ID)
+		if issue.Suggestion != "" && style != textNoSuggestions {
+			fmt.Fprintf(w, "  Suggestion: %s\n", issue.Suggestion)
+		}
+		fmt.Fprintln(w)
+	}
+	
+	for _, repo := range results.Repositories {
+		fmt.Fprintf(w, "%s: %d issues in %d files (Critical: %d, High: %d, Medium: %d, Low: %d)\n",
+			repo.Name,
+			repo.TotalIssues,
+			repo.Files,
+			repo.CriticalIssues,
+			repo.HighIssues,
+			repo.MediumIssues,
+			repo.LowIssues,
+		)
+	}
+	
+	fmt.Fprintln(w, totalsLine(results))
 }
 
-// printHTMLResults prints analysis results in HTML format
-func printHTMLResults(results *analyzer.Results) {
-	// Placeholder for HTML output
-	fmt.Println("HTML output not yet implemented")
+// printJSONResults writes analysis results to w in JSON format
+func printJSONResults(w io.Writer, results *analyzer.Results) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// jsonLinesIssue is an issue in the jsonl format, tagged as such
+type jsonLinesIssue struct {
+	Type string `json:"type"`
+	*models.Issue
+}
+
+// resultsSummary counts the files and issues of analysis results
+type resultsSummary struct {
+	Files          int
+	Lines          int
+	TotalIssues    int
+	CriticalIssues int
+	HighIssues     int
+	MediumIssues   int
+	LowIssues      int
+}
+
+// newResultsSummary returns the counts of analysis results
+func newResultsSummary(results *analyzer.Results) resultsSummary {
+	return resultsSummary{
+		Files:          results.Files,
+		Lines:          results.Lines,
+		TotalIssues:    results.TotalIssues,
+		CriticalIssues: results.CriticalIssues,
+		HighIssues:     results.HighIssues,
+		MediumIssues:   results.MediumIssues,
+		LowIssues:      results.LowIssues,
+	}
+}
+
+// jsonLinesSummary is the last line of the jsonl format
+type jsonLinesSummary struct {
+	Type string `json:"type"`
+	resultsSummary
+	Warnings []string
+}
+
+// printJSONLinesResults writes analysis results to w as JSON Lines: one compact object per
+// issue, with a type of "issue", followed by the totals in an object with a type of
+// "summary", so that consumers can process the issues as they are read
+func printJSONLinesResults(w io.Writer, results *analyzer.Results) error {
+	encoder := json.NewEncoder(w)
+	for _, issue := range results.Issues {
+		if err := encoder.Encode(&jsonLinesIssue{Type: "issue", Issue: issue}); err != nil {
+			return fmt.Errorf("failed to write issue: %w", err)
+		}
+	}
+	return encoder.Encode(&jsonLinesSummary{Type: "summary", resultsSummary: newResultsSummary(results), Warnings: results.Warnings})
+}
+
+// writeMetrics writes the metrics of an analysis to a file as JSON
+func writeMetrics(path string, metrics *analyzer.Metrics) error {
+	data, err := json.MarshalIndent(metrics, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,164 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// markdownSeverities lists the severities in the order their sections appear. Low issues
+// are folded into a collapsible section.
+var markdownSeverities = []string{"critical", "high", "medium", "low"}
+
+// markdownReserve is the room kept at the end of a size-limited report for closing tags
+// and the truncation note
+const markdownReserve = 200
+
+// markdownBadgeColors are the shields.io colors of the severity badges
+var markdownBadgeColors = map[string]string{
+	"critical": "critical",
+	"high":     "orange",
+	"medium":   "yellow",
+	"low":      "informational",
+}
+
+// markdownCellEscaper escapes text for a cell of a Markdown table
+var markdownCellEscaper = strings.NewReplacer("|", `\|`, "\r", "", "\n", " ", "<", "&lt;", ">", "&gt;")
+
+// WriteMarkdown writes issues as a Markdown report for a pull request comment: a summary
+// line and badges with the counts by severity, a table of the categories that have issues,
+// a table of issues per severity, with low issues in a collapsible section, and a legend
+// of the severity and confidence levels. When maxSize is positive, issues are left out as
+// needed to keep the report below maxSize bytes, and a note tells how many were omitted;
+// the legend is then left out too.
+func WriteMarkdown(w io.Writer, issues []*models.Issue, maxSize int) error {
+	bySeverity := make(map[string][]*models.Issue)
+	for _, issue := range issues {
+		severity := issue.Severity
+		if models.SeverityScore(severity) <= models.SeverityScore("low") {
+			severity = "low"
+		}
+		bySeverity[severity] = append(bySeverity[severity], issue)
+	}
+
+	var b strings.Builder
+	b.WriteString("## Code Review\n\n")
+	if len(issues) == 0 {
+		b.WriteString("No issues found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	counts := make([]string, 0, len(markdownSeverities))
+	for _, severity := range markdownSeverities {
+		counts = append(counts, fmt.Sprintf("%d %s", len(bySeverity[severity]), severity))
+	}
+	noun := "issues"
+	if len(issues) == 1 {
+		noun = "issue"
+	}
+	fmt.Fprintf(&b, "**%d %s**: %s\n", len(issues), noun, strings.Join(counts, ", "))
+
+	// Counts by severity as badges, and by category as a table with a text bar chart
+	badges := make([]string, 0, len(markdownSeverities))
+	for _, severity := range markdownSeverities {
+		badges = append(badges, fmt.Sprintf("![%s: %d](https://img.shields.io/badge/%s-%d-%s)",
+			severity, len(bySeverity[severity]), severity, len(bySeverity[severity]), markdownBadgeColors[severity]))
+	}
+	fmt.Fprintf(&b, "\n%s\n", strings.Join(badges, " "))
+	b.WriteString("\n| Category | Issues | |\n| --- | --- | --- |\n")
+	for _, category := range countCategories(issues) {
+		bar := strings.Repeat("█", (category.Percent+9)/10)
+		fmt.Fprintf(&b, "| %s | %d | %s |\n", markdownCellEscaper.Replace(category.Name), category.Issues, bar)
+	}
+
+	omitted := 0
+	fits := func(s string) bool {
+		return maxSize <= 0 || b.Len()+len(s)+markdownReserve <= maxSize
+	}
+	for _, severity := range markdownSeverities {
+		group := bySeverity[severity]
+		if len(group) == 0 {
+			continue
+		}
+		sortIssuesByLocation(group)
+
+		title := strings.ToUpper(severity[:1]) + severity[1:]
+		var header, footer string
+		if severity == "low" {
+			header = fmt.Sprintf("\n<details>\n<summary>%s (%d)</summary>\n\n", title, len(group))
+			footer = "\n</details>\n"
+		} else {
+			header = fmt.Sprintf("\n### %s (%d)\n\n", title, len(group))
+		}
+		header += "| Location | Rule | Message |\n| --- | --- | --- |\n"
+
+		// A section is only started if at least its first issue fits
+		if omitted > 0 || !fits(header+markdownRow(group[0])) {
+			omitted += len(group)
+			continue
+		}
+		b.WriteString(header)
+		for i, issue := range group {
+			row := markdownRow(issue)
+			if !fits(row) {
+				omitted += len(group) - i
+				break
+			}
+			b.WriteString(row)
+		}
+		b.WriteString(footer)
+	}
+
+	// The legend only ends reports that show every issue
+	if legend := markdownLegend(); omitted == 0 && fits(legend) {
+		b.WriteString(legend)
+	}
+
+	if omitted > 0 {
+		fmt.Fprintf(&b, "\n> **Note:** %d more issues are not shown to keep this report short.\n", omitted)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownLegend returns a collapsible section explaining the severity and confidence levels
+func markdownLegend() string {
+	var b strings.Builder
+	b.WriteString("\n<details>\n<summary>Legend</summary>\n\n")
+	for _, level := range severityLegend {
+		fmt.Fprintf(&b, "- **%s** severity: %s\n", level.Level, level.Meaning)
+	}
+	b.WriteString("\n")
+	for _, level := range confidenceLegend {
+		fmt.Fprintf(&b, "- **%s** confidence: %s\n", level.Level, level.Meaning)
+	}
+	b.WriteString("\n</details>\n")
+	return b.String()
+}
+
+// markdownRow formats an issue as a row of the issue table
+func markdownRow(issue *models.Issue) string {
+	location := issue.File
+	if issue.Line > 0 {
+		location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+	}
+	return fmt.Sprintf("| `%s` | %s | %s |\n",
+		strings.NewReplacer("`", "", "|", `\|`).Replace(location),
+		markdownCellEscaper.Replace(issue.Rule),
+		markdownCellEscaper.Replace(issue.Message))
+}
+
+// sortIssuesByLocation sorts issues by file and line
+func sortIssuesByLocation(issues []*models.Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// topFilesCount is the number of files listed in Metrics.TopFiles
+const topFilesCount = 5
+
+// Metrics aggregates the results of an analysis for dashboards
+type Metrics struct {
+	Files      int            // Number of files analyzed
+	Lines      int            // Lines of Go code in the analyzed files
+	Rules      map[string]int // Number of issues by rule
+	Categories map[string]int // Number of issues by category
+	Severities map[string]int // Number of issues by severity
+	TopFiles   []FileIssues   // Files with the most issues, most first
+}
+
+// FileIssues is the number of issues found in a file
+type FileIssues struct {
+	File   string
+	Issues int
+}
+
+// newMetrics computes the metrics of a list of issues found in files with the given number
+// of lines
+func newMetrics(files, lines int, issues []*models.Issue) *Metrics {
+	m := &Metrics{
+		Files:      files,
+		Lines:      lines,
+		Rules:      make(map[string]int),
+		Categories: make(map[string]int),
+		Severities: make(map[string]int),
+	}
+
+	perFile := make(map[string]int)
+	for _, issue := range issues {
+		m.Rules[issue.Rule]++
+		m.Categories[issue.Category]++
+		m.Severities[issue.Severity]++
+		perFile[issue.File]++
+	}
+
+	for file, count := range perFile {
+		m.TopFiles = append(m.TopFiles, FileIssues{File: file, Issues: count})
+	}
+	sort.Slice(m.TopFiles, func(i, j int) bool {
+		if m.TopFiles[i].Issues != m.TopFiles[j].Issues {
+			return m.TopFiles[i].Issues > m.TopFiles[j].Issues
+		}
+		return m.TopFiles[i].File < m.TopFiles[j].File
+	})
+	if len(m.TopFiles) > topFilesCount {
+		m.TopFiles = m.TopFiles[:topFilesCount]
+	}
+
+	return m
+}
@@ -2,24 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/user/code-review-assistant/internal/config"
 	"github.com/user/code-review-assistant/internal/ml"
-	"github.com/user/code-review-assistant/internal/models"
 )
 
-// RecordIssue records an issue for machine learning
-func RecordIssue(issue *models.Issue, repository string, cfg *config.Config) error {
-	// Create learning engine
-	engine, err := ml.NewLearningEngine(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to create learning engine: %w", err)
-	}
-
-	// Record issue
-	return engine.RecordIssue(issue, repository)
-}
-
 // RecordFeedback records feedback for an issue
 func RecordFeedback(issueID string, accepted bool, cfg *config.Config) error {
 	// Create learning engine
@@ -27,43 +15,41 @@ func RecordFeedback(issueID string, accepted bool, cfg *config.Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to create learning engine: %w", err)
 	}
+	defer engine.Close()
 
 	// Record feedback
 	return engine.RecordFeedback(issueID, accepted)
 }
 
-// ApplyLearning applies machine learning to improve analysis results
-func ApplyLearning(issues []*models.Issue, repository string, cfg *config.Config) ([]*models.Issue, []string, error) {
-	if !cfg.EnableLearning {
-		return issues, nil, nil
-	}
-
-	// Create learning engine
+// RuleStats returns the acceptance statistics of every rule from the machine learning data
+func RuleStats(cfg *config.Config) ([]*ml.RuleStats, error) {
 	engine, err := ml.NewLearningEngine(cfg)
 	if err != nil {
-		return issues, nil, fmt.Errorf("failed to create learning engine: %w", err)
+		return nil, fmt.Errorf("failed to create learning engine: %w", err)
 	}
+	defer engine.Close()
 
-	// Adjust issue confidence
-	for _, issue := range issues {
-		engine.AdjustIssueConfidence(issue)
-	}
-
-	// Filter issues
-	filteredIssues := engine.FilterIssues(issues)
+	return engine.RuleStats(time.Now()), nil
+}
 
-	// Sort issues
-	sortedIssues := engine.SortIssues(filteredIssues)
+// ExportLearning writes the machine learning data to a file to share with other machines
+func ExportLearning(path string, cfg *config.Config) error {
+	engine, err := ml.NewLearningEngine(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create learning engine: %w", err)
+	}
+	defer engine.Close()
 
-	// Get project insights
-	insights := engine.AnalyzeProjectPatterns(repository, issues)
+	return engine.Export(path)
+}
 
-	// Add custom rule suggestions
-	customRules := engine.SuggestCustomRules()
-	if len(customRules) > 0 {
-		insights = append(insights, "Suggested custom rules:")
-		insights = append(insights, customRules...)
+// ImportLearning merges a file written by ExportLearning into the machine learning data
+func ImportLearning(path string, cfg *config.Config) (*ml.ImportResult, error) {
+	engine, err := ml.NewLearningEngine(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create learning engine: %w", err)
 	}
+	defer engine.Close()
 
-	return sortedIssues, insights, nil
+	return engine.Import(path)
 }
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -11,6 +15,7 @@ type File struct {
 	Size     int64     // File size in bytes
 	ModTime  time.Time // Last modification time
 	IsVendor bool      // Whether the file is in a vendor directory
+	Lines    int       // Number of lines in the file
 }
 
 // Repository represents a code repository
@@ -32,6 +37,21 @@ type Issue struct {
 	Suggestion string // Suggested fix for the issue
 	Code       string // The problematic code snippet
 	Rule       string // The rule that triggered the issue
+	ID         string // Stable identifier of the issue, see Fingerprint
+}
+
+// Fingerprint returns an identifier of the issue that survives unrelated edits. It hashes
+// the rule, the file, and the code snippet with whitespace normalized, falling back to the
+// message when no snippet is available, so it does not depend on the line number.
+func (i *Issue) Fingerprint() string {
+	content := i.Code
+	if content == "" {
+		content = i.Message
+	}
+	content = strings.Join(strings.Fields(content), " ")
+
+	hash := sha256.Sum256([]byte(i.Rule + "\x00" + filepath.ToSlash(i.File) + "\x00" + content))
+	return hex.EncodeToString(hash[:8])
 }
 
 // Function represents a function or method in the code
@@ -70,6 +90,7 @@ type Optimization struct {
 	Description string // Description of the optimization
 	Benefit     string // Expected benefit of the optimization
 	Example     string // Example code with the optimization applied
+	Rule        string // The rule that suggested the optimization
 }
 
 // LearningData represents data used for machine learning
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/user/code-review-assistant/internal/optimization"
@@ -9,11 +10,16 @@ import (
 	"github.com/user/code-review-assistant/internal/models"
 )
 
-// AnalyzeOptimizations analyzes a repository for optimization opportunities
-func AnalyzeOptimizations(files []*models.File, cfg *config.Config) error {
+// AnalyzeOptimizations analyzes a repository for optimization opportunities. With fix set,
+// the optimizations that can be applied automatically are applied to the files first.
+func AnalyzeOptimizations(files []*models.File, cfg *config.Config, fix bool) error {
 	// Create optimization analyzer
 	analyzer := optimization.NewAnalyzer(cfg)
 
+	if fix {
+		FixOptimizations(os.Stdout, files, cfg)
+	}
+
 	// Analyze files
 	optimizations, err := analyzer.Analyze(files)
 	if err != nil {
@@ -28,3 +34,21 @@ func AnalyzeOptimizations(files []*models.File, cfg *config.Config) error {
 
 	return nil
 }
+
+// FixOptimizations applies the optimizations that have an automatic fix to the files,
+// reporting the fixes applied to each file on w
+func FixOptimizations(w io.Writer, files []*models.File, cfg *config.Config) {
+	analyzer := optimization.NewAnalyzer(cfg)
+	for _, file := range files {
+		fixed, err := analyzer.Fix(file)
+		if err != nil {
+			if cfg.Verbose {
+				fmt.Fprintf(os.Stderr, "Error fixing file %s: %v\n", file.Path, err)
+			}
+			continue
+		}
+		if fixed > 0 {
+			fmt.Fprintf(w, "Applied %d fixes to %s\n", fixed, file.RelPath)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package optimization
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+
+	"github.com/user/code-review-assistant/internal/config"
+	"github.com/user/code-review-assistant/internal/models"
+	"github.com/user/code-review-assistant/internal/typecheck"
+)
+
+// Analyzer is responsible for finding optimization opportunities in code
+type Analyzer struct {
+	config *config.Config
+	fset   *token.FileSet
+	rules  []*OptimizationRule
+}
+
+// NewAnalyzer creates a new optimization analyzer
+func NewAnalyzer(cfg *config.Config) *Analyzer {
+	a := &Analyzer{
+		config: cfg,
+		fset:   token.NewFileSet(),
+	}
+
+	// Only keep the rules that are enabled in the configuration
+	if cfg.CategoryEnabled("performance") {
+		for _, rule := range GetOptimizationRules(cfg) {
+			if cfg.RuleEnabled(rule.ID, rule.Name) {
+				a.rules = append(a.rules, rule)
+			}
+		}
+	}
+
+	return a
+}
+
+// Analyze analyzes a list of files and returns the optimizations found
+func (a *Analyzer) Analyze(files []*models.File) ([]*models.Optimization, error) {
+	optimizations := make([]*models.Optimization, 0)
+
+	// Type-check the packages of the files once, directory by directory
+	typed := typecheck.NewCache(a.fset)
+	if a.config.Verbose {
+		typed.OnError = typeError
+	}
+
+	for _, file := range files {
+		fileOptimizations, err := a.analyzeFile(file, typed.File(file.Path))
+		if err != nil {
+			if a.config.Verbose {
+				fmt.Fprintf(os.Stderr, "Error analyzing file %s: %v\n", file.Path, err)
+			}
+			continue
+		}
+		optimizations = append(optimizations, fileOptimizations...)
+	}
+
+	return optimizations, nil
+}
+
+// typeError reports a package that could not be type-checked
+func typeError(dir string, err error) {
+	fmt.Fprintf(os.Stderr, "Type information unavailable for %s, using heuristics: %v\n", dir, err)
+}
+
+// analyzeFile analyzes a single file and returns the optimizations found, using the type
+// information of the file when it is available
+func (a *Analyzer) analyzeFile(file *models.File, typed *typecheck.File) ([]*models.Optimization, error) {
+	if typed != nil {
+		return a.inspect(file, typed.Syntax, typed.Info), nil
+	}
+
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	astFile, err := parser.ParseFile(a.fset, file.Path, content, parser.AllErrors)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.inspect(file, astFile, nil), nil
+}
+
+// analyzeSource analyzes the contents of a file and returns the optimizations found. The
+// file's package is type-checked with the contents in place of the file on disk.
+func (a *Analyzer) analyzeSource(file *models.File, content []byte) ([]*models.Optimization, error) {
+	path := typecheck.AbsPath(file.Path)
+	typed, err := typecheck.LoadDir(a.fset, filepath.Dir(path), map[string][]byte{path: content})
+	if err != nil && a.config.Verbose {
+		typeError(filepath.Dir(path), err)
+	}
+	if t, ok := typed[path]; ok {
+		return a.inspect(file, t.Syntax, t.Info), nil
+	}
+
+	astFile, err := parser.ParseFile(a.fset, file.Path, content, parser.AllErrors)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.inspect(file, astFile, nil), nil
+}
+
+// inspect applies the rules to every node of a syntax tree. info is nil when the file
+// could not be type-checked.
+func (a *Analyzer) inspect(file *models.File, astFile *ast.File, info *types.Info) []*models.Optimization {
+	var optimizations []*models.Optimization
+	ast.Inspect(astFile, func(node ast.Node) bool {
+		if node == nil {
+			return true
+		}
+
+		for _, rule := range a.rules {
+			for _, opt := range rule.Detector(a.fset, info, node) {
+				// Set relative path for consistent reporting
+				opt.File = file.RelPath
+				opt.Rule = rule.ID
+				if opt.Example == "" {
+					opt.Example = rule.Example
+				}
+				optimizations = append(optimizations, opt)
+			}
+		}
+
+		return true
+	})
+
+	return optimizations
+}
+
+// Fix applies every automatic fix available for the optimizations found in a file and
+// writes the file back if it changed. It returns the number of fixes applied.
+func (a *Analyzer) Fix(file *models.File) (int, error) {
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return 0, err
+	}
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	// Every fix moves code around, so the file is analyzed again after each one
+	fixed := 0
+	for {
+		optimizations, err := a.analyzeSource(file, content)
+		if err != nil {
+			return fixed, err
+		}
+
+		applied := false
+		for _, opt := range optimizations {
+			if !CanFix(opt) {
+				continue
+			}
+			result, err := ApplyFix(opt, content)
+			if errors.Is(err, ErrNotFixable) {
+				continue
+			}
+			if err != nil {
+				return fixed, fmt.Errorf("failed to apply fix for %s at line %d: %w", opt.Rule, opt.Line, err)
+			}
+			content = result
+			applied = true
+			fixed++
+			break
+		}
+		if !applied {
+			break
+		}
+	}
+
+	if fixed == 0 {
+		return 0, nil
+	}
+	return fixed, os.WriteFile(file.Path, content, info.Mode().Perm())
+}
+
+// FormatOptimizations formats a list of optimizations as a string
+func (a *Analyzer) FormatOptimizations(optimizations []*models.Optimization) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("Optimization Suggestions:\n")
+	buf.WriteString("=========================\n")
+
+	if len(optimizations) == 0 {
+		buf.WriteString("No optimizations found!\n")
+		return buf.String()
+	}
+
+	for _, opt := range optimizations {
+		buf.WriteString(fmt.Sprintf("%s:%d: %s\n", opt.File, opt.Line, opt.Description))
+		buf.WriteString(fmt.Sprintf("  Benefit: %s\n", opt.Benefit))
+		if opt.Example != "" {
+			buf.WriteString("  Example:\n")
+			buf.WriteString(indent(opt.Example, "    "))
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString(fmt.Sprintf("Total optimizations: %d\n", len(optimizations)))
+
+	return buf.String()
+}
+
+// ToIssues converts optimizations to performance issues, so that they can be reported with
+// the issues of an analysis. The multi-line example is left out of the issues, which point
+// to -explain for it instead.
+func ToIssues(optimizations []*models.Optimization) []*models.Issue {
+	issues := make([]*models.Issue, 0, len(optimizations))
+	for _, opt := range optimizations {
+		issue := &models.Issue{
+			File:       opt.File,
+			Line:       opt.Line,
+			Message:    opt.Description,
+			Category:   "performance",
+			Severity:   "low",
+			Confidence: "medium",
+			Suggestion: opt.Benefit,
+			Rule:       opt.Rule,
+		}
+		if opt.Example != "" {
+			issue.Suggestion += fmt.Sprintf(" (see -explain %s for an example)", opt.Rule)
+		}
+		issue.ID = issue.Fingerprint()
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// indent prefixes every line of a multi-line string
+func indent(s, prefix string) string {
+	var buf bytes.Buffer
+	for _, line := range bytes.Split([]byte(s), []byte("\n")) {
+		buf.WriteString(prefix)
+		buf.Write(line)
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
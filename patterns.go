@@ -1,10 +1,12 @@
 package patterns
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 	"strings"
 
+	"github.com/user/code-review-assistant/internal/config"
 	"github.com/user/code-review-assistant/internal/models"
 )
 
@@ -14,11 +16,13 @@ type Pattern struct {
 	Description string
 	Category    string
 	Severity    string
-	Detector    func(fset *token.FileSet, node ast.Node) *models.Issue
+	Suggestion  string // Suggested fix for the issues found
+	Detector    func(fset *token.FileSet, node ast.Node) []*models.Issue
 }
 
-// GetGoPatterns returns a list of Go-specific code patterns to detect
-func GetGoPatterns() []*Pattern {
+// GetGoPatterns returns a list of Go-specific code patterns to detect, with the limits
+// of the configuration
+func GetGoPatterns(cfg *config.Config) []*Pattern {
 	return []*Pattern{
 		// Empty function pattern
 		{
@@ -26,23 +30,26 @@ func GetGoPatterns() []*Pattern {
 			Description: "Function with an empty body",
 			Category:    "code-smell",
 			Severity:    "low",
+			Suggestion:  "Consider implementing the function or removing it if not needed",
 			Detector:    detectEmptyFunction,
 		},
 		// Too many parameters pattern
 		{
 			Name:        "too-many-params",
-			Description: "Function with too many parameters",
+			Description: fmt.Sprintf("Function with more than max_params (%d) parameters", cfg.MaxParams),
 			Category:    "code-smell",
 			Severity:    "medium",
-			Detector:    detectTooManyParams,
+			Suggestion:  "Consider refactoring to use a struct for parameters",
+			Detector:    tooManyParams(cfg.MaxParams),
 		},
 		// Long function pattern
 		{
 			Name:        "long-function",
-			Description: "Function that is too long",
+			Description: fmt.Sprintf("Function longer than max_function_lines (%d) lines", cfg.MaxFunctionLines),
 			Category:    "code-smell",
 			Severity:    "medium",
-			Detector:    detectLongFunction,
+			Suggestion:  "Consider breaking down the function into smaller, more focused functions",
+			Detector:    longFunction(cfg.MaxFunctionLines),
 		},
 		// Deeply nested code pattern
 		{
@@ -74,6 +81,7 @@ func GetGoPatterns() []*Pattern {
 			Description: "Boolean parameter in function signature",
 			Category:    "code-smell",
 			Severity:    "low",
+			Suggestion:  "Consider using an enum type or constants for better readability and extensibility",
 			Detector:    detectBooleanParam,
 		},
 		// Magic number pattern
@@ -90,6 +98,7 @@ func GetGoPatterns() []*Pattern {
 			Description: "Exported function without documentation",
 			Category:    "documentation",
 			Severity:    "medium",
+			Suggestion:  "Add documentation comments to describe the function's purpose, parameters, and return values",
 			Detector:    detectUndocumentedExported,
 		},
 		// Inefficient string concatenation
@@ -104,7 +113,7 @@ func GetGoPatterns() []*Pattern {
 }
 
 // detectEmptyFunction detects functions with empty bodies
-func detectEmptyFunction(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectEmptyFunction(fset *token.FileSet, node ast.Node) []*models.Issue {
 	funcDecl, ok := node.(*ast.FuncDecl)
 	if !ok {
 		return nil
@@ -112,7 +121,7 @@ func detectEmptyFunction(fset *token.FileSet, node ast.Node) *models.Issue {
 
 	if funcDecl.Body != nil && len(funcDecl.Body.List) == 0 {
 		pos := fset.Position(funcDecl.Pos())
-		return &models.Issue{
+		return []*models.Issue{{
 			File:       pos.Filename,
 			Line:       pos.Line,
 			Column:     pos.Column,
@@ -120,126 +129,141 @@ func detectEmptyFunction(fset *token.FileSet, node ast.Node) *models.Issue {
 			Category:   "code-smell",
 			Severity:   "low",
 			Confidence: "high",
-			Suggestion: "Consider implementing the function or removing it if not needed",
 			Rule:       "empty-function",
-		}
+		}}
 	}
 
 	return nil
 }
 
-// detectTooManyParams detects functions with too many parameters
-func detectTooManyParams(fset *token.FileSet, node ast.Node) *models.Issue {
-	funcDecl, ok := node.(*ast.FuncDecl)
-	if !ok {
-		return nil
-	}
+// tooManyParams returns a detector of functions with more than maxParams parameters. A
+// maxParams of zero disables the detector.
+func tooManyParams(maxParams int) func(fset *token.FileSet, node ast.Node) []*models.Issue {
+	return func(fset *token.FileSet, node ast.Node) []*models.Issue {
+		funcDecl, ok := node.(*ast.FuncDecl)
+		if !ok || funcDecl.Type.Params == nil || maxParams <= 0 {
+			return nil
+		}
+
+		// Parameters sharing a type are counted separately, unnamed ones count as one
+		count := 0
+		for _, field := range funcDecl.Type.Params.List {
+			count += len(field.Names)
+			if len(field.Names) == 0 {
+				count++
+			}
+		}
+		if count <= maxParams {
+			return nil
+		}
 
-	if funcDecl.Type.Params != nil && len(funcDecl.Type.Params.List) > 5 {
 		pos := fset.Position(funcDecl.Pos())
-		return &models.Issue{
+		return []*models.Issue{{
 			File:       pos.Filename,
 			Line:       pos.Line,
 			Column:     pos.Column,
-			Message:    "Function '" + funcDecl.Name.Name + "' has too many parameters (" + string(rune('0'+len(funcDecl.Type.Params.List))) + ")",
+			Message:    fmt.Sprintf("Function '%s' has too many parameters (%d, maximum %d)", funcDecl.Name.Name, count, maxParams),
 			Category:   "code-smell",
 			Severity:   "medium",
 			Confidence: "high",
-			Suggestion: "Consider refactoring to use a struct for parameters",
 			Rule:       "too-many-params",
-		}
+		}}
 	}
-
-	return nil
 }
 
-// detectLongFunction detects functions that are too long
-func detectLongFunction(fset *token.FileSet, node ast.Node) *models.Issue {
-	funcDecl, ok := node.(*ast.FuncDecl)
-	if !ok || funcDecl.Body == nil {
-		return nil
-	}
+// longFunction returns a detector of functions whose body spans more than maxLines lines.
+// A maxLines of zero disables the detector.
+func longFunction(maxLines int) func(fset *token.FileSet, node ast.Node) []*models.Issue {
+	return func(fset *token.FileSet, node ast.Node) []*models.Issue {
+		funcDecl, ok := node.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil || maxLines <= 0 {
+			return nil
+		}
 
-	startPos := fset.Position(funcDecl.Body.Lbrace)
-	endPos := fset.Position(funcDecl.Body.Rbrace)
-	lineCount := endPos.Line - startPos.Line
+		startPos := fset.Position(funcDecl.Body.Lbrace)
+		endPos := fset.Position(funcDecl.Body.Rbrace)
+		lineCount := endPos.Line - startPos.Line
+		if lineCount <= maxLines {
+			return nil
+		}
 
-	if lineCount > 50 {
 		pos := fset.Position(funcDecl.Pos())
-		return &models.Issue{
+		return []*models.Issue{{
 			File:       pos.Filename,
 			Line:       pos.Line,
 			Column:     pos.Column,
-			Message:    "Function '" + funcDecl.Name.Name + "' is too long (" + string(rune('0'+lineCount/10)) + string(rune('0'+lineCount%10)) + " lines)",
+			Message:    fmt.Sprintf("Function '%s' is too long (%d lines, maximum %d)", funcDecl.Name.Name, lineCount, maxLines),
 			Category:   "code-smell",
 			Severity:   "medium",
 			Confidence: "high",
-			Suggestion: "Consider breaking down the function into smaller, more focused functions",
 			Rule:       "long-function",
-		}
+		}}
 	}
-
-	return nil
 }
 
 // detectDeepNesting detects deeply nested control structures
-func detectDeepNesting(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectDeepNesting(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Implementation will be added
 	return nil
 }
 
 // detectNakedReturn detects naked returns in functions with named return values
-func detectNakedReturn(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectNakedReturn(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Implementation will be added
 	return nil
 }
 
 // detectUnusedParam detects unused function parameters
-func detectUnusedParam(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectUnusedParam(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Implementation will be added
 	return nil
 }
 
 // detectBooleanParam detects boolean parameters in function signatures
-func detectBooleanParam(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectBooleanParam(fset *token.FileSet, node ast.Node) []*models.Issue {
 	funcDecl, ok := node.(*ast.FuncDecl)
 	if !ok || funcDecl.Type.Params == nil {
 		return nil
 	}
 
+	var issues []*models.Issue
 	for _, field := range funcDecl.Type.Params.List {
 		ident, ok := field.Type.(*ast.Ident)
-		if ok && ident.Name == "bool" {
-			pos := fset.Position(field.Pos())
-			paramName := ""
-			if len(field.Names) > 0 {
-				paramName = field.Names[0].Name
-			}
-			return &models.Issue{
+		if !ok || ident.Name != "bool" {
+			continue
+		}
+
+		// Report each parameter of a field like "a, b bool" separately
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{NamePos: field.Pos()}}
+		}
+		for _, name := range names {
+			pos := fset.Position(name.Pos())
+			issues = append(issues, &models.Issue{
 				File:       pos.Filename,
 				Line:       pos.Line,
 				Column:     pos.Column,
-				Message:    "Function '" + funcDecl.Name.Name + "' has boolean parameter '" + paramName + "'",
+				Message:    "Function '" + funcDecl.Name.Name + "' has boolean parameter '" + name.Name + "'",
 				Category:   "code-smell",
 				Severity:   "low",
 				Confidence: "medium",
-				Suggestion: "Consider using an enum type or constants for better readability and extensibility",
 				Rule:       "boolean-param",
-			}
+			})
 		}
 	}
 
-	return nil
+	return issues
 }
 
 // detectMagicNumber detects magic numbers in code
-func detectMagicNumber(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectMagicNumber(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Implementation will be added
 	return nil
 }
 
 // detectUndocumentedExported detects exported functions without documentation
-func detectUndocumentedExported(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectUndocumentedExported(fset *token.FileSet, node ast.Node) []*models.Issue {
 	funcDecl, ok := node.(*ast.FuncDecl)
 	if !ok {
 		return nil
@@ -250,7 +274,7 @@ func detectUndocumentedExported(fset *token.FileSet, node ast.Node) *models.Issu
 		// Check if function has a doc comment
 		if funcDecl.Doc == nil || len(funcDecl.Doc.List) == 0 {
 			pos := fset.Position(funcDecl.Pos())
-			return &models.Issue{
+			return []*models.Issue{{
 				File:       pos.Filename,
 				Line:       pos.Line,
 				Column:     pos.Column,
@@ -258,9 +282,8 @@ func detectUndocumentedExported(fset *token.FileSet, node ast.Node) *models.Issu
 				Category:   "documentation",
 				Severity:   "medium",
 				Confidence: "high",
-				Suggestion: "Add documentation comments to describe the function's purpose, parameters, and return values",
 				Rule:       "undocumented-exported",
-			}
+			}}
 		}
 	}
 
@@ -268,7 +291,7 @@ func detectUndocumentedExported(fset *token.FileSet, node ast.Node) *models.Issu
 }
 
 // detectInefficientStringConcat detects inefficient string concatenation in loops
-func detectInefficientStringConcat(fset *token.FileSet, node ast.Node) *models.Issue {
+func detectInefficientStringConcat(fset *token.FileSet, node ast.Node) []*models.Issue {
 	// Implementation will be added
 	return nil
 }
@@ -1,27 +1,38 @@
 package prsummary
 
 import (
+	"bufio"
+	"context"
 	"bytes"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/user/code-review-assistant/internal/analyzer"
 	"github.com/user/code-review-assistant/internal/config"
 	"github.com/user/code-review-assistant/internal/models"
 )
 
 // PRSummaryGenerator generates summaries of pull requests
 type PRSummaryGenerator struct {
-	config *config.Config
+	config       *config.Config
+	httpClient   *http.Client
+	githubAPIURL string
 }
 
 // NewPRSummaryGenerator creates a new PR summary generator
 func NewPRSummaryGenerator(cfg *config.Config) *PRSummaryGenerator {
 	return &PRSummaryGenerator{
-		config: cfg,
+		config:       cfg,
+		httpClient:   &http.Client{Timeout: defaultGitHubTimeout},
+		githubAPIURL: DefaultGitHubAPIURL,
 	}
 }
 
@@ -50,18 +61,201 @@ func (g *PRSummaryGenerator) GenerateSummary(repoPath, baseRef, headRef string)
 	// Identify key changes
 	keyChanges := g.identifyKeyChanges(repoPath, baseRef, headRef, changedFiles)
 
+	// Analyze the changed Go files for potential issues
+	potentialIssues, err := g.findPotentialIssues(repoPath, baseRef, headRef, changedFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze changed files: %w", err)
+	}
+
 	// Create summary
 	summary := &models.PRSummary{
-		FilesChanged:  stats.filesChanged,
-		Additions:     stats.additions,
-		Deletions:     stats.deletions,
-		KeyChanges:    keyChanges,
-		AffectedAreas: affectedAreas,
+		FilesChanged:    stats.filesChanged,
+		Additions:       stats.additions,
+		Deletions:       stats.deletions,
+		KeyChanges:      keyChanges,
+		AffectedAreas:   affectedAreas,
+		PotentialIssues: potentialIssues,
 	}
 
 	return summary, nil
 }
 
+// findPotentialIssues runs the code analyzer on the changed Go files as of headRef, which
+// the line numbers of the diff refer to whatever the state of the working tree, and returns
+// the issues reported on lines touched by the diff. gosec, which scans the files on disk,
+// is not run.
+func (g *PRSummaryGenerator) findPotentialIssues(repoPath, baseRef, headRef string, changedFiles []string) ([]*models.Issue, error) {
+	changedLines, err := ChangedLineRanges(repoPath, baseRef, headRef)
+	if err != nil {
+		return nil, err
+	}
+
+	a := analyzer.NewAnalyzer(repoPath, g.config)
+	var issues []*models.Issue
+	for _, relPath := range changedFiles {
+		// Deleted files and pure deletions have no changed lines
+		if !strings.HasSuffix(relPath, ".go") || len(changedLines[relPath]) == 0 {
+			continue
+		}
+
+		content, err := fileAtRef(repoPath, headRef, relPath)
+		if err != nil {
+			return nil, err
+		}
+		file := &models.File{
+			Path:     filepath.Join(repoPath, relPath),
+			RelPath:  relPath,
+			Size:     int64(len(content)),
+			Lines:    bytes.Count(content, []byte{'\n'}),
+			IsVendor: strings.Contains(relPath, "vendor/"),
+		}
+
+		results, err := a.AnalyzeSource(context.Background(), file, content)
+		if err != nil {
+			if g.config.Verbose {
+				fmt.Fprintf(os.Stderr, "Warning: failed to analyze %s: %v\n", relPath, err)
+			}
+			continue
+		}
+		issues = append(issues, results.Issues...)
+	}
+
+	return changedLines.Filter(issues), nil
+}
+
+// fileAtRef returns the contents of a file, relative to the repository root, at a Git reference
+func fileAtRef(repoPath, ref, relPath string) ([]byte, error) {
+	cmd := exec.Command("git", "show", ref+":"+relPath)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w", relPath, ref, err)
+	}
+	return output, nil
+}
+
+// LineRange represents an inclusive range of line numbers
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// ChangedLines holds the ranges of added or modified lines by file path relative to the
+// repository root, using / as separator
+type ChangedLines map[string][]LineRange
+
+// Contains reports whether a line of a file was added or modified
+func (c ChangedLines) Contains(file string, line int) bool {
+	for _, r := range c[filepath.ToSlash(file)] {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the issues that are on added or modified lines
+func (c ChangedLines) Filter(issues []*models.Issue) []*models.Issue {
+	filtered := make([]*models.Issue, 0, len(issues))
+	for _, issue := range issues {
+		if c.Contains(issue.File, issue.Line) {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
+
+// Patterns of the summary printed by git diff --stat, e.g. " 2 files changed, 3 insertions(+), 1 deletion(-)"
+var (
+	filesChangedPattern = regexp.MustCompile(`(\d+) files? changed`)
+	insertionsPattern   = regexp.MustCompile(`(\d+) insertions?\(\+\)`)
+	deletionsPattern    = regexp.MustCompile(`(\d+) deletions?\(-\)`)
+)
+
+// statLinePattern matches the line of a file in the output of git diff --stat, capturing the
+// file and its number of changed lines
+var statLinePattern = regexp.MustCompile(`(.+?)\s+\|\s+(\d+)\s+`)
+
+// interfacePattern matches interface type declarations, capturing the name of the interface
+var interfacePattern = regexp.MustCompile(`type\s+(\w+)\s+interface`)
+
+// hunkHeaderPattern matches the new-file range of a unified diff hunk header, e.g. "@@ -10,2 +12,3 @@"
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// ChangedLineRanges returns the ranges of added or modified lines per file between two Git
+// references, or between the merge base of baseRef and HEAD and the working tree, staged
+// and unstaged changes included, when headRef is empty
+func ChangedLineRanges(repoPath, baseRef, headRef string) (ChangedLines, error) {
+	args := []string{"diff", "--unified=0", "--no-color"}
+	if headRef == "" {
+		args = append(args, "--merge-base", baseRef)
+	} else {
+		args = append(args, fmt.Sprintf("%s...%s", baseRef, headRef))
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff: %w", err)
+	}
+
+	return ParseChangedLineRanges(string(output)), nil
+}
+
+// WorkingTreeAt reports whether the tracked files of the working tree, staged changes
+// included, are those of a Git reference
+func WorkingTreeAt(repoPath, ref string) (bool, error) {
+	cmd := exec.Command("git", "diff", "--quiet", ref)
+	cmd.Dir = repoPath
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to compare the working tree with %s: %w", ref, err)
+	}
+	return true, nil
+}
+
+// ParseChangedLineRanges parses unified diff output into the ranges of added or modified lines per file
+func ParseChangedLineRanges(diff string) ChangedLines {
+	ranges := make(ChangedLines)
+
+	currentFile := ""
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			// New file name, "/dev/null" for deleted files
+			currentFile = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "@@"):
+			match := hunkHeaderPattern.FindStringSubmatch(line)
+			if match == nil || currentFile == "" {
+				continue
+			}
+
+			start, _ := strconv.Atoi(match[1])
+			count := 1
+			if match[2] != "" {
+				count, _ = strconv.Atoi(match[2])
+			}
+
+			// Pure deletions have no lines in the new file
+			if count > 0 {
+				ranges[currentFile] = append(ranges[currentFile], LineRange{Start: start, End: start + count - 1})
+			}
+		}
+	}
+
+	return ranges
+}
+
 // diffStats represents statistics about a diff
 type diffStats struct {
 	filesChanged int
@@ -84,13 +278,13 @@ func (g *PRSummaryGenerator) getDiffStats(repoPath, baseRef, headRef string) (*d
 	outputStr := string(output)
 
 	// Example output: " 2 files changed, 3 insertions(+), 1 deletion(-)"
-	if match := regexp.MustCompile(`(\d+) files? changed`).FindStringSubmatch(outputStr); len(match) > 1 {
+	if match := filesChangedPattern.FindStringSubmatch(outputStr); len(match) > 1 {
 		fmt.Sscanf(match[1], "%d", &stats.filesChanged)
 	}
-	if match := regexp.MustCompile(`(\d+) insertions?\(\+\)`).FindStringSubmatch(outputStr); len(match) > 1 {
+	if match := insertionsPattern.FindStringSubmatch(outputStr); len(match) > 1 {
 		fmt.Sscanf(match[1], "%d", &stats.additions)
 	}
-	if match := regexp.MustCompile(`(\d+) deletions?\(-\)`).FindStringSubmatch(outputStr); len(match) > 1 {
+	if match := deletionsPattern.FindStringSubmatch(outputStr); len(match) > 1 {
 		fmt.Sscanf(match[1], "%d", &stats.deletions)
 	}
 
@@ -121,6 +315,39 @@ func (g *PRSummaryGenerator) getChangedFiles(repoPath, baseRef, headRef string)
 	return result, nil
 }
 
+// ChangedFiles returns the files of a repository changed since a Git reference: those
+// changed between the merge base of the reference and HEAD, and the staged, unstaged, and
+// untracked files of the working tree. Deleted files are left out. When repoPath is a
+// directory of the repository, only the files in it are listed. The paths are relative to
+// repoPath.
+func ChangedFiles(repoPath, ref string) ([]string, error) {
+	commands := [][]string{
+		{"diff", "--name-only", "--relative", "--diff-filter=d", "-z", ref + "...HEAD"},
+		{"diff", "--name-only", "--relative", "--diff-filter=d", "-z", "HEAD"},
+		{"ls-files", "--others", "--exclude-standard", "-z"},
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files changed since %s: %w", ref, err)
+		}
+		for _, file := range strings.Split(string(output), "\x00") {
+			if file != "" && !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files, nil
+}
+
 // analyzeAffectedAreas analyzes which areas of the codebase are affected by changes
 func (g *PRSummaryGenerator) analyzeAffectedAreas(changedFiles []string) []string {
 	// Map to track unique areas
@@ -161,10 +388,19 @@ func (g *PRSummaryGenerator) analyzeAffectedAreas(changedFiles []string) []strin
 
 // identifyKeyChanges identifies key changes between two Git references
 func (g *PRSummaryGenerator) identifyKeyChanges(repoPath, baseRef, headRef string, changedFiles []string) []string {
+	newFiles, _ := g.getNewFiles(repoPath, baseRef, headRef)
+	deletedFiles, _ := g.getDeletedFiles(repoPath, baseRef, headRef)
+	largeChanges, _ := g.findLargeChanges(repoPath, baseRef, headRef)
+	interfaceChanges, _ := g.findInterfaceChanges(repoPath, baseRef, headRef)
+
+	return g.summarizeKeyChanges(changedFiles, newFiles, deletedFiles, largeChanges, interfaceChanges)
+}
+
+// summarizeKeyChanges describes the key changes of a diff
+func (g *PRSummaryGenerator) summarizeKeyChanges(changedFiles, newFiles, deletedFiles []string, largeChanges []largeChange, interfaceChanges []string) []string {
 	var keyChanges []string
 
 	// Check for new files
-	newFiles, _ := g.getNewFiles(repoPath, baseRef, headRef)
 	if len(newFiles) > 0 {
 		if len(newFiles) <= 3 {
 			keyChanges = append(keyChanges, fmt.Sprintf("Added new files: %s", strings.Join(newFiles, ", ")))
@@ -174,7 +410,6 @@ func (g *PRSummaryGenerator) identifyKeyChanges(repoPath, baseRef, headRef strin
 	}
 
 	// Check for deleted files
-	deletedFiles, _ := g.getDeletedFiles(repoPath, baseRef, headRef)
 	if len(deletedFiles) > 0 {
 		if len(deletedFiles) <= 3 {
 			keyChanges = append(keyChanges, fmt.Sprintf("Deleted files: %s", strings.Join(deletedFiles, ", ")))
@@ -190,13 +425,11 @@ func (g *PRSummaryGenerator) identifyKeyChanges(repoPath, baseRef, headRef strin
 	}
 
 	// Check for large changes
-	largeChanges, _ := g.findLargeChanges(repoPath, baseRef, headRef)
 	for _, change := range largeChanges {
 		keyChanges = append(keyChanges, fmt.Sprintf("Large change to %s (%d lines)", change.file, change.lines))
 	}
 
 	// Check for changes to interfaces
-	interfaceChanges, _ := g.findInterfaceChanges(repoPath, baseRef, headRef)
 	for _, change := range interfaceChanges {
 		keyChanges = append(keyChanges, fmt.Sprintf("Modified interface: %s", change))
 	}
@@ -304,13 +537,12 @@ func (g *PRSummaryGenerator) findLargeChanges(repoPath, baseRef, headRef string)
 	lines := strings.Split(string(output), "\n")
 	
 	for _, line := range lines {
-		if match := regexp.MustCompile(`(.+?)\s+\|\s+(\d+)\s+`).FindStringSubmatch(line); len(match) > 2 {
+		if match := statLinePattern.FindStringSubmatch(line); len(match) > 2 {
 			file := strings.TrimSpace(match[1])
 			var lineCount int
 			fmt.Sscanf(match[2], "%d", &lineCount)
 			
-			// Consider changes with more than 50 lines as large
-			if lineCount > 50 {
+			if g.config.LargeChangeLines > 0 && lineCount > g.config.LargeChangeLines {
 				largeChanges = append(largeChanges, largeChange{
 					file:  file,
 					lines: lineCount,
@@ -332,20 +564,25 @@ func (g *PRSummaryGenerator) findInterfaceChanges(repoPath, baseRef, headRef str
 		return nil, err
 	}
 
+	return parseInterfaceChanges(string(output)), nil
+}
+
+// parseInterfaceChanges extracts the names of interfaces whose definitions appear in a diff
+func parseInterfaceChanges(diff string) []string {
 	// Look for interface definitions in the diff
 	var interfaceChanges []string
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(diff, "\n")
 	
-	for i, line := range lines {
+	for _, line := range lines {
 		if strings.Contains(line, "type") && strings.Contains(line, "interface") {
 			// Extract interface name
-			if match := regexp.MustCompile(`type\s+(\w+)\s+interface`).FindStringSubmatch(line); len(match) > 1 {
+			if match := interfacePattern.FindStringSubmatch(line); len(match) > 1 {
 				interfaceChanges = append(interfaceChanges, match[1])
 			}
 		}
 	}
 
-	return interfaceChanges, nil
+	return interfaceChanges
 }
 
 // isGitRepository checks if a directory is a Git repository
@@ -0,0 +1,57 @@
+// Package review is the public API for embedding the code review assistant in other
+// programs. It exposes the analysis pipeline used by the command-line tool without any
+// of its flag handling or output.
+package review
+
+import (
+	"context"
+
+	"github.com/user/code-review-assistant/internal/analyzer"
+	"github.com/user/code-review-assistant/internal/config"
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// Version is the version of the code review assistant
+const Version = analyzer.Version
+
+// Config configures an analysis run
+type Config = config.Config
+
+// Results holds the issues and metrics found by an analysis run
+type Results = analyzer.Results
+
+// Issue is a single finding
+type Issue = models.Issue
+
+// Function holds the metrics of a function
+type Function = models.Function
+
+// Rule is a custom detector run by every analysis alongside the built-in rules, see
+// RegisterRule. Detect returns issues of type Issue.
+type Rule = analyzer.Rule
+
+// RegisterRule adds a rule to the analysis runs started afterwards, typically from an init
+// function of the package declaring the rule. It panics if the rule has no ID or shares its
+// ID with another registered rule.
+func RegisterRule(rule Rule) {
+	analyzer.RegisterRule(rule)
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() *Config {
+	return config.DefaultConfig()
+}
+
+// LoadConfig loads a configuration file
+func LoadConfig(path string) (*Config, error) {
+	return config.LoadConfig(path)
+}
+
+// Run scans and analyzes the Go files of a repository. A nil configuration uses the
+// defaults. Nothing is printed unless Config.Verbose is set, in which case skipped files
+// are printed to standard output and errors to standard error; see Results.Warnings for
+// non-fatal problems. If the context is cancelled, the results gathered so far are
+// returned with the context's error.
+func Run(ctx context.Context, repoPath string, cfg *Config) (*Results, error) {
+	return analyzer.Run(ctx, repoPath, cfg)
+}
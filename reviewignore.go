@@ -0,0 +1,124 @@
+package reviewignore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// FileName is the name of the ignore file in the repository root
+const FileName = ".reviewignore"
+
+// Entry suppresses the issues of the rules matching Rule in the files matching Path
+type Entry struct {
+	Rule string // Rule ID, may contain the wildcards of path.Match
+	Path string // Glob of the path relative to the repository root, ** matches any number of directories
+}
+
+// List is the set of entries of an ignore file
+type List struct {
+	Entries []Entry
+}
+
+// Load loads an ignore file
+func Load(filename string) (*List, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return list, nil
+}
+
+// Parse reads ignore entries with one "rule path" pair per line. Empty lines and lines
+// starting with # are skipped.
+func Parse(r io.Reader) (*List, error) {
+	list := &List{}
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected a rule ID and a path pattern, got %q", lineNum, line)
+		}
+		entry := Entry{Rule: fields[0], Path: strings.TrimPrefix(fields[1], "./")}
+		for _, pattern := range []string{entry.Rule, entry.Path} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("line %d: invalid pattern %q: %w", lineNum, pattern, err)
+			}
+		}
+		list.Entries = append(list.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Ignored reports whether an issue is suppressed by one of the entries
+func (l *List) Ignored(issue *models.Issue) bool {
+	file := filepath.ToSlash(issue.File)
+	for _, entry := range l.Entries {
+		if ruleMatched, _ := path.Match(entry.Rule, issue.Rule); ruleMatched && matchPath(entry.Path, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the issues that are not suppressed
+func (l *List) Filter(issues []*models.Issue) []*models.Issue {
+	var filtered []*models.Issue
+	for _, issue := range issues {
+		if !l.Ignored(issue) {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
+
+// matchPath matches a slash-separated path against a glob in which a ** element matches
+// zero or more path elements
+func matchPath(pattern, name string) bool {
+	return matchElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchElements matches path elements against pattern elements
+func matchElements(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Try every number of elements for the ** element
+			for i := 0; i <= len(name); i++ {
+				if matchElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
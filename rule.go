@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sync"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// Rule is a detector compiled into a custom binary, see RegisterRule. Like the built-in
+// rules, it is run on every node of the syntax tree of every file analyzed, and can be
+// turned off with disabled_rules or by disabling its category.
+type Rule interface {
+	// ID identifies the rule in issues and configuration, such as disabled_rules
+	ID() string
+	// Category is the category of the issues of the rule, such as best-practice
+	Category() string
+	// Severity is the default severity of the issues of the rule
+	Severity() string
+	// Detect returns the issues found at node. The rule, category, and severity of the
+	// issues are filled in from the rule when left empty.
+	Detect(fset *token.FileSet, node ast.Node) []*models.Issue
+}
+
+var (
+	registeredMu    sync.Mutex
+	registeredRules []Rule
+)
+
+// RegisterRule adds a rule to the analyzers created afterwards, typically from an init
+// function of the package declaring the rule. It panics if the rule has no ID or shares its
+// ID with another registered rule.
+func RegisterRule(rule Rule) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	if rule.ID() == "" {
+		panic("analyzer: RegisterRule called with a rule without ID")
+	}
+	for _, registered := range registeredRules {
+		if registered.ID() == rule.ID() {
+			panic(fmt.Sprintf("analyzer: RegisterRule called twice for rule %s", rule.ID()))
+		}
+	}
+	registeredRules = append(registeredRules, rule)
+}
+
+// RegisteredRules returns the rules added with RegisterRule, in the order of registration
+func RegisteredRules() []Rule {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	rules := make([]Rule, len(registeredRules))
+	copy(rules, registeredRules)
+	return rules
+}
+
+// detectRule runs a registered rule on a node and fills in what the rule left out of its
+// issues
+func detectRule(rule Rule, fset *token.FileSet, node ast.Node) []*models.Issue {
+	issues := rule.Detect(fset, node)
+	for _, issue := range issues {
+		if issue.Rule == "" {
+			issue.Rule = rule.ID()
+		}
+		if issue.Category == "" {
+			issue.Category = rule.Category()
+		}
+		if issue.Severity == "" {
+			issue.Severity = rule.Severity()
+		}
+		if issue.Confidence == "" {
+			issue.Confidence = "medium"
+		}
+	}
+	return issues
+}
@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/user/code-review-assistant/internal/analyzer/patterns"
+	"github.com/user/code-review-assistant/internal/config"
+	"github.com/user/code-review-assistant/internal/optimization"
+	"github.com/user/code-review-assistant/internal/security"
+)
+
+// RuleDoc documents a built-in rule
+type RuleDoc struct {
+	ID          string // Rule ID as reported with issues, the name for rules that have no other ID
+	Name        string
+	Category    string
+	Severity    string // Default severity, empty for optimizations
+	Description string
+	Suggestion  string
+	Example     string
+}
+
+// Rules returns the documentation of the built-in rules of every registry: code patterns,
+// anti-patterns, best practices, security rules, rules added with RegisterRule, the
+// cyclomatic complexity check, and optimizations, in this order
+func Rules(cfg *config.Config) []*RuleDoc {
+	var rules []*RuleDoc
+	for _, p := range patterns.GetGoPatterns(cfg) {
+		rules = append(rules, &RuleDoc{ID: p.Name, Name: p.Name, Category: p.Category, Severity: p.Severity, Description: p.Description, Suggestion: p.Suggestion})
+	}
+	for _, ap := range patterns.GetGoAntiPatterns(cfg) {
+		rules = append(rules, &RuleDoc{ID: ap.Name, Name: ap.Name, Category: ap.Category, Severity: ap.Severity, Description: ap.Description, Suggestion: ap.Suggestion})
+	}
+	for _, bp := range patterns.GetGoBestPractices(cfg) {
+		rules = append(rules, &RuleDoc{ID: bp.Name, Name: bp.Name, Category: bp.Category, Severity: bp.Severity, Description: bp.Description, Suggestion: bp.Suggestion})
+	}
+	for _, sr := range security.GetCustomSecurityRules(cfg) {
+		rules = append(rules, &RuleDoc{ID: sr.ID, Name: sr.Name, Category: "security", Severity: sr.Severity, Description: sr.Description, Suggestion: sr.Suggestion})
+	}
+	for _, rule := range RegisteredRules() {
+		rules = append(rules, &RuleDoc{ID: rule.ID(), Name: rule.ID(), Category: rule.Category(), Severity: rule.Severity()})
+	}
+	rules = append(rules, &RuleDoc{
+		ID:          "cyclomatic-complexity",
+		Name:        "cyclomatic-complexity",
+		Category:    "code-smell",
+		Severity:    "medium",
+		Description: fmt.Sprintf("Function with a cyclomatic complexity above max_complexity (%d)", cfg.MaxComplexity),
+		Suggestion:  "Reduce the number of branches by extracting helper functions or simplifying conditions",
+	})
+	for _, opt := range optimization.GetOptimizationRules(cfg) {
+		rules = append(rules, &RuleDoc{ID: opt.ID, Name: opt.Name, Category: "performance", Description: opt.Description, Example: opt.Example})
+	}
+	return rules
+}
+
+// RuleDocs returns the documentation of the built-in rules keyed by lowercase rule ID and
+// name. Names that are also the ID of another rule refer to that rule.
+func RuleDocs(cfg *config.Config) map[string]*RuleDoc {
+	rules := Rules(cfg)
+	docs := make(map[string]*RuleDoc, 2*len(rules))
+	for _, rule := range rules {
+		docs[strings.ToLower(rule.ID)] = rule
+	}
+	for _, rule := range rules {
+		if _, ok := docs[strings.ToLower(rule.Name)]; !ok {
+			docs[strings.ToLower(rule.Name)] = rule
+		}
+	}
+	return docs
+}
+
+// LookupRule returns the documentation of a built-in rule by ID or name, ignoring case
+func LookupRule(cfg *config.Config, rule string) (*RuleDoc, bool) {
+	doc, ok := RuleDocs(cfg)[strings.ToLower(strings.TrimSpace(rule))]
+	return doc, ok
+}
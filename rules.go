@@ -1,10 +1,15 @@
 package optimization
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"runtime"
+	"sort"
 	"strings"
 
+	"github.com/user/code-review-assistant/internal/config"
 	"github.com/user/code-review-assistant/internal/models"
 )
 
@@ -13,17 +18,19 @@ type OptimizationRule struct {
 	ID          string
 	Name        string
 	Description string
-	Detector    func(fset *token.FileSet, node ast.Node) *models.Optimization
+	Example     string // Example code with the optimization applied
+	Detector    func(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization
 }
 
-// GetOptimizationRules returns a list of optimization rules
-func GetOptimizationRules() []*OptimizationRule {
+// GetOptimizationRules returns a list of optimization rules, with the thresholds of cfg
+func GetOptimizationRules(cfg *config.Config) []*OptimizationRule {
 	return []*OptimizationRule{
 		// Inefficient string concatenation in loops
 		{
 			ID:          "OPT001",
 			Name:        "inefficient-string-concat",
 			Description: "Inefficient string concatenation in loops",
+			Example:     "// Instead of:\nvar result string\nfor _, s := range strings {\n    result += s\n}\n\n// Use strings.Builder:\nvar builder strings.Builder\nfor _, s := range strings {\n    builder.WriteString(s)\n}\nresult := builder.String()",
 			Detector:    detectInefficientStringConcat,
 		},
 		// Unnecessary memory allocations
@@ -31,6 +38,7 @@ func GetOptimizationRules() []*OptimizationRule {
 			ID:          "OPT002",
 			Name:        "unnecessary-allocation",
 			Description: "Unnecessary memory allocations",
+			Example:     "// Instead of:\nuser := new(User)\nuser.Name = \"John\"\n\n// Use struct literal:\nuser := User{Name: \"John\"}",
 			Detector:    detectUnnecessaryAllocation,
 		},
 		// Suboptimal slice capacity
@@ -38,6 +46,7 @@ func GetOptimizationRules() []*OptimizationRule {
 			ID:          "OPT003",
 			Name:        "suboptimal-slice-capacity",
 			Description: "Suboptimal slice capacity",
+			Example:     "// Instead of:\nvar items []Item\nfor i := 0; i < n; i++ {\n    items = append(items, Item{})\n}\n\n// Pre-allocate the slice:\nitems := make([]Item, 0, n)\nfor i := 0; i < n; i++ {\n    items = append(items, Item{})\n}",
 			Detector:    detectSuboptimalSliceCapacity,
 		},
 		// Inefficient map initialization
@@ -45,6 +54,7 @@ func GetOptimizationRules() []*OptimizationRule {
 			ID:          "OPT004",
 			Name:        "inefficient-map-init",
 			Description: "Inefficient map initialization",
+			Example:     "// Instead of:\nm := make(map[string]int)\nfor i := 0; i < n; i++ {\n    m[fmt.Sprintf(\"key%d\", i)] = i\n}\n\n// Provide capacity hint:\nm := make(map[string]int, n)\nfor i := 0; i < n; i++ {\n    m[fmt.Sprintf(\"key%d\", i)] = i\n}",
 			Detector:    detectInefficientMapInit,
 		},
 		// Redundant type conversions
@@ -52,6 +62,7 @@ func GetOptimizationRules() []*OptimizationRule {
 			ID:          "OPT005",
 			Name:        "redundant-type-conversion",
 			Description: "Redundant type conversions",
+			Example:     "// Instead of:\nresult := string(str)\n\n// If str is already a string, simply use:\nresult := str",
 			Detector:    detectRedundantTypeConversion,
 		},
 		// Inefficient regular expression usage
@@ -59,6 +70,7 @@ func GetOptimizationRules() []*OptimizationRule {
 			ID:          "OPT006",
 			Name:        "inefficient-regex",
 			Description: "Inefficient regular expression usage",
+			Example:     "// Instead of:\nfor _, s := range strings {\n    re := regexp.MustCompile(`pattern`)\n    matches := re.FindAllString(s, -1)\n}\n\n// Compile the regex once, outside the loop:\nre := regexp.MustCompile(`pattern`)\nfor _, s := range strings {\n    matches := re.FindAllString(s, -1)\n}",
 			Detector:    detectInefficientRegex,
 		},
 		// Inefficient error handling
@@ -66,6 +78,7 @@ func GetOptimizationRules() []*OptimizationRule {
 			ID:          "OPT007",
 			Name:        "inefficient-error-handling",
 			Description: "Inefficient error handling",
+			Example:     "// Instead of:\nreturn fmt.Errorf(\"failed to process: \" + err.Error())\n// Or:\nreturn fmt.Errorf(\"failed to process: %v\", err)\n\n// Use %w for proper error wrapping:\nreturn fmt.Errorf(\"failed to process: %w\", err)",
 			Detector:    detectInefficientErrorHandling,
 		},
 		// Inefficient JSON marshaling
@@ -73,13 +86,62 @@ func GetOptimizationRules() []*OptimizationRule {
 			ID:          "OPT008",
 			Name:        "inefficient-json",
 			Description: "Inefficient JSON marshaling/unmarshaling",
+			Example:     "// For multiple JSON operations on the same structure, consider:\n// 1. Using a JSON encoder/decoder with io.Pipe for streaming\n// 2. Processing data in batches\n// 3. Using a more efficient encoding like gob or protobuf for internal operations",
 			Detector:    detectInefficientJSON,
 		},
+		// Large structs passed or returned by value
+		{
+			ID:          "OPT009",
+			Name:        "large-struct-by-value",
+			Description: "Large struct passed or returned by value",
+			Example:     "// Instead of:\nfunc process(cfg Config) Result {...}\n\n// Pass and return pointers:\nfunc process(cfg *Config) *Result {...}",
+			Detector:    largeStructByValue(cfg.MaxStructSize),
+		},
+		// Whole input read into memory only to iterate over its lines
+		{
+			ID:          "OPT010",
+			Name:        "read-then-split-lines",
+			Description: "Whole file or reader read into memory only to iterate over its lines",
+			Example:     "// Instead of:\ndata, err := os.ReadFile(path)\nif err != nil {\n    return err\n}\nfor _, line := range strings.Split(string(data), \"\\n\") {\n    process(line)\n}\n\n// Stream the lines with bufio.Scanner:\nf, err := os.Open(path)\nif err != nil {\n    return err\n}\ndefer f.Close()\nscanner := bufio.NewScanner(f)\nfor scanner.Scan() {\n    process(scanner.Text())\n}\nreturn scanner.Err()",
+			Detector:    detectReadThenSplitLines,
+		},
+		// Struct fields ordered so that padding wastes memory
+		{
+			ID:          "OPT011",
+			Name:        "struct-padding",
+			Description: "Struct whose field order wastes memory to alignment padding",
+			Example:     "// Instead of (24 bytes on 64-bit platforms):\ntype item struct {\n    visible bool\n    id      int64\n    deleted bool\n}\n\n// Order fields by decreasing alignment (16 bytes):\ntype item struct {\n    id      int64\n    visible bool\n    deleted bool\n}",
+			Detector:    detectStructPadding,
+		},
+		// Builder written to in a loop over a slice without being pre-sized
+		{
+			ID:          "OPT012",
+			Name:        "unsized-builder",
+			Description: "strings.Builder or bytes.Buffer written to in a loop over a slice without calling Grow first",
+			Example:     "// Instead of:\nvar builder strings.Builder\nfor _, name := range names {\n    builder.WriteString(name)\n    builder.WriteByte('\\n')\n}\n\n// Grow the builder to the expected size first:\nvar builder strings.Builder\nbuilder.Grow(len(names) * 16)\nfor _, name := range names {\n    builder.WriteString(name)\n    builder.WriteByte('\\n')\n}",
+			Detector:    detectUnsizedBuilder,
+		},
+		// Unconditional logging on every iteration of a loop
+		{
+			ID:          "OPT013",
+			Name:        "log-in-loop",
+			Description: "Logging or printing on every iteration of a loop",
+			Example:     "// Instead of:\nfor _, item := range items {\n    log.Printf(\"processing %s\", item.ID)\n    process(item)\n}\n\n// Log once for the whole loop:\nlog.Printf(\"processing %d items\", len(items))\nfor _, item := range items {\n    process(item)\n}\n\n// Or log a sample of the iterations:\nfor i, item := range items {\n    if i%1000 == 0 {\n        log.Printf(\"processed %d of %d items\", i, len(items))\n    }\n    process(item)\n}",
+			Detector:    detectLogInLoop,
+		},
+		// time.Now called on every iteration of a loop for a timestamp
+		{
+			ID:          "OPT014",
+			Name:        "time-now-in-loop",
+			Description: "time.Now called on every iteration of a loop, where one timestamp may be intended",
+			Example:     "// Instead of:\nfor _, item := range items {\n    item.UpdatedAt = time.Now()\n    save(item)\n}\n\n// Take the timestamp once, before the loop:\nnow := time.Now()\nfor _, item := range items {\n    item.UpdatedAt = now\n    save(item)\n}",
+			Detector:    detectTimeNowInLoop,
+		},
 	}
 }
 
 // detectInefficientStringConcat detects inefficient string concatenation in loops
-func detectInefficientStringConcat(fset *token.FileSet, node ast.Node) *models.Optimization {
+func detectInefficientStringConcat(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
 	// Look for string concatenation in loops
 	forStmt, ok := node.(*ast.ForStmt)
 	if !ok {
@@ -89,19 +151,20 @@ func detectInefficientStringConcat(fset *token.FileSet, node ast.Node) *models.O
 		}
 		
 		// Check for string concatenation in range loop body
-		return checkStringConcatInBody(fset, rangeStmt.Body)
+		return checkStringConcatInBody(fset, info, rangeStmt.Body)
 	}
 	
 	// Check for string concatenation in for loop body
-	return checkStringConcatInBody(fset, forStmt.Body)
+	return checkStringConcatInBody(fset, info, forStmt.Body)
 }
 
 // checkStringConcatInBody checks for string concatenation in a block statement
-func checkStringConcatInBody(fset *token.FileSet, body *ast.BlockStmt) *models.Optimization {
+func checkStringConcatInBody(fset *token.FileSet, info *types.Info, body *ast.BlockStmt) []*models.Optimization {
 	if body == nil {
 		return nil
 	}
 	
+	var optimizations []*models.Optimization
 	// Look for string concatenation using += operator
 	for _, stmt := range body.List {
 		assignStmt, ok := stmt.(*ast.AssignStmt)
@@ -109,25 +172,24 @@ func checkStringConcatInBody(fset *token.FileSet, body *ast.BlockStmt) *models.O
 			continue
 		}
 		
-		// Check if left side is a string variable
-		// This is a simplified check and would need type information for accuracy
-		if len(assignStmt.Lhs) > 0 {
+		// Check if left side is a string variable. Without type information, every
+		// += is assumed to be a string concatenation
+		if len(assignStmt.Lhs) > 0 && (info == nil || isString(info.TypeOf(assignStmt.Lhs[0]))) {
 			pos := fset.Position(assignStmt.Pos())
-			return &models.Optimization{
+			optimizations = append(optimizations, &models.Optimization{
 				File:        pos.Filename,
 				Line:        pos.Line,
 				Description: "Inefficient string concatenation in loop",
 				Benefit:     "Reduced memory allocations and improved performance",
-				Example:     "// Instead of:\nvar result string\nfor _, s := range strings {\n    result += s\n}\n\n// Use strings.Builder:\nvar builder strings.Builder\nfor _, s := range strings {\n    builder.WriteString(s)\n}\nresult := builder.String()",
-			}
+			})
 		}
 	}
 	
-	return nil
+	return optimizations
 }
 
 // detectUnnecessaryAllocation detects unnecessary memory allocations
-func detectUnnecessaryAllocation(fset *token.FileSet, node ast.Node) *models.Optimization {
+func detectUnnecessaryAllocation(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
 	// Look for unnecessary use of new() or make() for small structs
 	callExpr, ok := node.(*ast.CallExpr)
 	if !ok {
@@ -137,20 +199,19 @@ func detectUnnecessaryAllocation(fset *token.FileSet, node ast.Node) *models.Opt
 	// Check if it's a call to new()
 	if ident, ok := callExpr.Fun.(*ast.Ident); ok && ident.Name == "new" {
 		pos := fset.Position(callExpr.Pos())
-		return &models.Optimization{
+		return []*models.Optimization{{
 			File:        pos.Filename,
 			Line:        pos.Line,
 			Description: "Unnecessary use of new() for small struct",
 			Benefit:     "Reduced heap allocations and improved performance",
-			Example:     "// Instead of:\nuser := new(User)\nuser.Name = \"John\"\n\n// Use struct literal:\nuser := User{Name: \"John\"}",
-		}
+		}}
 	}
 	
 	return nil
 }
 
 // detectSuboptimalSliceCapacity detects suboptimal slice capacity
-func detectSuboptimalSliceCapacity(fset *token.FileSet, node ast.Node) *models.Optimization {
+func detectSuboptimalSliceCapacity(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
 	// Look for slice creation followed by append in a loop
 	forStmt, ok := node.(*ast.ForStmt)
 	if !ok {
@@ -168,11 +229,12 @@ func detectSuboptimalSliceCapacity(fset *token.FileSet, node ast.Node) *models.O
 }
 
 // checkSliceAppendInBody checks for slice append in a block statement
-func checkSliceAppendInBody(fset *token.FileSet, body *ast.BlockStmt) *models.Optimization {
+func checkSliceAppendInBody(fset *token.FileSet, body *ast.BlockStmt) []*models.Optimization {
 	if body == nil {
 		return nil
 	}
 	
+	var optimizations []*models.Optimization
 	// Look for append calls
 	for _, stmt := range body.List {
 		assignStmt, ok := stmt.(*ast.AssignStmt)
@@ -188,22 +250,21 @@ func checkSliceAppendInBody(fset *token.FileSet, body *ast.BlockStmt) *models.Op
 			
 			if ident, ok := callExpr.Fun.(*ast.Ident); ok && ident.Name == "append" {
 				pos := fset.Position(callExpr.Pos())
-				return &models.Optimization{
+				optimizations = append(optimizations, &models.Optimization{
 					File:        pos.Filename,
 					Line:        pos.Line,
 					Description: "Slice being repeatedly appended to in a loop without pre-allocation",
 					Benefit:     "Reduced memory allocations and improved performance",
-					Example:     "// Instead of:\nvar items []Item\nfor i := 0; i < n; i++ {\n    items = append(items, Item{})\n}\n\n// Pre-allocate the slice:\nitems := make([]Item, 0, n)\nfor i := 0; i < n; i++ {\n    items = append(items, Item{})\n}",
-				}
+				})
 			}
 		}
 	}
 	
-	return nil
+	return optimizations
 }
 
 // detectInefficientMapInit detects inefficient map initialization
-func detectInefficientMapInit(fset *token.FileSet, node ast.Node) *models.Optimization {
+func detectInefficientMapInit(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
 	// Look for map creation without capacity hint followed by multiple insertions
 	forStmt, ok := node.(*ast.ForStmt)
 	if !ok {
@@ -221,11 +282,12 @@ func detectInefficientMapInit(fset *token.FileSet, node ast.Node) *models.Optimi
 }
 
 // checkMapAssignInBody checks for map assignments in a block statement
-func checkMapAssignInBody(fset *token.FileSet, body *ast.BlockStmt) *models.Optimization {
+func checkMapAssignInBody(fset *token.FileSet, body *ast.BlockStmt) []*models.Optimization {
 	if body == nil {
 		return nil
 	}
 	
+	var optimizations []*models.Optimization
 	// Look for map assignments
 	for _, stmt := range body.List {
 		assignStmt, ok := stmt.(*ast.AssignStmt)
@@ -241,52 +303,55 @@ func checkMapAssignInBody(fset *token.FileSet, body *ast.BlockStmt) *models.Opti
 			
 			// This is a simplified check and would need type information for accuracy
 			pos := fset.Position(indexExpr.Pos())
-			return &models.Optimization{
+			optimizations = append(optimizations, &models.Optimization{
 				File:        pos.Filename,
 				Line:        pos.Line,
 				Description: "Map being populated in a loop without capacity hint",
 				Benefit:     "Reduced memory allocations and improved performance",
-				Example:     "// Instead of:\nm := make(map[string]int)\nfor i := 0; i < n; i++ {\n    m[fmt.Sprintf(\"key%d\", i)] = i\n}\n\n// Provide capacity hint:\nm := make(map[string]int, n)\nfor i := 0; i < n; i++ {\n    m[fmt.Sprintf(\"key%d\", i)] = i\n}",
-			}
+			})
 		}
 	}
 	
-	return nil
+	return optimizations
 }
 
 // detectRedundantTypeConversion detects redundant type conversions
-func detectRedundantTypeConversion(fset *token.FileSet, node ast.Node) *models.Optimization {
+func detectRedundantTypeConversion(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
 	// Look for redundant type conversions
 	callExpr, ok := node.(*ast.CallExpr)
-	if !ok {
+	if !ok || len(callExpr.Args) != 1 {
 		return nil
 	}
 	
-	// Check if it's a type conversion
-	if typeIdent, ok := callExpr.Fun.(*ast.Ident); ok {
-		// Check if argument is of the same type (simplified check)
-		if len(callExpr.Args) == 1 {
-			if argIdent, ok := callExpr.Args[0].(*ast.Ident); ok {
-				// This is a simplified check and would need type information for accuracy
-				if typeIdent.Name == "string" && argIdent.Name == "str" {
-					pos := fset.Position(callExpr.Pos())
-					return &models.Optimization{
-						File:        pos.Filename,
-						Line:        pos.Line,
-						Description: "Potentially redundant type conversion",
-						Benefit:     "Cleaner code and potentially improved performance",
-						Example:     "// Instead of:\nresult := string(str)\n\n// If str is already a string, simply use:\nresult := str",
-					}
-				}
-			}
+	if info != nil {
+		// The conversion is redundant when the argument already has the target type
+		target, ok := info.Types[callExpr.Fun]
+		if !ok || !target.IsType() || !types.Identical(target.Type, info.TypeOf(callExpr.Args[0])) {
+			return nil
+		}
+	} else {
+		// Without type information, only conversions of a variable named str to string are recognized
+		typeIdent, ok := callExpr.Fun.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		argIdent, ok := callExpr.Args[0].(*ast.Ident)
+		if !ok || typeIdent.Name != "string" || argIdent.Name != "str" {
+			return nil
 		}
 	}
 	
-	return nil
+	pos := fset.Position(callExpr.Pos())
+	return []*models.Optimization{{
+		File:        pos.Filename,
+		Line:        pos.Line,
+		Description: "Potentially redundant type conversion",
+		Benefit:     "Cleaner code and potentially improved performance",
+	}}
 }
 
 // detectInefficientRegex detects inefficient regular expression usage
-func detectInefficientRegex(fset *token.FileSet, node ast.Node) *models.Optimization {
+func detectInefficientRegex(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
 	// Look for regexp.Compile or regexp.MustCompile in loops
 	forStmt, ok := node.(*ast.ForStmt)
 	if !ok {
@@ -304,11 +369,12 @@ func detectInefficientRegex(fset *token.FileSet, node ast.Node) *models.Optimiza
 }
 
 // checkRegexCompileInBody checks for regex compilation in a block statement
-func checkRegexCompileInBody(fset *token.FileSet, body *ast.BlockStmt) *models.Optimization {
+func checkRegexCompileInBody(fset *token.FileSet, body *ast.BlockStmt) []*models.Optimization {
 	if body == nil {
 		return nil
 	}
 	
+	var optimizations []*models.Optimization
 	// Look for regexp.Compile or regexp.MustCompile calls
 	for _, stmt := range body.List {
 		exprStmt, ok := stmt.(*ast.ExprStmt)
@@ -329,22 +395,21 @@ func checkRegexCompileInBody(fset *token.FileSet, body *ast.BlockStmt) *models.O
 		if xIdent, ok := selectorExpr.X.(*ast.Ident); ok && xIdent.Name == "regexp" {
 			if selectorExpr.Sel.Name == "Compile" || selectorExpr.Sel.Name == "MustCompile" {
 				pos := fset.Position(callExpr.Pos())
-				return &models.Optimization{
+				optimizations = append(optimizations, &models.Optimization{
 					File:        pos.Filename,
 					Line:        pos.Line,
 					Description: "Regular expression compiled inside a loop",
 					Benefit:     "Significantly improved performance by avoiding repeated regex compilation",
-					Example:     "// Instead of:\nfor _, s := range strings {\n    re := regexp.MustCompile(`pattern`)\n    matches := re.FindAllString(s, -1)\n}\n\n// Compile the regex once, outside the loop:\nre := regexp.MustCompile(`pattern`)\nfor _, s := range strings {\n    matches := re.FindAllString(s, -1)\n}",
-				}
+				})
 			}
 		}
 	}
 	
-	return nil
+	return optimizations
 }
 
 // detectInefficientErrorHandling detects inefficient error handling
-func detectInefficientErrorHandling(fset *token.FileSet, node ast.Node) *models.Optimization {
+func detectInefficientErrorHandling(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
 	// Look for fmt.Errorf with string concatenation
 	callExpr, ok := node.(*ast.CallExpr)
 	if !ok {
@@ -365,13 +430,12 @@ func detectInefficientErrorHandling(fset *token.FileSet, node ast.Node) *models.
 					for i := 1; i < len(callExpr.Args); i++ {
 						if ident, ok := callExpr.Args[i].(*ast.Ident); ok && ident.Name == "err" {
 							pos := fset.Position(callExpr.Pos())
-							return &models.Optimization{
+							return []*models.Optimization{{
 								File:        pos.Filename,
 								Line:        pos.Line,
 								Description: "Error wrapping without using %w verb",
 								Benefit:     "Proper error wrapping allows for error unwrapping and inspection",
-								Example:     "// Instead of:\nreturn fmt.Errorf(\"failed to process: \" + err.Error())\n// Or:\nreturn fmt.Errorf(\"failed to process: %v\", err)\n\n// Use %w for proper error wrapping:\nreturn fmt.Errorf(\"failed to process: %w\", err)",
-							}
+							}}
 						}
 					}
 				}
@@ -383,7 +447,7 @@ func detectInefficientErrorHandling(fset *token.FileSet, node ast.Node) *models.
 }
 
 // detectInefficientJSON detects inefficient JSON marshaling/unmarshaling
-func detectInefficientJSON(fset *token.FileSet, node ast.Node) *models.Optimization {
+func detectInefficientJSON(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
 	// Look for json.Marshal or json.Unmarshal in loops
 	forStmt, ok := node.(*ast.ForStmt)
 	if !ok {
@@ -401,11 +465,12 @@ func detectInefficientJSON(fset *token.FileSet, node ast.Node) *models.Optimizat
 }
 
 // checkJSONInBody checks for JSON operations in a block statement
-func checkJSONInBody(fset *token.FileSet, body *ast.BlockStmt) *models.Optimization {
+func checkJSONInBody(fset *token.FileSet, body *ast.BlockStmt) []*models.Optimization {
 	if body == nil {
 		return nil
 	}
 	
+	var optimizations []*models.Optimization
 	// Look for json.Marshal or json.Unmarshal calls
 	for _, stmt := range body.List {
 		assignStmt, ok := stmt.(*ast.AssignStmt)
@@ -427,17 +492,690 @@ func checkJSONInBody(fset *token.FileSet, body *ast.BlockStmt) *models.Optimizat
 			if xIdent, ok := selectorExpr.X.(*ast.Ident); ok && xIdent.Name == "json" {
 				if selectorExpr.Sel.Name == "Marshal" || selectorExpr.Sel.Name == "Unmarshal" {
 					pos := fset.Position(callExpr.Pos())
-					return &models.Optimization{
+					optimizations = append(optimizations, &models.Optimization{
 						File:        pos.Filename,
 						Line:        pos.Line,
 						Description: "JSON marshaling/unmarshaling inside a loop",
 						Benefit:     "Improved performance by reducing repeated encoding/decoding operations",
-						Example:     "// For multiple JSON operations on the same structure, consider:\n// 1. Using a JSON encoder/decoder with io.Pipe for streaming\n// 2. Processing data in batches\n// 3. Using a more efficient encoding like gob or protobuf for internal operations",
-					}
+					})
 				}
 			}
 		}
 	}
 	
-	return nil
+	return optimizations
+}
+
+// wholeReads lists the functions reading a whole file or reader into memory, by package
+var wholeReads = map[string]map[string]bool{
+	"os":     {"ReadFile": true},
+	"io":     {"ReadAll": true},
+	"ioutil": {"ReadFile": true, "ReadAll": true},
+}
+
+// detectReadThenSplitLines detects a file or reader read whole with os.ReadFile or
+// io.ReadAll, split into lines with strings.Split, and only ranged over afterwards, either
+// directly or through a variable holding the lines. A bufio.Scanner would only hold one
+// line at a time.
+func detectReadThenSplitLines(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
+	block, ok := node.(*ast.BlockStmt)
+	if !ok {
+		return nil
+	}
+
+	var optimizations []*models.Optimization
+	for i, stmt := range block.List {
+		data, read := wholeRead(stmt)
+		if data == nil || !onlyRangedOverLines(block.List[i+1:], data.Obj) {
+			continue
+		}
+		pos := fset.Position(stmt.Pos())
+		optimizations = append(optimizations, &models.Optimization{
+			File:        pos.Filename,
+			Line:        pos.Line,
+			Description: fmt.Sprintf("%s reads the whole input into %s only to iterate over its lines; use a bufio.Scanner instead", read, data.Name),
+			Benefit:     "Lower memory for large files, as only one line is held at a time",
+		})
+	}
+
+	return optimizations
+}
+
+// wholeRead returns the variable assigned the contents read by a statement such as
+// data, err := os.ReadFile(path), with the name of the function called, or nil
+func wholeRead(stmt ast.Stmt) (*ast.Ident, string) {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 {
+		return nil, ""
+	}
+	call, ok := assign.Rhs[0].(*ast.CallExpr)
+	if !ok {
+		return nil, ""
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, ""
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || !wholeReads[pkg.Name][sel.Sel.Name] {
+		return nil, ""
+	}
+
+	data, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok || data.Obj == nil {
+		return nil, ""
+	}
+	return data, pkg.Name + "." + sel.Sel.Name
+}
+
+// onlyRangedOverLines reports whether the only use of data in stmts splits it into lines
+// that are ranged over, and used for nothing else
+func onlyRangedOverLines(stmts []ast.Stmt, data *ast.Object) bool {
+	if countReferences(stmts, data) != 1 {
+		return false
+	}
+	for i, stmt := range stmts {
+		if !references(stmt, data) {
+			continue
+		}
+		switch s := stmt.(type) {
+		case *ast.RangeStmt:
+			return splitsLines(s.X, data)
+		case *ast.AssignStmt:
+			// lines := strings.Split(string(data), "\n") followed by a range over lines
+			lines, ok := s.Lhs[0].(*ast.Ident)
+			if len(s.Lhs) != 1 || len(s.Rhs) != 1 || !ok || lines.Obj == nil || !splitsLines(s.Rhs[0], data) {
+				return false
+			}
+			rest := stmts[i+1:]
+			if countReferences(rest, lines.Obj) != 1 {
+				return false
+			}
+			for _, next := range rest {
+				if loop, ok := next.(*ast.RangeStmt); ok {
+					if ident, ok := loop.X.(*ast.Ident); ok && ident.Obj == lines.Obj {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// splitsLines reports whether an expression is strings.Split(string(data), "\n") or
+// strings.Split(data, "\n")
+func splitsLines(expr ast.Expr, data *ast.Object) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 2 {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Split" || !isIdent(sel.X, "strings") {
+		return false
+	}
+	if sep, ok := call.Args[1].(*ast.BasicLit); !ok || sep.Value != `"\n"` {
+		return false
+	}
+
+	arg := call.Args[0]
+	if conv, ok := arg.(*ast.CallExpr); ok && len(conv.Args) == 1 && isIdent(conv.Fun, "string") {
+		arg = conv.Args[0]
+	}
+	ident, ok := arg.(*ast.Ident)
+	return ok && ident.Obj == data
+}
+
+// countReferences returns the number of references to an object in statements
+func countReferences(stmts []ast.Stmt, obj *ast.Object) int {
+	count := 0
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Obj == obj {
+				count++
+			}
+			return true
+		})
+	}
+	return count
+}
+
+// largeStructFields is the number of fields above which a struct is considered large when
+// its size is not known
+const largeStructFields = 8
+
+// structSizes computes type sizes for the platform the tool runs on, nil if it is unknown
+var structSizes = types.SizesFor("gc", runtime.GOARCH)
+
+// largeStructByValue returns a detector of function parameters and results of struct types
+// larger than maxSize bytes that are passed by value. Without type information, structs
+// declared in the file with more than largeStructFields fields are reported. A maxSize of
+// zero disables the detector.
+func largeStructByValue(maxSize int) func(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
+	return func(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
+		funcDecl, ok := node.(*ast.FuncDecl)
+		if !ok || maxSize <= 0 {
+			return nil
+		}
+
+		var optimizations []*models.Optimization
+		check := func(fields *ast.FieldList, kind string) {
+			if fields == nil {
+				return
+			}
+			position := 0
+			for _, field := range fields.List {
+				var size string
+				if info != nil {
+					size = largeStructSize(info.TypeOf(field.Type), maxSize)
+				} else {
+					size = largeStructDecl(field.Type)
+				}
+
+				// Unnamed results and parameters count as one
+				names := field.Names
+				if len(names) == 0 {
+					names = []*ast.Ident{nil}
+				}
+				for _, name := range names {
+					position++
+					if size == "" {
+						continue
+					}
+					what := fmt.Sprintf("%s %d", kind, position)
+					at := field.Type.Pos()
+					if name != nil {
+						what += fmt.Sprintf(" (%s)", name.Name)
+						at = name.Pos()
+					}
+					pos := fset.Position(at)
+					optimizations = append(optimizations, &models.Optimization{
+						File:        pos.Filename,
+						Line:        pos.Line,
+						Description: fmt.Sprintf("%s of %s is %s passed by value", strings.ToUpper(what[:1])+what[1:], funcDecl.Name.Name, size),
+						Benefit:     "Less copying on every call; pass a pointer to the struct instead",
+					})
+				}
+			}
+		}
+		check(funcDecl.Type.Params, "parameter")
+		check(funcDecl.Type.Results, "result")
+
+		return optimizations
+	}
+}
+
+// largeStructSize describes the size of a struct type larger than maxSize bytes, or returns
+// an empty string if t is not such a struct
+func largeStructSize(t types.Type, maxSize int) string {
+	if t == nil {
+		return ""
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return ""
+	}
+	if structSizes == nil {
+		if st.NumFields() > largeStructFields {
+			return fmt.Sprintf("a struct with %d fields", st.NumFields())
+		}
+		return ""
+	}
+	if size := structSizes.Sizeof(t); size > int64(maxSize) {
+		return fmt.Sprintf("a struct of about %d bytes", size)
+	}
+	return ""
+}
+
+// largeStructDecl describes a struct type declared in the same file with more than
+// largeStructFields fields, or returns an empty string if expr is not such a type
+func largeStructDecl(expr ast.Expr) string {
+	ident, ok := expr.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return ""
+	}
+	spec, ok := ident.Obj.Decl.(*ast.TypeSpec)
+	if !ok {
+		return ""
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok || st.Fields == nil {
+		return ""
+	}
+	n := 0
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			n++ // Embedded field
+		}
+		n += len(field.Names)
+	}
+	if n > largeStructFields {
+		return fmt.Sprintf("a struct with %d fields", n)
+	}
+	return ""
+}
+
+// isString reports whether a type is a string type, including named types based on string
+func isString(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
+
+// minPaddingSavings is the number of bytes a struct must save by reordering its fields
+// to be reported by OPT011
+const minPaddingSavings = 8
+
+// detectStructPadding detects struct types that would be at least minPaddingSavings bytes
+// smaller with their fields ordered by decreasing alignment. It needs type information, and
+// leaves out structs with blank fields, which often pad or mark the layout deliberately.
+func detectStructPadding(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
+	spec, ok := node.(*ast.TypeSpec)
+	if !ok || info == nil || structSizes == nil {
+		return nil
+	}
+	if _, ok := spec.Type.(*ast.StructType); !ok {
+		return nil
+	}
+	obj := info.Defs[spec.Name]
+	if obj == nil {
+		return nil
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok || st.NumFields() < 2 {
+		return nil
+	}
+
+	fields := make([]*types.Var, st.NumFields())
+	for i := range fields {
+		fields[i] = st.Field(i)
+		if fields[i].Name() == "_" {
+			return nil
+		}
+	}
+	optimal := optimalFieldOrder(fields)
+	size := structSizes.Sizeof(st)
+	optimalSize := structSizes.Sizeof(types.NewStruct(optimal, nil))
+	if size-optimalSize < minPaddingSavings {
+		return nil
+	}
+
+	names := make([]string, len(optimal))
+	for i, field := range optimal {
+		names[i] = field.Name()
+	}
+	pos := fset.Position(spec.Name.Pos())
+	return []*models.Optimization{{
+		File:        pos.Filename,
+		Line:        pos.Line,
+		Description: fmt.Sprintf("Struct %s takes %d bytes because of padding between its fields, ordered as %s it would take %d bytes", spec.Name.Name, size, strings.Join(names, ", "), optimalSize),
+		Benefit:     fmt.Sprintf("%d bytes less memory per %s value, and more values per cache line", size-optimalSize, spec.Name.Name),
+	}}
+}
+
+// optimalFieldOrder returns struct fields ordered to minimize padding: zero-sized fields
+// first, as a trailing one is padded, then by decreasing alignment and size. Fields that
+// compare equal keep their order.
+func optimalFieldOrder(fields []*types.Var) []*types.Var {
+	ordered := make([]*types.Var, len(fields))
+	copy(ordered, fields)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i].Type(), ordered[j].Type()
+		sizeA, sizeB := structSizes.Sizeof(a), structSizes.Sizeof(b)
+		if (sizeA == 0) != (sizeB == 0) {
+			return sizeA == 0
+		}
+		if alignA, alignB := structSizes.Alignof(a), structSizes.Alignof(b); alignA != alignB {
+			return alignA > alignB
+		}
+		return sizeA > sizeB
+	})
+	return ordered
+}
+
+// builderTypes are the types of the buffers that OPT012 suggests to grow, by package
+var builderTypes = map[string]string{
+	"strings": "Builder",
+	"bytes":   "Buffer",
+}
+
+// detectUnsizedBuilder detects a strings.Builder or bytes.Buffer declared in a block and
+// then written to in a range loop over a slice, an array, or a map held in a variable or
+// field, without calling Grow before the loop. Without type information, loops over any
+// variable or field are taken to have a length.
+func detectUnsizedBuilder(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
+	block, ok := node.(*ast.BlockStmt)
+	if !ok {
+		return nil
+	}
+
+	var optimizations []*models.Optimization
+	for i, stmt := range block.List {
+		builder, typeName := declaredBuilder(stmt)
+		if builder == nil {
+			continue
+		}
+		for _, next := range block.List[i+1:] {
+			if growsBuilder(next, builder.Obj) {
+				break
+			}
+			loop, ok := next.(*ast.RangeStmt)
+			if !ok || !writesBuilder(loop.Body, builder.Obj) {
+				continue
+			}
+			if !hasLength(info, loop.X) {
+				break
+			}
+			pos := fset.Position(loop.Pos())
+			optimizations = append(optimizations, &models.Optimization{
+				File:        pos.Filename,
+				Line:        pos.Line,
+				Description: fmt.Sprintf("%s %s is written to in a loop over %s without being pre-sized; call %s.Grow(len(%s) * n) before the loop, with n the average size written per element", typeName, builder.Name, types.ExprString(loop.X), builder.Name, types.ExprString(loop.X)),
+				Benefit:     "Fewer reallocations and copies of the buffer as it grows",
+			})
+			break
+		}
+	}
+
+	return optimizations
+}
+
+// declaredBuilder returns the variable declared by a statement such as var b strings.Builder,
+// b := bytes.Buffer{}, or b := new(strings.Builder), with the name of its type, or nil
+func declaredBuilder(stmt ast.Stmt) (*ast.Ident, string) {
+	var name *ast.Ident
+	var typ ast.Expr
+	switch s := stmt.(type) {
+	case *ast.DeclStmt:
+		gen, ok := s.Decl.(*ast.GenDecl)
+		if !ok || len(gen.Specs) != 1 {
+			return nil, ""
+		}
+		spec, ok := gen.Specs[0].(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 || len(spec.Values) != 0 {
+			return nil, ""
+		}
+		name, typ = spec.Names[0], spec.Type
+	case *ast.AssignStmt:
+		if s.Tok != token.DEFINE || len(s.Lhs) != 1 || len(s.Rhs) != 1 {
+			return nil, ""
+		}
+		name, _ = s.Lhs[0].(*ast.Ident)
+		value := s.Rhs[0]
+		if unary, ok := value.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			value = unary.X
+		}
+		switch v := value.(type) {
+		case *ast.CompositeLit:
+			if len(v.Elts) == 0 {
+				typ = v.Type
+			}
+		case *ast.CallExpr:
+			if isIdent(v.Fun, "new") && len(v.Args) == 1 {
+				typ = v.Args[0]
+			}
+		}
+	}
+	if name == nil || name.Obj == nil || typ == nil {
+		return nil, ""
+	}
+
+	sel, ok := typ.(*ast.SelectorExpr)
+	if !ok {
+		return nil, ""
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || builderTypes[pkg.Name] != sel.Sel.Name {
+		return nil, ""
+	}
+	return name, pkg.Name + "." + sel.Sel.Name
+}
+
+// writesBuilder reports whether a node calls a Write method of a builder or prints to it
+// with fmt.Fprint, Fprintf, or Fprintln
+func writesBuilder(node ast.Node, builder *ast.Object) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || found {
+			return !found
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == builder && strings.HasPrefix(sel.Sel.Name, "Write") {
+			found = true
+		}
+		if isIdent(sel.X, "fmt") && strings.HasPrefix(sel.Sel.Name, "Fprint") && len(call.Args) > 0 {
+			arg := call.Args[0]
+			if unary, ok := arg.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+				arg = unary.X
+			}
+			if ident, ok := arg.(*ast.Ident); ok && ident.Obj == builder {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// growsBuilder reports whether a statement calls the Grow method of a builder
+func growsBuilder(stmt ast.Stmt, builder *ast.Object) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "Grow" {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == builder {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// hasLength reports whether the operand of a range loop is a variable or field that len
+// can be called on to know the number of iterations in advance
+func hasLength(info *types.Info, expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		if !isSimpleExpr(expr) {
+			return false
+		}
+	default:
+		return false
+	}
+	if info == nil || info.TypeOf(expr) == nil {
+		return true
+	}
+
+	switch t := info.TypeOf(expr).Underlying().(type) {
+	case *types.Slice, *types.Array, *types.Map:
+		return true
+	case *types.Pointer:
+		_, ok := t.Elem().Underlying().(*types.Array)
+		return ok
+	}
+	return false
+}
+
+// logFunctions are the functions of the log and fmt packages that format and write a line
+var logFunctions = map[string]map[string]bool{
+	"log": {"Print": true, "Printf": true, "Println": true},
+	"fmt": {"Print": true, "Printf": true, "Println": true, "Fprint": true, "Fprintf": true, "Fprintln": true},
+}
+
+// detectLogInLoop detects the log and fmt print functions called on every iteration of a
+// loop. Calls under an if, switch, or select, such as logging an error or logging when a
+// debug flag is set, are left alone; so are the loops nested in the body, which are
+// inspected on their own.
+func detectLogInLoop(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
+	var body *ast.BlockStmt
+	switch loop := node.(type) {
+	case *ast.ForStmt:
+		body = loop.Body
+	case *ast.RangeStmt:
+		body = loop.Body
+	default:
+		return nil
+	}
+
+	call, name := unconditionalLog(info, body.List)
+	if call == nil {
+		return nil
+	}
+	pos := fset.Position(call.Pos())
+	return []*models.Optimization{{
+		File:        pos.Filename,
+		Line:        pos.Line,
+		Description: fmt.Sprintf("%s is called on every iteration of a loop; move it out of the loop or log only a sample of the iterations", name),
+		Benefit:     "Less time spent formatting and writing output, which can dominate the runtime of a tight loop",
+	}}
+}
+
+// unconditionalLog returns the first call to a log function among statements that run
+// whenever the statements before them do, with the name of the function
+func unconditionalLog(info *types.Info, stmts []ast.Stmt) (*ast.CallExpr, string) {
+	for _, stmt := range stmts {
+		for {
+			labeled, ok := stmt.(*ast.LabeledStmt)
+			if !ok {
+				break
+			}
+			stmt = labeled.Stmt
+		}
+
+		switch s := stmt.(type) {
+		case *ast.BlockStmt:
+			if call, name := unconditionalLog(info, s.List); call != nil {
+				return call, name
+			}
+		case *ast.ExprStmt:
+			call, ok := s.X.(*ast.CallExpr)
+			if !ok {
+				continue
+			}
+			if name := logFunction(info, call); name != "" {
+				return call, name
+			}
+		}
+	}
+	return nil, ""
+}
+
+// logFunction returns the name of the log function a call calls, such as log.Printf, or an
+// empty string. fmt.Fprint and its variants only log when they print to os.Stdout or
+// os.Stderr.
+func logFunction(info *types.Info, call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || !logFunctions[pkg.Name][sel.Sel.Name] {
+		return ""
+	}
+	if info != nil {
+		if name, ok := info.Uses[pkg].(*types.PkgName); !ok || name.Imported().Path() != pkg.Name {
+			return ""
+		}
+	}
+
+	if strings.HasPrefix(sel.Sel.Name, "Fprint") {
+		if len(call.Args) == 0 {
+			return ""
+		}
+		out, ok := call.Args[0].(*ast.SelectorExpr)
+		if !ok || !isIdent(out.X, "os") || (out.Sel.Name != "Stdout" && out.Sel.Name != "Stderr") {
+			return ""
+		}
+	}
+	return pkg.Name + "." + sel.Sel.Name
+}
+
+// detectTimeNowInLoop detects time.Now assigned to a variable or field on every iteration
+// of a loop, which gives each iteration a slightly different timestamp where the loop may
+// be meant to stamp everything with the same one. Loops that measure elapsed time with
+// time.Since, time.Until, or Sub, or that wait with time.Sleep or on channels, need the
+// current time on each iteration and are left alone.
+func detectTimeNowInLoop(fset *token.FileSet, info *types.Info, node ast.Node) []*models.Optimization {
+	var body *ast.BlockStmt
+	switch loop := node.(type) {
+	case *ast.ForStmt:
+		body = loop.Body
+	case *ast.RangeStmt:
+		if info != nil && info.TypeOf(loop.X) != nil {
+			if _, ok := info.TypeOf(loop.X).Underlying().(*types.Chan); ok {
+				return nil
+			}
+		}
+		body = loop.Body
+	default:
+		return nil
+	}
+
+	var assigned ast.Expr
+	var call *ast.CallExpr
+	waits := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit, *ast.ForStmt, *ast.RangeStmt:
+			// Inspected on their own
+			return false
+		case *ast.SelectStmt:
+			waits = true
+		case *ast.UnaryExpr:
+			waits = waits || n.Op == token.ARROW
+		case *ast.AssignStmt:
+			if call == nil && len(n.Lhs) == 1 && len(n.Rhs) == 1 && timeFunction(info, n.Rhs[0], "Now") {
+				assigned, call = n.Lhs[0], n.Rhs[0].(*ast.CallExpr)
+			}
+		case *ast.CallExpr:
+			if timeFunction(info, n, "Since") || timeFunction(info, n, "Until") || timeFunction(info, n, "Sleep") {
+				waits = true
+			}
+			if sel, ok := n.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Sub" {
+				waits = true
+			}
+		}
+		return true
+	})
+	if call == nil || waits {
+		return nil
+	}
+
+	pos := fset.Position(call.Pos())
+	return []*models.Optimization{{
+		File:        pos.Filename,
+		Line:        pos.Line,
+		Description: fmt.Sprintf("time.Now() is assigned to %s on every iteration of the loop; if one timestamp is intended for all iterations, call time.Now() once before the loop", types.ExprString(assigned)),
+		Benefit:     "Consistent timestamps across the iterations and fewer clock reads",
+	}}
+}
+
+// timeFunction reports whether an expression is a call to the function of the time package
+// with the given name
+func timeFunction(info *types.Info, expr ast.Expr, name string) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || pkg.Name != "time" {
+		return false
+	}
+	if info != nil {
+		if name, ok := info.Uses[pkg].(*types.PkgName); !ok || name.Imported().Path() != "time" {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,106 @@
+package ml
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// trendWindow is the age below which records count as recent in the trend of a rule
+	trendWindow = 30 * 24 * time.Hour
+
+	// trendThreshold is the change of acceptance rate between older and recent records
+	// from which a rule is rising or falling rather than steady
+	trendThreshold = 0.1
+
+	// minDisableRecords is the number of records a rule needs before disabling it is suggested
+	minDisableRecords = 10
+)
+
+// RuleStats summarizes the learning data recorded for a rule
+type RuleStats struct {
+	Rule           string
+	Records        int
+	Accepted       int
+	RecentRecords  int // Records of the last 30 days
+	RecentAccepted int
+	OlderRecords   int
+	OlderAccepted  int
+}
+
+// Rate returns the acceptance rate of all records of the rule
+func (s *RuleStats) Rate() float64 {
+	return rate(s.Accepted, s.Records)
+}
+
+// Trend returns how the acceptance rate of the last 30 days compares to the one of older
+// records: rising, falling, or steady, or an empty string when either has no records
+func (s *RuleStats) Trend() string {
+	if s.RecentRecords == 0 || s.OlderRecords == 0 {
+		return ""
+	}
+	change := rate(s.RecentAccepted, s.RecentRecords) - rate(s.OlderAccepted, s.OlderRecords)
+	switch {
+	case change >= trendThreshold:
+		return "rising"
+	case change <= -trendThreshold:
+		return "falling"
+	}
+	return "steady"
+}
+
+// SuggestDisabling reports whether the rule has enough records with an acceptance rate
+// low enough for FilterIssues to drop its issues, so running it is wasted
+func (s *RuleStats) SuggestDisabling() bool {
+	return s.Records >= minDisableRecords && s.Rate() < filterAcceptanceRate
+}
+
+// rate returns accepted relative to records, 0 without records
+func rate(accepted, records int) float64 {
+	if records == 0 {
+		return 0
+	}
+	return float64(accepted) / float64(records)
+}
+
+// RuleStats returns the statistics of every rule with learning data, with the records
+// recorded since now minus 30 days as recent, sorted by increasing acceptance rate and then
+// by rule
+func (c *DataCollector) RuleStats(now time.Time) []*RuleStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := make([]*RuleStats, 0, len(c.issueData))
+	for ruleID, records := range c.issueData {
+		if len(records) == 0 {
+			continue
+		}
+		s := &RuleStats{Rule: ruleID, Records: len(records)}
+		for _, data := range records {
+			recent := now.Sub(data.Timestamp) < trendWindow
+			if recent {
+				s.RecentRecords++
+			} else {
+				s.OlderRecords++
+			}
+			if !data.Accepted {
+				continue
+			}
+			s.Accepted++
+			if recent {
+				s.RecentAccepted++
+			} else {
+				s.OlderAccepted++
+			}
+		}
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Rate() != stats[j].Rate() {
+			return stats[i].Rate() < stats[j].Rate()
+		}
+		return stats[i].Rule < stats[j].Rule
+	})
+	return stats
+}
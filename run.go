@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/user/code-review-assistant/internal/config"
+	"github.com/user/code-review-assistant/internal/ml"
+	"github.com/user/code-review-assistant/internal/models"
+	"github.com/user/code-review-assistant/internal/scanner"
+)
+
+// Run scans a repository, analyzes the files found and, when learning is enabled, adjusts
+// the results using the recorded feedback. It does not print anything; problems that do not
+// prevent analysis are reported in Results.Warnings. If the context is cancelled, the
+// results gathered so far are returned along with the context's error.
+func Run(ctx context.Context, repoPath string, cfg *config.Config) (*Results, error) {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+
+	// Analyze files as the scanner finds them, timing the scan until the walk ends
+	start := time.Now()
+	files, scanErrs := scanner.NewScanner(repoPath, cfg).Stream(ctx)
+	scanned := make(chan error, 1)
+	var scanTime time.Duration
+	go func() {
+		err := <-scanErrs
+		scanTime = time.Since(start)
+		scanned <- err
+	}()
+	results, err := NewAnalyzer(repoPath, cfg).AnalyzeStream(ctx, files)
+	scanErr := <-scanned
+	if err != nil {
+		// Partial results on cancellation
+		return results, err
+	}
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	results.Timings.Scan = scanTime
+
+	return finish(results, repoPath, cfg), nil
+}
+
+// RunFiles is like Run, but analyzes only the given files of the repository instead of
+// walking it. Relative paths are resolved against the working directory, and files that
+// the exclude and size rules skip are not analyzed. gosec, when enabled, scans the
+// packages of the files and only its issues in the files are kept.
+func RunFiles(ctx context.Context, repoPath string, paths []string, cfg *config.Config) (*Results, error) {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	repoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	files, err := scanner.NewScanner(repoPath, cfg).Files(paths)
+	if err != nil {
+		return nil, err
+	}
+	scanTime := time.Since(start)
+
+	codeAnalyzer := NewAnalyzer(repoPath, cfg)
+	codeAnalyzer.only = make(map[string]bool)
+	for _, file := range files {
+		codeAnalyzer.only[file.RelPath] = true
+	}
+	results, err := codeAnalyzer.Analyze(ctx, files)
+	if err != nil {
+		// Partial results on cancellation
+		return results, err
+	}
+	results.Timings.Scan = scanTime
+
+	return finish(results, repoPath, cfg), nil
+}
+
+// RunSource is like RunFiles for a single file whose contents are given instead of read
+// from disk, such as an unsaved editor buffer piped by an editor. Issues refer to the file
+// by name as given, and gosec, which needs the files on disk, is not run.
+func RunSource(ctx context.Context, repoPath, name string, content []byte, cfg *config.Config) (*Results, error) {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := bytes.Count(content, []byte{'\n'})
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	file := &models.File{
+		Path:    path,
+		RelPath: name,
+		Size:    int64(len(content)),
+		Lines:   lines,
+	}
+
+	start := time.Now()
+	results, err := NewAnalyzer(repoPath, cfg).AnalyzeSource(ctx, file, content)
+	if err != nil {
+		return nil, err
+	}
+	results.Timings.Analysis = time.Since(start)
+
+	return finish(results, repoPath, cfg), nil
+}
+
+// finish filters the results of an analysis and applies machine learning when it is enabled
+func finish(results *Results, repoPath string, cfg *config.Config) *Results {
+	// Filter before learning so that only the issues shown are recorded and counted
+	results.Filter(cfg)
+
+	if cfg.EnableLearning {
+		start := time.Now()
+		if err := applyLearning(results, repoPath, cfg); err != nil {
+			results.Warnings = append(results.Warnings, fmt.Sprintf("failed to apply machine learning: %v", err))
+		}
+		results.Timings.Learning = time.Since(start)
+	}
+
+	return results
+}
+
+// applyLearning adjusts, filters, and sorts issues based on learning data, collects project
+// insights, and records the issues for future learning
+func applyLearning(results *Results, repoPath string, cfg *config.Config) error {
+	engine, err := ml.NewLearningEngine(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create learning engine: %w", err)
+	}
+	defer engine.Close()
+
+	// Adjust issue confidence, dropping issues that fall below the minimum confidence
+	for _, issue := range results.Issues {
+		engine.AdjustIssueConfidence(issue)
+	}
+	results.Filter(cfg)
+
+	// Get project insights before dropping unlikely issues
+	insights := engine.AnalyzeProjectPatterns(repoPath, results.Issues)
+
+	// Filter and sort issues
+	results.Issues = engine.SortIssues(engine.FilterIssues(results.Issues))
+	results.Recount()
+
+	// Add custom rule suggestions
+	if customRules := engine.SuggestCustomRules(); len(customRules) > 0 {
+		insights = append(insights, "Suggested custom rules:")
+		insights = append(insights, customRules...)
+	}
+	results.Insights = insights
+
+	// Record issues for learning
+	if err := engine.RecordIssues(results.Issues, repoPath); err != nil {
+		results.Warnings = append(results.Warnings, fmt.Sprintf("failed to record issues for learning: %v", err))
+	}
+
+	return nil
+}
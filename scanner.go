@@ -1,7 +1,10 @@
 package scanner
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,6 +13,9 @@ import (
 	"github.com/user/code-review-assistant/internal/models"
 )
 
+// streamBufferSize is the number of scanned files buffered ahead of the consumer in Stream
+const streamBufferSize = 64
+
 // Scanner is responsible for scanning repositories and finding files to analyze
 type Scanner struct {
 	rootPath string
@@ -26,18 +32,71 @@ func NewScanner(rootPath string, cfg *config.Config) *Scanner {
 
 // Scan scans the repository and returns a list of files to analyze
 func (s *Scanner) Scan() ([]*models.File, error) {
+	var files []*models.File
+	
+	err := s.Walk(func(file *models.File) error {
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return files, nil
+}
+
+// Stream scans the repository in the background and sends each file to analyze on the
+// returned channel, so analysis can start before the walk completes. The file channel is
+// closed when the walk ends or the context is cancelled; the error channel then receives
+// the scan error, if any. The file channel must be drained.
+func (s *Scanner) Stream(ctx context.Context) (<-chan *models.File, <-chan error) {
+	files := make(chan *models.File, streamBufferSize)
+	errs := make(chan error, 1)
+	
+	go func() {
+		defer close(errs)
+		defer close(files)
+		
+		errs <- s.Walk(func(file *models.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			select {
+			case files <- file:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	
+	return files, errs
+}
+
+// Walk scans the repository and calls fn for each file to analyze. Scanning stops at
+// the first error returned by fn.
+func (s *Scanner) Walk(fn func(file *models.File) error) error {
+	return s.walk(s.file, fn)
+}
+
+// WalkSecretFiles is like Walk, but calls fn for the files that are not Go source and are
+// scanned for secrets, those that Config.SecretScanFile selects
+func (s *Scanner) WalkSecretFiles(fn func(file *models.File) error) error {
+	return s.walk(s.secretFile, fn)
+}
+
+// walk scans the repository and calls fn for each file that selectFile returns
+func (s *Scanner) walk(selectFile func(path string, info os.FileInfo) (*models.File, string), fn func(file *models.File) error) error {
 	// Check if root path exists
 	info, err := os.Stat(s.rootPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to access repository path: %w", err)
+		return fmt.Errorf("failed to access repository path: %w", err)
 	}
 	
 	if !info.IsDir() {
-		return nil, fmt.Errorf("repository path is not a directory: %s", s.rootPath)
+		return fmt.Errorf("repository path is not a directory: %s", s.rootPath)
 	}
 	
-	var files []*models.File
-	
 	// Walk through the directory tree
 	err = filepath.Walk(s.rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -47,72 +106,263 @@ func (s *Scanner) Scan() ([]*models.File, error) {
 		// Skip directories
 		if info.IsDir() {
 			// Check if directory should be excluded
-			for _, excludeDir := range s.config.ExcludeDirs {
-				if info.Name() == excludeDir {
-					if s.config.Verbose {
-						fmt.Printf("Skipping excluded directory: %s\n", path)
-					}
-					return filepath.SkipDir
+			if s.excludedDir(info.Name()) {
+				if s.config.Verbose {
+					fmt.Printf("Skipping excluded directory: %s\n", path)
 				}
+				return filepath.SkipDir
 			}
 			return nil
 		}
 		
-		// Skip files that are too large
-		if info.Size() > s.config.MaxFileSize {
-			if s.config.Verbose {
-				fmt.Printf("Skipping file (too large): %s\n", path)
+		file, skipped := selectFile(path, info)
+		if file == nil {
+			if skipped != "" && s.config.Verbose {
+				fmt.Printf("Skipping %s: %s\n", skipped, path)
 			}
 			return nil
 		}
 		
-		// Skip test files if not included
-		if !s.config.IncludeTests && strings.HasSuffix(info.Name(), "_test.go") {
-			if s.config.Verbose {
-				fmt.Printf("Skipping test file: %s\n", path)
-			}
-			return nil
+		return fn(file)
+	})
+	
+	if err != nil {
+		return fmt.Errorf("failed to scan repository: %w", err)
+	}
+	
+	return nil
+}
+
+// WalkDirs calls fn for the root and every directory below it that is not excluded
+func (s *Scanner) WalkDirs(fn func(path string) error) error {
+	return filepath.Walk(s.rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
 		}
-		
-		// Check file extension (only .go files for now)
-		if !strings.HasSuffix(info.Name(), ".go") {
+		if !info.IsDir() {
 			return nil
 		}
-		
-		// Check if file should be excluded
-		for _, excludeFile := range s.config.ExcludeFiles {
-			if info.Name() == excludeFile {
-				if s.config.Verbose {
-					fmt.Printf("Skipping excluded file: %s\n", path)
-				}
-				return nil
-			}
+		if path != s.rootPath && s.excludedDir(info.Name()) {
+			return filepath.SkipDir
 		}
-		
-		// Add file to the list
-		relPath, err := filepath.Rel(s.rootPath, path)
+		return fn(path)
+	})
+}
+
+// Lookup returns the file at path if it is one the scanner would analyze, or nil otherwise
+func (s *Scanner) Lookup(path string) (*models.File, error) {
+	relPath, err := filepath.Rel(s.rootPath, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return nil, nil
+	}
+	
+	// Files below an excluded directory are never analyzed
+	if s.Excluded(filepath.Dir(path)) {
+		return nil, nil
+	}
+	
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, nil
+	}
+	
+	file, _ := s.file(path, info)
+	return file, nil
+}
+
+// Files returns the files to analyze among an explicit list of paths, without walking the
+// repository. Relative paths are resolved against the working directory. Files that the
+// exclude and size rules skip are left out; paths outside the repository are an error.
+func (s *Scanner) Files(paths []string) ([]*models.File, error) {
+	var files []*models.File
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
 		if err != nil {
-			relPath = path
+			return nil, err
 		}
-		
-		file := &models.File{
-			Path:     path,
-			RelPath:  relPath,
-			Size:     info.Size(),
-			ModTime:  info.ModTime(),
-			IsVendor: strings.Contains(path, "vendor/"),
+		relPath, err := filepath.Rel(s.rootPath, absPath)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is not in the repository %s", path, s.rootPath)
 		}
 		
+		file, err := s.Lookup(absPath)
+		if err != nil {
+			return nil, err
+		}
+		if file == nil {
+			if s.config.Verbose {
+				fmt.Printf("Skipping %s\n", relPath)
+			}
+			continue
+		}
 		files = append(files, file)
-		
-		return nil
-	})
+	}
+	
+	return files, nil
+}
+
+// Excluded reports whether a directory of the repository is excluded or is inside an
+// excluded directory
+func (s *Scanner) Excluded(dir string) bool {
+	relPath, err := filepath.Rel(s.rootPath, dir)
+	if err != nil || relPath == "." {
+		return false
+	}
+	
+	for _, name := range strings.Split(relPath, string(filepath.Separator)) {
+		if s.excludedDir(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedDir checks if a directory with the given name should be skipped
+func (s *Scanner) excludedDir(name string) bool {
+	for _, excludeDir := range s.config.ExcludeDirs {
+		if name == excludeDir {
+			return true
+		}
+	}
+	return false
+}
+
+// file returns the file to analyze for a path, or nil along with a description of the
+// skipped file, which is empty for files that are not Go source files
+func (s *Scanner) file(path string, info os.FileInfo) (*models.File, string) {
+	// Skip files that are too large
+	if info.Size() > s.config.MaxFileSize {
+		return nil, "file (too large)"
+	}
+	
+	// Skip test files if not included
+	if !s.config.IncludeTests && strings.HasSuffix(info.Name(), "_test.go") {
+		return nil, "test file"
+	}
+	
+	// Check file extension (only .go files for now)
+	if !strings.HasSuffix(info.Name(), ".go") {
+		return nil, ""
+	}
+	
+	// Check if file should be excluded
+	for _, excludeFile := range s.config.ExcludeFiles {
+		if info.Name() == excludeFile {
+			return nil, "excluded file"
+		}
+	}
 	
+	relPath, err := filepath.Rel(s.rootPath, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan repository: %w", err)
+		relPath = path
 	}
 	
-	return files, nil
+	// Skip generated and binary files
+	if skip, reason := s.shouldSkipContent(path, relPath); skip {
+		return nil, reason + " file"
+	}
+	
+	// Unreadable files are reported by the analyzer
+	lines, _ := countLines(path)
+	
+	return &models.File{
+		Path:     path,
+		RelPath:  relPath,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		IsVendor: strings.Contains(path, "vendor/"),
+		Lines:    lines,
+	}, ""
+}
+
+// secretFile returns the file to scan for secrets for a path, or nil along with a
+// description of the skipped file, which is empty for files that are not scanned
+func (s *Scanner) secretFile(path string, info os.FileInfo) (*models.File, string) {
+	if strings.HasSuffix(info.Name(), ".go") || !s.config.SecretScanFile(info.Name()) {
+		return nil, ""
+	}
+	if info.Size() > s.config.MaxFileSize {
+		return nil, "file (too large)"
+	}
+	for _, excludeFile := range s.config.ExcludeFiles {
+		if info.Name() == excludeFile {
+			return nil, "excluded file"
+		}
+	}
+
+	relPath, err := filepath.Rel(s.rootPath, path)
+	if err != nil {
+		relPath = path
+	}
+	if _, binary, err := inspectHeader(path); err == nil && binary {
+		return nil, "binary file"
+	}
+
+	lines, _ := countLines(path)
+	return &models.File{
+		Path:     path,
+		RelPath:  relPath,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		IsVendor: strings.Contains(path, "vendor/"),
+		Lines:    lines,
+	}, ""
+}
+
+// countLines returns the number of lines in a file, counting a last line without a
+// trailing newline
+func countLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	
+	lines := 0
+	last := byte('\n')
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if last != '\n' {
+		lines++
+	}
+	
+	return lines, nil
+}
+
+// shouldSkipContent checks if a file is generated or binary and returns the reason for skipping it
+func (s *Scanner) shouldSkipContent(path, relPath string) (bool, string) {
+	if s.config.SkipGenerated && matchesGeneratedPattern(relPath, s.config.GeneratedPatterns) {
+		return true, "generated"
+	}
+	
+	generated, binary, err := inspectHeader(path)
+	if err != nil {
+		// Unreadable files are reported by the analyzer
+		return false, ""
+	}
+	if binary {
+		return true, "binary"
+	}
+	if s.config.SkipGenerated && generated {
+		return true, "generated"
+	}
+	
+	return false, ""
 }
 
 // GetRepositoryInfo returns information about the repository
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/user/code-review-assistant/internal/analyzer"
+	"github.com/user/code-review-assistant/internal/config"
+)
+
+// TokenEnv is the environment variable holding the token of the server when the
+// configuration has none
+const TokenEnv = "REVIEW_SERVER_TOKEN"
+
+// maxRequestSize is the maximum size in bytes of the body of an analysis request
+const maxRequestSize = 64 * 1024
+
+// cloneSchemes are the prefixes of the repository URLs that are cloned. Other transports
+// of git, such as ext::, can run commands and are refused.
+var cloneSchemes = []string{"https://", "http://", "ssh://", "git://", "git@"}
+
+// Request is the body of a POST to /analyze. Exactly one of Path and URL must be set.
+type Request struct {
+	Path string `json:"path,omitempty"` // Repository on the server to analyze
+	URL  string `json:"url,omitempty"`  // Git repository to clone into a temporary directory and analyze
+}
+
+// errorResponse is the body of the responses to failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server analyzes repositories on request over HTTP. Clients must send the token of the
+// server as a bearer token, or as the password of basic authentication. The analysis
+// results are returned in the format of -format json.
+type Server struct {
+	config *config.Config
+	token  string
+	mux    *http.ServeMux
+}
+
+// NewServer creates a server analyzing repositories with cfg. If token is empty, the
+// server_token of the configuration is used, and then the REVIEW_SERVER_TOKEN environment
+// variable; a server without a token is refused, since it can read any repository on
+// the machine.
+func NewServer(cfg *config.Config, token string) (*Server, error) {
+	if token == "" {
+		token = cfg.ServerToken
+	}
+	if token == "" {
+		token = os.Getenv(TokenEnv)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("a token is required, set server_token in the configuration or %s", TokenEnv)
+	}
+
+	s := &Server{config: cfg, token: token, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/analyze", s.handleAnalyze)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="code-review-assistant"`)
+		writeJSON(w, http.StatusUnauthorized, &errorResponse{Error: "missing or invalid token"})
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized reports whether a request carries the token of the server
+func (s *Server) authorized(r *http.Request) bool {
+	given := ""
+	if _, password, ok := r.BasicAuth(); ok {
+		given = password
+	} else if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		given = bearer
+	}
+	return given != "" && subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) == 1
+}
+
+// handleAnalyze analyzes the repository of a request and writes the results
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "use POST"})
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if (req.Path == "") == (req.URL == "") {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: "exactly one of path and url is required"})
+		return
+	}
+
+	repoPath := req.Path
+	if req.URL != "" {
+		dir, err := clone(r.Context(), req.URL)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, &errorResponse{Error: err.Error()})
+			return
+		}
+		defer os.RemoveAll(dir)
+		repoPath = dir
+	} else if info, err := os.Stat(repoPath); err != nil || !info.IsDir() {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: fmt.Sprintf("%s is not a directory", repoPath)})
+		return
+	}
+
+	results, err := analyzer.Run(r.Context(), repoPath, s.config)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, context.Canceled) {
+			// The client went away, the response will not be read
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, &errorResponse{Error: fmt.Sprintf("analysis failed: %v", err)})
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// clone clones the default branch of a git repository into a new temporary directory and
+// returns it. The caller removes the directory.
+func clone(ctx context.Context, url string) (string, error) {
+	allowed := false
+	for _, scheme := range cloneSchemes {
+		allowed = allowed || strings.HasPrefix(url, scheme)
+	}
+	if !allowed {
+		return "", fmt.Errorf("unsupported repository URL %q (must start with %s)", url, strings.Join(cloneSchemes, ", "))
+	}
+
+	dir, err := os.MkdirTemp("", "review-clone-*")
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", "--depth", "1", "--", url, dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to clone %s: %v: %s", url, err, strings.TrimSpace(string(output)))
+	}
+	return dir, nil
+}
+
+// writeJSON writes a value as the JSON body of a response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
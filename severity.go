@@ -0,0 +1,31 @@
+package models
+
+// SeverityScore returns a numeric score for a severity level (critical=4, high=3, medium=2, low=1)
+func SeverityScore(severity string) int {
+	switch severity {
+	case "critical":
+		return 4
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ConfidenceScore returns a numeric score for a confidence level (high=3, medium=2, low=1)
+func ConfidenceScore(confidence string) int {
+	switch confidence {
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	default:
+		return 0
+	}
+}
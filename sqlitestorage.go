@@ -0,0 +1,171 @@
+package ml
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/user/code-review-assistant/internal/models"
+
+	// Pure Go SQLite driver, registered as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the learning data tables. Each recorded issue is a row, so
+// parallel runs append to the database instead of overwriting each other's data.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS issues (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	fingerprint TEXT    NOT NULL,
+	rule        TEXT    NOT NULL,
+	file        TEXT    NOT NULL,
+	line        INTEGER NOT NULL,
+	col         INTEGER NOT NULL,
+	message     TEXT    NOT NULL,
+	category    TEXT    NOT NULL,
+	severity    TEXT    NOT NULL,
+	confidence  TEXT    NOT NULL,
+	suggestion  TEXT    NOT NULL,
+	code        TEXT    NOT NULL,
+	repository  TEXT    NOT NULL,
+	recorded_at INTEGER NOT NULL,
+	accepted    INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS issues_fingerprint ON issues (fingerprint);
+CREATE INDEX IF NOT EXISTS issues_rule ON issues (rule);
+
+CREATE TABLE IF NOT EXISTS feedback (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	fingerprint TEXT    NOT NULL,
+	accepted    INTEGER NOT NULL,
+	recorded_at INTEGER NOT NULL
+);
+`
+
+// sqliteStorage stores learning data in an SQLite database
+type sqliteStorage struct {
+	db *sql.DB
+}
+
+// openSQLiteStorage opens or creates an SQLite database
+func openSQLiteStorage(path string) (*sqliteStorage, error) {
+	// Wait for locks held by concurrent runs instead of failing immediately
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open learning database: %w", err)
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create learning database schema: %w", err)
+	}
+
+	return &sqliteStorage{db: db}, nil
+}
+
+// load reads all recorded issues
+func (s *sqliteStorage) load() (map[string][]models.LearningData, error) {
+	rows, err := s.db.Query(`SELECT rule, file, line, col, message, category, severity, confidence,
+		suggestion, code, repository, recorded_at, accepted FROM issues ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read learning data: %w", err)
+	}
+	defer rows.Close()
+
+	issueData := make(map[string][]models.LearningData)
+	for rows.Next() {
+		issue := &models.Issue{}
+		data := models.LearningData{Issue: issue}
+		var recordedAt int64
+		if err := rows.Scan(&issue.Rule, &issue.File, &issue.Line, &issue.Column, &issue.Message, &issue.Category,
+			&issue.Severity, &issue.Confidence, &issue.Suggestion, &issue.Code, &data.Repository, &recordedAt, &data.Accepted); err != nil {
+			return nil, fmt.Errorf("failed to read learning data: %w", err)
+		}
+		issue.ID = issue.Fingerprint()
+		data.Timestamp = time.Unix(0, recordedAt)
+
+		issueData[issue.Rule] = append(issueData[issue.Rule], data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read learning data: %w", err)
+	}
+
+	return issueData, nil
+}
+
+// addIssues inserts the new issues in a single transaction
+func (s *sqliteStorage) addIssues(all map[string][]models.LearningData, added []models.LearningData) error {
+	return s.merge(all, added, nil)
+}
+
+// merge inserts the imported issues and marks the accepted ones in a single transaction
+func (s *sqliteStorage) merge(all map[string][]models.LearningData, added []models.LearningData, accepted []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to write learning data: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO issues (fingerprint, rule, file, line, col, message, category, severity,
+		confidence, suggestion, code, repository, recorded_at, accepted) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to write learning data: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, data := range added {
+		issue := data.Issue
+		if _, err := stmt.Exec(issue.Fingerprint(), issue.Rule, issue.File, issue.Line, issue.Column, issue.Message,
+			issue.Category, issue.Severity, issue.Confidence, issue.Suggestion, issue.Code,
+			data.Repository, data.Timestamp.UnixNano(), data.Accepted); err != nil {
+			return fmt.Errorf("failed to write learning data: %w", err)
+		}
+	}
+	for _, issueID := range accepted {
+		if _, err := tx.Exec(`UPDATE issues SET accepted = 1 WHERE fingerprint = ?`, issueID); err != nil {
+			return fmt.Errorf("failed to write learning data: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write learning data: %w", err)
+	}
+	return nil
+}
+
+// setAccepted records the feedback and marks every matching issue
+func (s *sqliteStorage) setAccepted(all map[string][]models.LearningData, issueID string, accepted bool) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to write feedback: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE issues SET accepted = ? WHERE fingerprint = ?`, accepted, issueID)
+	if err != nil {
+		return false, fmt.Errorf("failed to write feedback: %w", err)
+	}
+	matched, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to write feedback: %w", err)
+	}
+	if matched == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`INSERT INTO feedback (fingerprint, accepted, recorded_at) VALUES (?, ?, ?)`,
+		issueID, accepted, time.Now().UnixNano()); err != nil {
+		return false, fmt.Errorf("failed to write feedback: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to write feedback: %w", err)
+	}
+	return true, nil
+}
+
+// close closes the database
+func (s *sqliteStorage) close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,106 @@
+package ml
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// storage persists learning data. Implementations receive both the complete data set
+// and the change, so they can either rewrite everything or apply the change only.
+type storage interface {
+	// load returns all recorded learning data keyed by rule ID
+	load() (map[string][]models.LearningData, error)
+	// addIssues persists newly recorded issues
+	addIssues(all map[string][]models.LearningData, added []models.LearningData) error
+	// setAccepted persists feedback for the issues with a fingerprint and reports whether any matched
+	setAccepted(all map[string][]models.LearningData, issueID string, accepted bool) (bool, error)
+	// merge persists imported issues and marks the issues with the given fingerprints accepted
+	merge(all map[string][]models.LearningData, added []models.LearningData, accepted []string) error
+	// close releases the storage
+	close() error
+}
+
+// newStorage opens the storage backend with the given name in a data directory
+func newStorage(backend, dataPath string) (storage, error) {
+	switch backend {
+	case "", "json":
+		return &jsonStorage{path: filepath.Join(dataPath, "learning_data.json")}, nil
+	case "sqlite":
+		return openSQLiteStorage(filepath.Join(dataPath, "learning_data.db"))
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %s", backend)
+	}
+}
+
+// jsonStorage stores all learning data in a single JSON file that is rewritten on every change
+type jsonStorage struct {
+	path string
+}
+
+// load reads the JSON file, if it exists
+func (s *jsonStorage) load() (map[string][]models.LearningData, error) {
+	issueData := make(map[string][]models.LearningData)
+
+	// Check if file exists
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return issueData, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read learning data: %w", err)
+	}
+
+	// Parse JSON
+	if err := json.Unmarshal(data, &issueData); err != nil {
+		return nil, fmt.Errorf("failed to parse learning data: %w", err)
+	}
+
+	return issueData, nil
+}
+
+// addIssues rewrites the JSON file
+func (s *jsonStorage) addIssues(all map[string][]models.LearningData, added []models.LearningData) error {
+	return s.save(all)
+}
+
+// setAccepted rewrites the JSON file if any issue matched
+func (s *jsonStorage) setAccepted(all map[string][]models.LearningData, issueID string, accepted bool) (bool, error) {
+	for _, issues := range all {
+		for _, data := range issues {
+			if data.Issue != nil && data.Issue.Fingerprint() == issueID {
+				return true, s.save(all)
+			}
+		}
+	}
+	return false, nil
+}
+
+// merge rewrites the JSON file
+func (s *jsonStorage) merge(all map[string][]models.LearningData, added []models.LearningData, accepted []string) error {
+	return s.save(all)
+}
+
+// save writes all learning data to the JSON file
+func (s *jsonStorage) save(all map[string][]models.LearningData) error {
+	// Marshal to JSON with indentation
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal learning data: %w", err)
+	}
+
+	// Write to file
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write learning data: %w", err)
+	}
+
+	return nil
+}
+
+// close is a no-op, the file is not kept open
+func (s *jsonStorage) close() error {
+	return nil
+}
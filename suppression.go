@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// suppressionDirective is the comment that silences issues on a line
+const suppressionDirective = "//review:ignore"
+
+// suppressions maps a line number to the rules suppressed on that line.
+// A nil rule set suppresses every rule on the line.
+type suppressions map[int]map[string]bool
+
+// parseSuppressions collects the //review:ignore directives of a file. A directive
+// trailing code applies to its own line, while a directive on a line of its own
+// applies to the line below it.
+func parseSuppressions(fset *token.FileSet, astFile *ast.File, content []byte) suppressions {
+	result := make(suppressions)
+
+	for _, group := range astFile.Comments {
+		for _, comment := range group.List {
+			rules, ok := parseDirective(comment.Text)
+			if !ok {
+				continue
+			}
+
+			pos := fset.Position(comment.Pos())
+			line := pos.Line
+			if startsLine(content, pos.Offset) {
+				line++
+			}
+			result.add(line, rules)
+		}
+	}
+
+	return result
+}
+
+// parseDirective parses a suppression comment, returning the rules it names
+// (nil for all rules) and whether the comment is a directive at all
+func parseDirective(text string) ([]string, bool) {
+	if !strings.HasPrefix(text, suppressionDirective) {
+		return nil, false
+	}
+
+	rest := text[len(suppressionDirective):]
+	if rest == "" {
+		return nil, true
+	}
+	if rest[0] != ' ' && rest[0] != '\t' {
+		// Some other directive, e.g. //review:ignored
+		return nil, false
+	}
+
+	// Only the first field lists rules; anything after it is a free-form reason
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return nil, true
+	}
+
+	var rules []string
+	for _, rule := range strings.Split(fields[0], ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, true
+}
+
+// startsLine checks if only whitespace precedes the given offset on its line
+func startsLine(content []byte, offset int) bool {
+	for i := offset - 1; i >= 0 && content[i] != '\n'; i-- {
+		if content[i] != ' ' && content[i] != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// add records suppressed rules for a line
+func (s suppressions) add(line int, rules []string) {
+	existing, seen := s[line]
+	if seen && existing == nil {
+		// Already suppressing everything
+		return
+	}
+	if len(rules) == 0 {
+		s[line] = nil
+		return
+	}
+
+	if existing == nil {
+		existing = make(map[string]bool)
+		s[line] = existing
+	}
+	for _, rule := range rules {
+		existing[rule] = true
+	}
+}
+
+// suppressed reports whether an issue is silenced by a directive
+func (s suppressions) suppressed(issue *models.Issue) bool {
+	rules, ok := s[issue.Line]
+	if !ok {
+		return false
+	}
+	return rules == nil || rules[issue.Rule]
+}
+
+// filter removes suppressed issues from a list
+func (s suppressions) filter(issues []*models.Issue) []*models.Issue {
+	if len(s) == 0 {
+		return issues
+	}
+
+	filtered := issues[:0]
+	for _, issue := range issues {
+		if !s.suppressed(issue) {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
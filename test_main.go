@@ -1,10 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"testing"
 
 	"github.com/user/code-review-assistant/internal/analyzer"
 	"github.com/user/code-review-assistant/internal/config"
@@ -236,10 +236,10 @@ func runTests(testDir string) error {
 	fmt.Printf("Found %d files to analyze\n", len(files))
 
 	// Initialize code analyzer
-	codeAnalyzer := analyzer.NewAnalyzer(cfg)
+	codeAnalyzer := analyzer.NewAnalyzer(testDir, cfg)
 
 	// Analyze files
-	results, err := codeAnalyzer.Analyze(files)
+	results, err := codeAnalyzer.Analyze(context.Background(), files)
 	if err != nil {
 		return fmt.Errorf("error analyzing code: %w", err)
 	}
@@ -0,0 +1,72 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/user/code-review-assistant/internal/config"
+	"github.com/user/code-review-assistant/internal/models"
+)
+
+// textSecretPatterns match assignments of secrets on the lines of configuration files, as
+// in API_KEY=value, api_key: "value", or "api_key": "value", capturing the value
+var textSecretPatterns = keyPatterns(`['"]?\s*[:=]\s*['"]?([^\s'",#][^\s'",]*)`)
+
+// TextSecretScanner scans files that are not Go source, such as .env files, YAML, and
+// Dockerfiles, for the hardcoded secrets of rule CS001
+type TextSecretScanner struct {
+	minEntropy float64
+	allowlist  []*regexp.Regexp
+}
+
+// NewTextSecretScanner creates a scanner with the secret settings of the configuration
+func NewTextSecretScanner(cfg *config.Config) *TextSecretScanner {
+	return &TextSecretScanner{
+		minEntropy: cfg.SecretMinEntropy,
+		allowlist:  compileAllowlist(cfg.SecretAllowlist),
+	}
+}
+
+// Scan reads a file and returns its secrets as issues of file.RelPath
+func (s *TextSecretScanner) Scan(file *models.File) ([]*models.Issue, error) {
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.RelPath, err)
+	}
+	return s.ScanContent(file.RelPath, content), nil
+}
+
+// ScanContent returns the secrets assigned on the lines of content as issues of name.
+// Values that refer to a secret stored elsewhere, such as ${DB_PASSWORD} or
+// {{ .Values.password }}, are not secrets themselves and are left out.
+func (s *TextSecretScanner) ScanContent(name string, content []byte) []*models.Issue {
+	var issues []*models.Issue
+	lines := bufio.NewScanner(bytes.NewReader(content))
+	lines.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for number := 1; lines.Scan(); number++ {
+		line := lines.Text()
+
+		// Report every secret on the line, once even if several patterns match it
+		reported := make(map[int]bool)
+		for _, pattern := range textSecretPatterns {
+			for _, match := range pattern.FindAllStringSubmatchIndex(line, -1) {
+				if reported[match[2]] {
+					continue
+				}
+				reported[match[2]] = true
+				secret := line[match[2]:match[3]]
+				if strings.HasPrefix(secret, "$") || strings.HasPrefix(secret, "{{") || allowedSecret(s.allowlist, secret) {
+					continue
+				}
+				issue := secretIssue(name, number, match[2]+1, secret, s.minEntropy)
+				issue.Code = strings.TrimSpace(line)
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,162 @@
+package typecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// File is the syntax tree of a file in a type-checked package
+type File struct {
+	Syntax *ast.File
+	Info   *types.Info
+	// Directories of the package, of the packages of its module it imports, and of the
+	// module, whose files the type information depends on
+	Dirs []string
+}
+
+// LoadDir type-checks the package in a directory, and its tests, with overlay replacing
+// the contents of files on disk. It returns the syntax trees and type information of the
+// package's files by absolute path. Packages that have errors are left out; the error
+// describes the first package that could not be loaded, if any.
+func LoadDir(fset *token.FileSet, dir string, overlay map[string][]byte) (map[string]*File, error) {
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+		Dir:     dir,
+		Fset:    fset,
+		Tests:   true,
+		Overlay: overlay,
+		// Keep the object resolution of go/parser, which the syntax-based rules rely on
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			return parser.ParseFile(fset, filename, src, parser.AllErrors|parser.ParseComments)
+		},
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*File)
+	var loadErr error
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 || pkg.TypesInfo == nil {
+			if loadErr == nil && len(pkg.Errors) > 0 {
+				loadErr = fmt.Errorf("%s: %v", pkg.ID, pkg.Errors[0])
+			}
+			continue
+		}
+		dirs := moduleDirs(pkg)
+		for i, syntax := range pkg.Syntax {
+			// Test variants of a package repeat its files, the first one is kept
+			path := pkg.CompiledGoFiles[i]
+			if _, ok := files[path]; !ok {
+				files[path] = &File{Syntax: syntax, Info: pkg.TypesInfo, Dirs: dirs}
+			}
+		}
+	}
+
+	return files, loadErr
+}
+
+// moduleDirs returns the directories of a package and of the packages of the main module
+// it imports, directly or not, followed by the directory of the module. Packages of other
+// modules and of the standard library are left out, their versions being fixed by the
+// go.mod and go.sum files of the module.
+func moduleDirs(pkg *packages.Package) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	add := func(dir string) {
+		if dir != "" && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	visited := make(map[*packages.Package]bool)
+	var visit func(p *packages.Package)
+	visit = func(p *packages.Package) {
+		if visited[p] || (p != pkg && (p.Module == nil || !p.Module.Main)) {
+			return
+		}
+		visited[p] = true
+		for _, file := range p.GoFiles {
+			add(filepath.Dir(file))
+		}
+		for _, imported := range p.Imports {
+			visit(imported)
+		}
+	}
+	visit(pkg)
+
+	if pkg.Module != nil {
+		add(pkg.Module.Dir)
+	}
+	return dirs
+}
+
+// Cache type-checks the packages of files on demand, one directory at a time, and shares
+// the results between the files of a directory. It is safe for concurrent use.
+type Cache struct {
+	fset *token.FileSet
+	// OnError, if not nil, is called with the directory when a package cannot be loaded
+	OnError func(dir string, err error)
+
+	mu   sync.Mutex
+	dirs map[string]*cachedDir
+}
+
+// cachedDir holds the type-checked files of a directory once they are loaded
+type cachedDir struct {
+	once  sync.Once
+	files map[string]*File
+}
+
+// NewCache creates a cache that records positions in fset
+func NewCache(fset *token.FileSet) *Cache {
+	return &Cache{
+		fset: fset,
+		dirs: make(map[string]*cachedDir),
+	}
+}
+
+// File returns the type-checked syntax tree of a file, or nil when its package could not
+// be type-checked. A nil cache type-checks nothing.
+func (c *Cache) File(path string) *File {
+	if c == nil {
+		return nil
+	}
+	path = AbsPath(path)
+	dir := filepath.Dir(path)
+
+	c.mu.Lock()
+	d, ok := c.dirs[dir]
+	if !ok {
+		d = &cachedDir{}
+		c.dirs[dir] = d
+	}
+	c.mu.Unlock()
+
+	d.once.Do(func() {
+		files, err := LoadDir(c.fset, dir, nil)
+		if err != nil && c.OnError != nil {
+			c.OnError(dir, err)
+		}
+		d.files = files
+	})
+
+	return d.files[path]
+}
+
+// AbsPath returns the absolute form of a path, or the path itself if it cannot be determined
+func AbsPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,204 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/user/code-review-assistant/internal/config"
+	"github.com/user/code-review-assistant/internal/models"
+	"github.com/user/code-review-assistant/internal/scanner"
+)
+
+// watchDebounce is how long file events are collected before the changed files are
+// analyzed, since editors often write a file several times when saving it
+const watchDebounce = 100 * time.Millisecond
+
+// FileChange describes how the issues of a file changed after it was modified
+type FileChange struct {
+	File     string          // Path of the file relative to the repository root
+	Issues   []*models.Issue // Current issues of the file, empty if the file was removed
+	New      []*models.Issue // Issues that were not reported before the change
+	Resolved []*models.Issue // Issues that are no longer reported
+}
+
+// Watch analyzes a repository and then keeps watching it for changes to Go files, which
+// are analyzed again one by one. The scanner's exclude rules apply to the watched files.
+// ready, if not nil, is called with the results of the initial analysis, and onChange for
+// every analyzed change that added or resolved issues. gosec and the secret scan of files
+// that are not Go source are not run, as they scan the whole repository. Watch returns nil
+// once the context is cancelled.
+func Watch(ctx context.Context, repoPath string, cfg *config.Config, ready func(*Results), onChange func(*FileChange)) error {
+	watchCfg := *cfg
+	watchCfg.EnableGosec = false
+	watchCfg.ScanNonGoSecrets = false
+
+	repoScanner := scanner.NewScanner(repoPath, &watchCfg)
+	codeAnalyzer := NewAnalyzer(repoPath, &watchCfg)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watch directories before the initial analysis so no change is missed
+	if err := repoScanner.WalkDirs(watcher.Add); err != nil {
+		return fmt.Errorf("failed to watch repository: %w", err)
+	}
+
+	files, err := repoScanner.Scan()
+	if err != nil {
+		return err
+	}
+	results, err := codeAnalyzer.Analyze(ctx, files)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+
+	issues := make(map[string][]*models.Issue)
+	for _, issue := range results.Issues {
+		issues[issue.File] = append(issues[issue.File], issue)
+	}
+	if ready != nil {
+		ready(results)
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			if watchCfg.Verbose {
+				fmt.Fprintf(os.Stderr, "Error watching repository: %v\n", err)
+			}
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			// Watch new directories and analyze the files they already contain
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watchDir(repoScanner, watcher, event.Name, pending)
+					timer.Reset(watchDebounce)
+					continue
+				}
+			}
+
+			if strings.HasSuffix(event.Name, ".go") && !event.Has(fsnotify.Chmod) {
+				pending[event.Name] = true
+				timer.Reset(watchDebounce)
+			}
+
+		case <-timer.C:
+			for path := range pending {
+				change, err := reanalyze(ctx, repoScanner, codeAnalyzer, issues, path)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					if watchCfg.Verbose {
+						fmt.Fprintf(os.Stderr, "Error analyzing file %s: %v\n", path, err)
+					}
+					continue
+				}
+				if len(change.New) > 0 || len(change.Resolved) > 0 {
+					onChange(change)
+				}
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// watchDir adds a new directory and the directories below it to the watcher, and marks
+// the Go files already in them for analysis
+func watchDir(repoScanner *scanner.Scanner, watcher *fsnotify.Watcher, dir string, pending map[string]bool) {
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if repoScanner.Excluded(path) {
+				return filepath.SkipDir
+			}
+			watcher.Add(path)
+		} else if strings.HasSuffix(path, ".go") {
+			pending[path] = true
+		}
+		return nil
+	})
+}
+
+// reanalyze analyzes a changed file again and returns how its issues changed
+func reanalyze(ctx context.Context, repoScanner *scanner.Scanner, codeAnalyzer *Analyzer, issues map[string][]*models.Issue, path string) (*FileChange, error) {
+	file, err := repoScanner.Lookup(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	relPath, err := filepath.Rel(codeAnalyzer.rootPath, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files that were removed or are no longer analyzed resolve all their issues
+	var current []*models.Issue
+	if file != nil {
+		results, err := codeAnalyzer.Analyze(ctx, []*models.File{file})
+		if err != nil {
+			return nil, err
+		}
+		current = results.Issues
+	}
+
+	change := &FileChange{
+		File:     relPath,
+		Issues:   current,
+		New:      diffIssues(current, issues[relPath]),
+		Resolved: diffIssues(issues[relPath], current),
+	}
+	if len(current) > 0 {
+		issues[relPath] = current
+	} else {
+		delete(issues, relPath)
+	}
+
+	return change, nil
+}
+
+// diffIssues returns the issues of a that are not in b, comparing them by ID so that
+// issues which merely moved to another line are not reported
+func diffIssues(a, b []*models.Issue) []*models.Issue {
+	counts := make(map[string]int)
+	for _, issue := range b {
+		counts[issue.ID]++
+	}
+
+	var diff []*models.Issue
+	for _, issue := range a {
+		if counts[issue.ID] > 0 {
+			counts[issue.ID]--
+			continue
+		}
+		diff = append(diff, issue)
+	}
+	return diff
+}